@@ -0,0 +1,11 @@
+// Package api holds the descriptions of the Gopher API
+package api
+
+import (
+	_ "embed"
+)
+
+// OpenAPI is the OpenAPI 3.1 document describing the routes served by the server
+//
+//go:embed openapi.json
+var OpenAPI []byte
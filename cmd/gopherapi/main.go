@@ -1,73 +1,333 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/friendsofgo/gopherapi/pkg/storage/mysql"
-	"log"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/friendsofgo/gopherapi/cmd/sample-data"
 	gopher "github.com/friendsofgo/gopherapi/pkg"
 	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/exporting"
 	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/graphql"
+	gopherapigrpc "github.com/friendsofgo/gopherapi/pkg/grpc"
+	"github.com/friendsofgo/gopherapi/pkg/health"
+	"github.com/friendsofgo/gopherapi/pkg/importing"
+	"github.com/friendsofgo/gopherapi/pkg/log"
 	"github.com/friendsofgo/gopherapi/pkg/log/logrus"
+	"github.com/friendsofgo/gopherapi/pkg/log/slog"
+	"github.com/friendsofgo/gopherapi/pkg/log/zap"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
 	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
 	"github.com/friendsofgo/gopherapi/pkg/removing"
+	"github.com/friendsofgo/gopherapi/pkg/searching"
 	"github.com/friendsofgo/gopherapi/pkg/server"
 	"github.com/friendsofgo/gopherapi/pkg/storage/cockroach"
 	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+	"github.com/friendsofgo/gopherapi/pkg/storage/redis"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
 	"github.com/friendsofgo/gopherapi/pkg/tracer"
+	"github.com/friendsofgo/gopherapi/pkg/watching"
 	_ "github.com/joho/godotenv/autoload"
-	"github.com/openzipkin/zipkin-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"google.golang.org/grpc"
 )
 
 func main() {
 
 	var (
-		hostName, _     = os.Hostname()
-		defaultServerID = fmt.Sprintf("%s-%s", os.Getenv("GOPHERAPI_NAME"), hostName)
-		defaultHost     = os.Getenv("GOPHERAPI_SERVER_HOST")
-		defaultPort, _  = strconv.Atoi(os.Getenv("GOPHERAPI_SERVER_PORT"))
-		defaultDatabase = os.Getenv("GOPHERAPI_SERVER_PORT")
+		hostName, _        = os.Hostname()
+		defaultServerID    = fmt.Sprintf("%s-%s", os.Getenv("GOPHERAPI_NAME"), hostName)
+		defaultHost        = os.Getenv("GOPHERAPI_SERVER_HOST")
+		defaultPort, _     = strconv.Atoi(os.Getenv("GOPHERAPI_SERVER_PORT"))
+		defaultGRPCPort, _ = strconv.Atoi(os.Getenv("GOPHERAPI_GRPC_PORT"))
+		defaultDatabase    = os.Getenv("GOPHERAPI_SERVER_PORT")
 
-		zipkinURL = os.Getenv("ZIPKIN_ENDPOINT")
+		defaultLogBackend = os.Getenv("GOPHERAPI_LOG_BACKEND")
+		defaultLogLevel   = os.Getenv("GOPHERAPI_LOG_LEVEL")
+		defaultLogFormat  = os.Getenv("GOPHERAPI_LOG_FORMAT")
+
+		defaultAccessLogSampleRate = floatEnv("GOPHERAPI_ACCESS_LOG_SAMPLE_RATE", 1)
+		defaultAccessLogExclude    = os.Getenv("GOPHERAPI_ACCESS_LOG_EXCLUDE")
+
+		defaultValidateRequests = os.Getenv("GOPHERAPI_VALIDATE_REQUESTS") == "true"
+		defaultV1DeprecatedAt   = os.Getenv("GOPHERAPI_V1_DEPRECATED_AT")
+		defaultV1Sunset         = os.Getenv("GOPHERAPI_V1_SUNSET")
+
+		defaultGraphQLMaxDepth      = intEnv("GOPHERAPI_GRAPHQL_MAX_DEPTH", graphql.DefaultLimits.MaxDepth)
+		defaultGraphQLMaxComplexity = intEnv("GOPHERAPI_GRAPHQL_MAX_COMPLEXITY", graphql.DefaultLimits.MaxComplexity)
+
+		defaultTraceExporter      = os.Getenv("GOPHERAPI_TRACE_EXPORTER")
+		defaultTraceReporter      = os.Getenv("GOPHERAPI_TRACE_REPORTER")
+		defaultTraceKafkaTopic    = os.Getenv("GOPHERAPI_TRACE_KAFKA_TOPIC")
+		defaultTraceSampling      = os.Getenv("GOPHERAPI_TRACE_SAMPLING")
+		defaultTraceSampleRate    = floatEnv("GOPHERAPI_TRACE_SAMPLE_RATE", 1)
+		defaultTraceSampleRoutes  = os.Getenv("GOPHERAPI_TRACE_SAMPLE_ROUTES")
+		defaultTraceSampleErrors  = os.Getenv("GOPHERAPI_TRACE_SAMPLE_ERRORS") == "true"
+		defaultTraceBatchSize, _  = strconv.Atoi(os.Getenv("GOPHERAPI_TRACE_BATCH_SIZE"))
+		defaultTraceBatchInterval = durationEnv("GOPHERAPI_TRACE_BATCH_INTERVAL", 0)
+		defaultTraceReportTimeout = durationEnv("GOPHERAPI_TRACE_REPORT_TIMEOUT", 0)
+
+		defaultAPIKeysFile = os.Getenv("GOPHERAPI_API_KEYS_FILE")
+		defaultJWTKeysFile = os.Getenv("GOPHERAPI_JWT_KEYS_FILE")
+		defaultJWTIssuer   = os.Getenv("GOPHERAPI_JWT_ISSUER")
+		defaultJWTAudience = os.Getenv("GOPHERAPI_JWT_AUDIENCE")
+
+		defaultTenantHeader = os.Getenv("GOPHERAPI_TENANT_HEADER")
+		defaultTenantDomain = os.Getenv("GOPHERAPI_TENANT_DOMAIN")
+
+		defaultTrustedProxies  = os.Getenv("GOPHERAPI_TRUSTED_PROXIES")
+		defaultRateLimit       = os.Getenv("GOPHERAPI_RATE_LIMIT")
+		defaultRateLimitRoutes = os.Getenv("GOPHERAPI_RATE_LIMIT_ROUTES")
+		defaultRateLimitStore  = os.Getenv("GOPHERAPI_RATE_LIMIT_STORE")
+		defaultRateLimitIP     = os.Getenv("GOPHERAPI_RATE_LIMIT_IP")
+
+		defaultReadTimeout       = durationEnv("GOPHERAPI_READ_TIMEOUT", 5*time.Second)
+		defaultReadHeaderTimeout = durationEnv("GOPHERAPI_READ_HEADER_TIMEOUT", 2*time.Second)
+		defaultWriteTimeout      = durationEnv("GOPHERAPI_WRITE_TIMEOUT", 10*time.Second)
+		defaultIdleTimeout       = durationEnv("GOPHERAPI_IDLE_TIMEOUT", 120*time.Second)
+		defaultShutdownTimeout   = durationEnv("GOPHERAPI_SHUTDOWN_TIMEOUT", 30*time.Second)
+		defaultShutdownDelay     = durationEnv("GOPHERAPI_SHUTDOWN_DELAY", 0)
+		defaultHealthTimeout     = durationEnv("GOPHERAPI_HEALTH_TIMEOUT", 2*time.Second)
+		defaultMaxHeaderBytes, _ = strconv.Atoi(os.Getenv("GOPHERAPI_MAX_HEADER_BYTES"))
+		defaultMaxImportBytes, _ = strconv.ParseInt(os.Getenv("GOPHERAPI_MAX_IMPORT_BYTES"), 10, 64)
+
+		defaultTLSCert              = os.Getenv("GOPHERAPI_TLS_CERT")
+		defaultTLSKey               = os.Getenv("GOPHERAPI_TLS_KEY")
+		defaultTLSClientCA          = os.Getenv("GOPHERAPI_TLS_CLIENT_CA")
+		defaultTLSRequireClientCert = os.Getenv("GOPHERAPI_TLS_REQUIRE_CLIENT_CERT") == "true"
 	)
 
 	host := flag.String("host", defaultHost, "define host of the server")
 	port := flag.Int("port", defaultPort, "define port of the server")
+	grpcPort := flag.Int("grpc-port", defaultGRPCPort, "serve the gRPC api on the given port too, disabled when 0")
 	serverID := flag.String("server-id", defaultServerID, "define server identifier")
 	withData := flag.Bool("withData", false, "initialize the api with some gophers")
 	withTrace := flag.Bool("withTrace", false, "initialize the api with tracing")
+	traceExporter := flag.String("trace-exporter", defaultTraceExporter, "export the traces to zipkin (ZIPKIN_ENDPOINT) or to an OpenTelemetry collector with otlp (OTEL_EXPORTER_OTLP_ENDPOINT)")
+	traceReporter := flag.String("trace-reporter", defaultTraceReporter, "report the zipkin spans with http, log (standard output) or kafka (KAFKA_ADDRS)")
+	traceKafkaTopic := flag.String("trace-kafka-topic", defaultTraceKafkaTopic, "kafka topic where the zipkin spans are published, zipkin by default")
+	traceSampling := flag.String("trace-sampling", defaultTraceSampling, "sampling strategy of the traces, counting or boundary (consistent by trace ID)")
+	traceSampleRate := flag.Float64("trace-sample-rate", defaultTraceSampleRate, "ratio of the traces sampled, from 0 to 1")
+	traceSampleRoutes := flag.String("trace-sample-routes", defaultTraceSampleRoutes, "comma separated path-prefix=rate overriding the sample rate, e.g. /healthz=0")
+	traceSampleErrors := flag.Bool("trace-sample-errors", defaultTraceSampleErrors, "report the traces with errors even when they are not sampled (zipkin only)")
+	traceBatchSize := flag.Int("trace-batch-size", defaultTraceBatchSize, "maximum number of spans reported together")
+	traceBatchInterval := flag.Duration("trace-batch-interval", defaultTraceBatchInterval, "maximum time a span waits to be reported")
+	traceReportTimeout := flag.Duration("trace-report-timeout", defaultTraceReportTimeout, "maximum time to report a batch of spans")
 	database := flag.String("database", defaultDatabase, "initialize the api using the given db engine")
+	apiKeysFile := flag.String("api-keys", defaultAPIKeysFile, "require authentication with the API keys of the given JSON file")
+	jwtKeysFile := flag.String("jwt-keys", defaultJWTKeysFile, "require authentication with JWTs signed by the keys of the given JWKS file")
+	jwtIssuer := flag.String("jwt-issuer", defaultJWTIssuer, "expected issuer of the JWTs")
+	jwtAudience := flag.String("jwt-audience", defaultJWTAudience, "expected audience of the JWTs")
+	tenantHeader := flag.String("tenant-header", defaultTenantHeader, "resolve the tenant of the requests from the given header")
+	tenantDomain := flag.String("tenant-domain", defaultTenantDomain, "resolve the tenant of the requests from the subdomains of the given domain")
+	trustedProxies := flag.String("trusted-proxies", defaultTrustedProxies, "comma separated CIDRs of the proxies trusted to set X-Forwarded-For")
+	rateLimit := flag.String("rate-limit", defaultRateLimit, "limit the requests of each client to the given rate:burst, e.g. 10:20")
+	rateLimitRoutes := flag.String("rate-limit-routes", defaultRateLimitRoutes, "comma separated route=rate:burst limits overriding the default one, e.g. addGopher=0.5:5")
+	rateLimitIP := flag.String("rate-limit-ip", defaultRateLimitIP, "limit all the requests and gRPC calls of each client IP to the given rate:burst before authenticating them, the --rate-limit by default")
+	rateLimitStore := flag.String("rate-limit-store", defaultRateLimitStore, "store used to count the requests, inmem or redis")
+	readTimeout := flag.Duration("read-timeout", defaultReadTimeout, "maximum duration for reading an entire request")
+	readHeaderTimeout := flag.Duration("read-header-timeout", defaultReadHeaderTimeout, "maximum duration for reading the request headers")
+	writeTimeout := flag.Duration("write-timeout", defaultWriteTimeout, "maximum duration before timing out writes of the response")
+	idleTimeout := flag.Duration("idle-timeout", defaultIdleTimeout, "maximum duration to wait for the next request on keep-alive connections")
+	shutdownTimeout := flag.Duration("shutdown-timeout", defaultShutdownTimeout, "maximum duration to drain the in-flight requests on shutdown")
+	shutdownDelay := flag.Duration("shutdown-delay", defaultShutdownDelay, "duration to keep serving as not ready before draining on shutdown")
+	healthTimeout := flag.Duration("health-timeout", defaultHealthTimeout, "maximum duration of each dependency check of the readiness endpoint")
+	maxHeaderBytes := flag.Int("max-header-bytes", defaultMaxHeaderBytes, "maximum size of the request headers, 0 means 1MB")
+	maxImportBytes := flag.Int64("max-import-bytes", defaultMaxImportBytes, "maximum size of the bodies imported, 0 means 100MB")
+	tlsCert := flag.String("tls-cert", defaultTLSCert, "serve HTTPS with the given certificate file, reloaded when it changes")
+	tlsKey := flag.String("tls-key", defaultTLSKey, "private key file of the TLS certificate")
+	tlsClientCA := flag.String("tls-client-ca", defaultTLSClientCA, "authenticate the clients presenting a certificate signed by the CAs of the given bundle")
+	tlsRequireClientCert := flag.Bool("tls-require-client-cert", defaultTLSRequireClientCert, "reject the TLS connections without a valid client certificate")
+	logBackend := flag.String("log-backend", defaultLogBackend, "library used to log the messages: logrus, slog or zap")
+	logLevel := flag.String("log-level", defaultLogLevel, "minimum level of the messages logged: debug, info, warn or error")
+	logFormat := flag.String("log-format", defaultLogFormat, "format of the messages logged, text or json")
+	accessLogSampleRate := flag.Float64("access-log-sample-rate", defaultAccessLogSampleRate, "ratio of the requests logged, from 0 to 1, the server errors are always logged")
+	accessLogExclude := flag.String("access-log-exclude", defaultAccessLogExclude, "comma separated path prefixes of the requests not logged, like /healthz,/readyz")
+	validateRequests := flag.Bool("validate-requests", defaultValidateRequests, "reject the requests not matching the OpenAPI document with a 400")
+	v1DeprecatedAt := flag.String("v1-deprecated-at", defaultV1DeprecatedAt, "RFC3339 date the v1 api was deprecated at, announced in its responses")
+	v1Sunset := flag.String("v1-sunset", defaultV1Sunset, "RFC3339 date the v1 api will be removed at, announced in its responses")
+	graphQLMaxDepth := flag.Int("graphql-max-depth", defaultGraphQLMaxDepth, "maximum nesting of the fields of the GraphQL queries, 0 means unlimited")
+	graphQLMaxComplexity := flag.Int("graphql-max-complexity", defaultGraphQLMaxComplexity, "maximum cost of the GraphQL queries, each field of each item asked for costs 1, 0 means unlimited")
 	flag.Parse()
 
+	logger, err := initializeLogger(*logBackend, log.Config{
+		Level:  *logLevel,
+		Format: *logFormat,
+		Fields: []log.FieldsFunc{server.RequestFields, callerFields},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// closers are closed in reverse order once the server has been drained
+	var closers []io.Closer
+	// startupFailed logs the error preventing the server to start and exits, closing what was opened so far
+	startupFailed := func(err error) {
+		logger.StartupFailed(ctx, err)
+		closeAll(ctx, logger, closers)
+		stop()
+		os.Exit(1)
+	}
+
 	var gophers map[string]gopher.Gopher
 	if *withData {
 		gophers = sample.Gophers
 	}
 
-	logger := logrus.NewLogger()
 	trc := tracer.NewNoopTracer()
 	if *withTrace {
-		var err error
-		trc, err = tracer.NewTracer(*serverID, zipkinURL)
+		routes, err := parseSamplingRoutes(*traceSampleRoutes)
 		if err != nil {
-			log.Fatal(err)
+			startupFailed(err)
+		}
+		sampling := tracer.Sampling{
+			Strategy: *traceSampling,
+			Rate:     *traceSampleRate,
+			Routes:   routes,
+			OnError:  *traceSampleErrors,
 		}
+		batching := tracer.Batching{Size: *traceBatchSize, Interval: *traceBatchInterval, Timeout: *traceReportTimeout}
+
+		var reporter io.Closer
+		trc, reporter, err = initializeTracer(*traceExporter, *traceReporter, *traceKafkaTopic, *serverID,
+			tracer.WithSampling(sampling), tracer.WithBatching(batching))
+		if err != nil {
+			startupFailed(err)
+		}
+		closers = append(closers, reporter)
 	}
 
-	repo := initializeRepo(database, trc, gophers)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	backend := backendName(*database)
+	repo, conn, err := initializeRepo(database, gophers)
+	if err != nil {
+		startupFailed(err)
+	}
+	if conn != nil {
+		closers = append(closers, conn)
+	}
+	if db, ok := conn.(*sql.DB); ok {
+		registry.MustRegister(collectors.NewDBStatsCollector(db, backend))
+	}
+	// the health is checked on the storage itself, the decorators wrapping it don't report any
+	checker, hasHealth := repo.(health.Checker)
+	repo = metrics.NewRepository(repo, backend, registry)
+	repo = tracer.NewRepository(repo, backend, trc)
+	broker := watching.NewBroker()
+	repo = watching.NewRepository(repo, broker)
+	index := initializeSearchIndex(*database, repo, conn)
+	repo = searching.NewRepository(repo, index)
+
+	probe := health.NewProbe(*healthTimeout)
+	if hasHealth {
+		probe.Register(backend, checker)
+	}
 
 	fetchingService := fetching.NewService(repo, logger)
 	addingService := adding.NewService(repo)
 	modifyingService := modifying.NewService(repo)
 	removingService := removing.NewService(repo)
+	watchingService := watching.NewService(broker)
+	exportingService := exporting.NewService(repo)
+	importingService := importing.NewService(repo)
+	searchingService := searching.NewService(index)
+
+	authenticators, err := initializeAuthenticators(*apiKeysFile, *jwtKeysFile, *jwtIssuer, *jwtAudience)
+	if err != nil {
+		startupFailed(err)
+	}
+	if *tlsClientCA != "" {
+		authenticators = append([]auth.Authenticator{auth.NewClientCertAuthenticator()}, authenticators...)
+	}
+	var policy *auth.Policy
+	if len(authenticators) > 0 {
+		policy = auth.DefaultPolicy()
+		fetchingService = fetching.NewAuthorizingService(fetchingService, policy)
+		addingService = adding.NewAuthorizingService(addingService, policy)
+		modifyingService = modifying.NewAuthorizingService(modifyingService, policy)
+		removingService = removing.NewAuthorizingService(removingService, policy)
+		watchingService = watching.NewAuthorizingService(watchingService, policy)
+		exportingService = exporting.NewAuthorizingService(exportingService, policy)
+		importingService = importing.NewAuthorizingService(importingService, policy)
+		searchingService = searching.NewAuthorizingService(searchingService, policy)
+	}
+	fetchingService = fetching.NewTracingService(fetchingService, trc)
+	addingService = adding.NewTracingService(addingService, trc)
+	modifyingService = modifying.NewTracingService(modifyingService, trc)
+	removingService = removing.NewTracingService(removingService, trc)
+	exportingService = exporting.NewTracingService(exportingService, trc)
+	importingService = importing.NewTracingService(importingService, trc)
+	searchingService = searching.NewTracingService(searchingService, trc)
 
 	httpAddr := fmt.Sprintf("%s:%d", *host, *port)
 
+	proxies, err := parseTrustedProxies(*trustedProxies)
+	if err != nil {
+		startupFailed(err)
+	}
+	opts := []server.Option{
+		server.WithAuthenticators(authenticators...),
+		server.WithPolicy(policy),
+		server.WithTenantResolver(tenant.Resolver{Header: *tenantHeader, Domain: *tenantDomain}),
+		server.WithTrustedProxies(proxies...),
+		server.WithHealthProbe(probe),
+		server.WithMetrics(registry),
+		server.WithLogger(logger),
+		server.WithAccessLog(server.AccessLog{SampleRate: *accessLogSampleRate, Exclude: parsePathPrefixes(*accessLogExclude)}),
+	}
+	deprecation, err := parseDeprecation(*v1DeprecatedAt, *v1Sunset)
+	if err != nil {
+		startupFailed(err)
+	}
+	opts = append(opts,
+		server.WithDeprecation(deprecation),
+		server.WithGraphQLLimits(graphql.Limits{MaxDepth: *graphQLMaxDepth, MaxComplexity: *graphQLMaxComplexity}),
+		server.WithMaxImportSize(*maxImportBytes),
+	)
+	if *validateRequests {
+		opts = append(opts, server.WithRequestValidation())
+	}
+	var (
+		rateLimits server.RateLimits
+		limiter    ratelimit.Store
+	)
+	if *rateLimit != "" {
+		rateLimits, err = parseRateLimits(*rateLimit, *rateLimitIP, *rateLimitRoutes)
+		if err != nil {
+			startupFailed(err)
+		}
+		var conn io.Closer
+		limiter, conn = initializeRateLimitStore(*rateLimitStore, registry)
+		if conn != nil {
+			closers = append(closers, conn)
+		}
+		opts = append(opts, server.WithRateLimit(limiter, rateLimits))
+	}
+
 	s := server.New(
 		*serverID,
 		trc,
@@ -75,43 +335,390 @@ func main() {
 		addingService,
 		modifyingService,
 		removingService,
+		exportingService,
+		importingService,
+		searchingService,
+		opts...,
 	)
 
-	fmt.Println("The gopher server is on tap now:", httpAddr)
-	log.Fatal(http.ListenAndServe(httpAddr, s.Router()))
+	httpServer := &http.Server{
+		Addr:              httpAddr,
+		Handler:           s.Router(),
+		ReadTimeout:       *readTimeout,
+		ReadHeaderTimeout: *readHeaderTimeout,
+		WriteTimeout:      *writeTimeout,
+		IdleTimeout:       *idleTimeout,
+		MaxHeaderBytes:    *maxHeaderBytes,
+	}
+	if *tlsCert != "" {
+		httpServer.TLSConfig, err = initializeTLSConfig(*tlsCert, *tlsKey, *tlsClientCA, *tlsRequireClientCert)
+		if err != nil {
+			startupFailed(err)
+		}
+	}
+
+	var grpcServer *grpc.Server
+	if *grpcPort != 0 {
+		grpcOpts := []gopherapigrpc.Option{
+			gopherapigrpc.WithAuthenticators(authenticators...),
+			gopherapigrpc.WithTenantResolver(tenant.Resolver{Header: *tenantHeader, Domain: *tenantDomain}),
+			gopherapigrpc.WithLogger(logger),
+			gopherapigrpc.WithMetrics(registry),
+		}
+		if limiter != nil {
+			grpcOpts = append(grpcOpts, gopherapigrpc.WithRateLimit(limiter, rateLimits.IP))
+		}
+		if httpServer.TLSConfig != nil {
+			grpcOpts = append(grpcOpts, gopherapigrpc.WithTLSConfig(httpServer.TLSConfig))
+		}
+		grpcServer = gopherapigrpc.NewServer(
+			trc,
+			fetchingService,
+			addingService,
+			modifyingService,
+			removingService,
+			watchingService,
+			grpcOpts...,
+		)
+	}
+
+	serverErr := make(chan error, 2)
+	if grpcServer != nil {
+		grpcAddr := fmt.Sprintf("%s:%d", *host, *grpcPort)
+		listener, err := net.Listen("tcp", grpcAddr)
+		if err != nil {
+			startupFailed(err)
+		}
+		go func() {
+			logger.ServerStarted(ctx, grpcAddr)
+			serverErr <- grpcServer.Serve(listener)
+		}()
+	}
+	go func() {
+		logger.ServerStarted(ctx, httpAddr)
+		if httpServer.TLSConfig != nil {
+			serverErr <- httpServer.ListenAndServeTLS("", "")
+			return
+		}
+		serverErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			startupFailed(err)
+		}
+	case <-ctx.Done():
+		stop()
+		probe.ShutDown()
+		logger.ServerStopping(ctx)
+		if *shutdownDelay > 0 {
+			time.Sleep(*shutdownDelay)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			logger.UnexpectedError(shutdownCtx, err)
+		}
+		if grpcServer != nil {
+			stopGRPCServer(shutdownCtx, grpcServer)
+		}
+	}
+
+	closeAll(ctx, logger, closers)
+}
+
+// closeAll closes the given closers in reverse order, logging the ones failing
+func closeAll(ctx context.Context, logger log.Logger, closers []io.Closer) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		if err := closers[i].Close(); err != nil {
+			logger.UnexpectedError(ctx, err)
+		}
+	}
+}
+
+// stopGRPCServer waits for the in-flight calls until ctx is done, then closes the ones left,
+// like the watchers which never end by themselves
+func stopGRPCServer(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
 }
 
-func initializeRepo(database *string, trc *zipkin.Tracer, gophers map[string]gopher.Gopher) gopher.Repository {
-	var repo gopher.Repository
+// floatEnv reads the number of the given environment variable, or the default one when not set
+func floatEnv(name string, defaultValue float64) float64 {
+	f, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return f
+}
+
+// intEnv reads the integer of the given environment variable, or the default one when not set
+func intEnv(name string, defaultValue int) int {
+	n, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+	return n
+}
+
+// durationEnv reads the duration of the given environment variable, or the default one when not set
+func durationEnv(name string, defaultValue time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(name))
+	if err != nil {
+		return defaultValue
+	}
+	return d
+}
+
+func initializeLogger(backend string, config log.Config) (log.Logger, error) {
+	switch backend {
+	case "", "logrus":
+		return logrus.NewLogger(config)
+	case "slog":
+		return slog.NewLogger(config)
+	case "zap":
+		return zap.NewLogger(config)
+	default:
+		return nil, fmt.Errorf("unknown log backend %q", backend)
+	}
+}
+
+// callerFields returns the caller, the tenant and the trace of the context, when there are any,
+// to be logged along with every message
+func callerFields(ctx context.Context) []log.Field {
+	var fields []log.Field
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		fields = append(fields, log.Field{Key: "principal", Value: principal.Subject})
+	}
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		fields = append(fields, log.Field{Key: "tenant", Value: tenantID})
+	}
+	if span := tracer.SpanFromContext(ctx); span != nil && span.TraceID() != "" {
+		fields = append(fields, log.Field{Key: "traceid", Value: span.TraceID()}, log.Field{Key: "spanid", Value: span.SpanID()})
+	}
+	return fields
+}
+
+func initializeTracer(exporter, zipkinReporter, kafkaTopic, serviceName string, opts ...tracer.Option) (tracer.Tracer, io.Closer, error) {
+	switch exporter {
+	case "otlp":
+		return tracer.NewOpenTelemetryTracer(serviceName, os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), opts...)
+	default:
+		return tracer.NewZipkinTracer(serviceName, newZipkinReporter(zipkinReporter, kafkaTopic), opts...)
+	}
+}
+
+// backendName returns the name of the storage used by initializeRepo
+func backendName(database string) string {
+	switch database {
+	case "cockroach", "mysql":
+		return database
+	default:
+		return "inmem"
+	}
+}
+
+func initializeRepo(database *string, gophers map[string]gopher.Gopher) (gopher.Repository, io.Closer, error) {
 	switch *database {
 	case "cockroach":
-		repo = newCockroachRepository(trc)
+		return newCockroachRepository()
+	case "mysql":
+		return newMySQLRepository()
+	default:
+		return inmem.NewRepository(gophers), nil, nil
+	}
+}
+
+// initializeSearchIndex searches the gophers with the database when there is one, the memory
+// index is only used with the inmem storage, the only one no other instance writes to
+func initializeSearchIndex(database string, repo gopher.Repository, conn io.Closer) searching.Index {
+	db, _ := conn.(*sql.DB)
+	switch backendName(database) {
+	case "cockroach":
+		return cockroach.NewSearchIndex(db)
 	case "mysql":
-		repo = newMySQLRepository()
+		return mysql.NewSearchIndex("gophers", db)
+	default:
+		return searching.NewMemoryIndex(repo)
+	}
+}
+
+func initializeAuthenticators(apiKeysFile, jwtKeysFile, jwtIssuer, jwtAudience string) ([]auth.Authenticator, error) {
+	var authenticators []auth.Authenticator
+	if apiKeysFile != "" {
+		keys, err := auth.LoadAPIKeys(apiKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		a, err := auth.NewAPIKeyAuthenticator(keys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if jwtKeysFile != "" {
+		keys, err := auth.LoadKeySet(jwtKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(keys, jwtIssuer, jwtAudience))
+	}
+	return authenticators, nil
+}
+
+func initializeTLSConfig(certFile, keyFile, clientCAFile string, requireClientCert bool) (*tls.Config, error) {
+	reloader, err := server.NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return server.NewTLSConfig(reloader, clientCAFile, requireClientCert)
+}
+
+func parseTrustedProxies(cidrs string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, cidr := range strings.Split(cidrs, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, proxy, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, proxy)
+	}
+	return proxies, nil
+}
+
+func newZipkinReporter(reporter, kafkaTopic string) tracer.ZipkinReporter {
+	switch reporter {
+	case "log":
+		return tracer.ZipkinLog(os.Stdout)
+	case "kafka":
+		if kafkaTopic == "" {
+			kafkaTopic = "zipkin"
+		}
+		return tracer.ZipkinKafka(strings.Split(os.Getenv("KAFKA_ADDRS"), ","), kafkaTopic)
 	default:
-		repo = inmem.NewRepository(gophers, trc)
+		return tracer.ZipkinHTTP(os.Getenv("ZIPKIN_ENDPOINT"))
+	}
+}
+
+func parseSamplingRoutes(routes string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	for _, route := range strings.Split(routes, ",") {
+		if route = strings.TrimSpace(route); route == "" {
+			continue
+		}
+		parts := strings.SplitN(route, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid route sample rate %q, expected path-prefix=rate", route)
+		}
+		rate, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		rates[parts[0]] = rate
+	}
+	return rates, nil
+}
+
+func parseRateLimits(defaultLimit, ipLimit, routes string) (server.RateLimits, error) {
+	limit, err := ratelimit.ParseLimit(defaultLimit)
+	if err != nil {
+		return server.RateLimits{}, err
+	}
+
+	limits := server.RateLimits{Default: limit, Routes: make(map[string]ratelimit.Limit), IP: limit}
+	if ipLimit != "" {
+		if limits.IP, err = ratelimit.ParseLimit(ipLimit); err != nil {
+			return server.RateLimits{}, err
+		}
+	}
+	for _, route := range strings.Split(routes, ",") {
+		if route = strings.TrimSpace(route); route == "" {
+			continue
+		}
+		parts := strings.SplitN(route, "=", 2)
+		if len(parts) != 2 {
+			return server.RateLimits{}, fmt.Errorf("invalid route rate limit %q, expected route=rate:burst", route)
+		}
+		limit, err := ratelimit.ParseLimit(parts[1])
+		if err != nil {
+			return server.RateLimits{}, err
+		}
+		limits.Routes[parts[0]] = limit
 	}
-	return repo
+	return limits, nil
 }
 
-func newCockroachRepository(trc *zipkin.Tracer) gopher.Repository {
+func initializeRateLimitStore(store string, registry *prometheus.Registry) (ratelimit.Store, io.Closer) {
+	if store == "redis" {
+		pool := redis.NewConn(os.Getenv("REDIS_ADDR"))
+		registry.MustRegister(metrics.NewRedisPoolCollector(pool, "ratelimit"))
+		return redis.NewRateLimitStore(pool), pool
+	}
+	return ratelimit.NewMemoryStore(), nil
+}
+
+func newCockroachRepository() (gopher.Repository, io.Closer, error) {
 	cockroachAddr := os.Getenv("COCKROACH_ADDR")
 	cockroachDBName := os.Getenv("COCKROACH_DB")
 
 	cockroachConn, err := cockroach.NewConn(cockroachAddr, cockroachDBName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
 	}
-	return cockroach.NewRepository(cockroachConn, trc)
+	return cockroach.NewRepository(cockroachConn), cockroachConn, nil
 }
 
-func newMySQLRepository() gopher.Repository {
+func newMySQLRepository() (gopher.Repository, io.Closer, error) {
 	mysqlAddr := os.Getenv("MYSQL_ADDR")
 	mysqlDBName := os.Getenv("MYSQL_DB")
 
 	mysqlConn, err := mysql.NewConn(mysqlAddr, mysqlDBName)
 	if err != nil {
-		log.Fatal(err)
+		return nil, nil, err
+	}
+	return mysql.NewRepository("gophers", mysqlConn), mysqlConn, nil
+}
+
+// parsePathPrefixes parses a comma separated list of path prefixes
+func parsePathPrefixes(prefixes string) []string {
+	var parsed []string
+	for _, prefix := range strings.Split(prefixes, ",") {
+		if prefix = strings.TrimSpace(prefix); prefix != "" {
+			parsed = append(parsed, prefix)
+		}
+	}
+	return parsed
+}
+
+// parseDeprecation parses the optional RFC3339 dates of the v1 api deprecation
+func parseDeprecation(deprecatedAt, sunset string) (server.Deprecation, error) {
+	var (
+		deprecation server.Deprecation
+		err         error
+	)
+	if deprecatedAt != "" {
+		if deprecation.At, err = time.Parse(time.RFC3339, deprecatedAt); err != nil {
+			return deprecation, fmt.Errorf("invalid v1 deprecation date: %w", err)
+		}
+	}
+	if sunset != "" {
+		if deprecation.Sunset, err = time.Parse(time.RFC3339, sunset); err != nil {
+			return deprecation, fmt.Errorf("invalid v1 sunset date: %w", err)
+		}
 	}
-	return mysql.NewRepository("gophers", mysqlConn)
+	return deprecation, nil
 }
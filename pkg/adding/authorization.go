@@ -0,0 +1,25 @@
+package adding
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps an adding service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// AddGopher adds the given gopher to storage if the caller has write permission
+func (s *authorizingService) AddGopher(ctx context.Context, ID, name, image string, age int) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionWrite); err != nil {
+		return err
+	}
+	return s.next.AddGopher(ctx, ID, name, image, age)
+}
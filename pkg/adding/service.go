@@ -2,6 +2,8 @@ package adding
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	gopher "github.com/friendsofgo/gopherapi/pkg"
 )
@@ -22,6 +24,12 @@ func NewService(repository gopher.Repository) Service {
 
 // AddGopher adds the given gopher to storage
 func (s *service) AddGopher(ctx context.Context, ID, name, image string, age int) error {
+	if gopher.ReservedID(ID) {
+		return fmt.Errorf("%w: %s", gopher.ErrReservedID, ID)
+	}
+
 	g := gopher.New(ID, name, image, age)
+	now := time.Now()
+	g.CreatedAt, g.UpdatedAt = &now, &now
 	return s.repository.CreateGopher(ctx, g)
 }
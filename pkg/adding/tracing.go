@@ -0,0 +1,30 @@
+package adding
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps an adding service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// AddGopher adds the given gopher to storage within a span
+func (s *tracingService) AddGopher(ctx context.Context, ID, name, image string, age int) error {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "adding.AddGopher")
+	span.Tag("gopher.id", ID)
+	defer span.Finish()
+
+	err := s.next.AddGopher(ctx, ID, name, image, age)
+	if err != nil {
+		span.SetError(err)
+	}
+	return err
+}
@@ -0,0 +1,82 @@
+package adding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+func Test_TracingService_AddGopher(t *testing.T) {
+	testData := []struct {
+		name string
+		err  error
+	}{
+		{name: "added"},
+		{name: "failed", err: errors.New("connection refused")},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN an adding service which succeeds or fails
+			trc := &recordingTracer{}
+			s := NewTracingService(stubService{err: tt.err}, trc)
+
+			// WHEN a gopher is added
+			err := s.AddGopher(context.Background(), "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "Jenny", "", 18)
+
+			// THEN the operation is traced with the gopher ID, and its error if any
+			assert.Equal(t, tt.err, err)
+			require.Len(t, trc.spans, 1)
+			span := trc.spans[0]
+			assert.Equal(t, "adding.AddGopher", span.name)
+			assert.Equal(t, "01D3XZ3ZHCP3KG9VT4FGAD8KDR", span.tags["gopher.id"])
+			assert.Equal(t, tt.err, span.err)
+			assert.True(t, span.finished)
+		})
+	}
+}
+
+type stubService struct {
+	err error
+}
+
+func (s stubService) AddGopher(context.Context, string, string, string, int) error {
+	return s.err
+}
+
+// recordingTracer keeps the spans started to assert on them
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) StartSpanFromContext(ctx context.Context, name string) (tracer.Span, context.Context) {
+	span := &recordedSpan{name: name, tags: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return span, ctx
+}
+
+func (t *recordingTracer) Middleware(next http.Handler) http.Handler {
+	return next
+}
+
+type recordedSpan struct {
+	name     string
+	tags     map[string]string
+	err      error
+	finished bool
+}
+
+func (s *recordedSpan) SetName(name string)        { s.name = name }
+func (s *recordedSpan) Tag(key, value string)      { s.tags[key] = value }
+func (s *recordedSpan) Annotate(time.Time, string) {}
+func (s *recordedSpan) SetError(err error)         { s.err = err }
+func (s *recordedSpan) Finish()                    { s.finished = true }
+func (s *recordedSpan) TraceID() string            { return "" }
+func (s *recordedSpan) SpanID() string             { return "" }
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const apiKeyHeader = "X-API-Key"
+
+// APIKey is a static API key as stored in the configuration, only the
+// SHA-256 hash of the key is kept so the configuration doesn't leak secrets
+type APIKey struct {
+	Name   string   `json:"name"`
+	Hash   string   `json:"sha256"`
+	Scopes []string `json:"scopes"`
+	Roles  []string `json:"roles"`
+	Tenant string   `json:"tenant"`
+}
+
+type apiKeyAuthenticator struct {
+	keys map[string]APIKey
+}
+
+// NewAPIKeyAuthenticator creates an authenticator accepting the given API keys
+// through the X-API-Key header or the "ApiKey" authorization scheme
+func NewAPIKeyAuthenticator(keys []APIKey) (Authenticator, error) {
+	a := &apiKeyAuthenticator{keys: make(map[string]APIKey, len(keys))}
+	for _, k := range keys {
+		hash, err := hex.DecodeString(k.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: sha256 must be a hex encoded SHA-256 hash", k.Name)
+		}
+		a.keys[hex.EncodeToString(hash)] = k
+	}
+	return a, nil
+}
+
+// LoadAPIKeys reads the API keys from the given JSON file
+func LoadAPIKeys(path string) ([]APIKey, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []APIKey
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("parsing api keys file %s: %w", path, err)
+	}
+	return keys, nil
+}
+
+// HashAPIKey returns the hash of the given key as expected in the configuration
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (a *apiKeyAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	key := r.Header.Get(apiKeyHeader)
+	if key == "" {
+		scheme, credentials := authorization(r)
+		if !strings.EqualFold(scheme, "ApiKey") {
+			return Principal{}, ErrNoCredentials
+		}
+		key = credentials
+	}
+
+	k, ok := a.keys[HashAPIKey(key)]
+	if !ok {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	return Principal{Subject: k.Name, Method: "apikey", Scopes: k.Scopes, Roles: k.Roles, Tenant: k.Tenant}, nil
+}
+
+func (a *apiKeyAuthenticator) Challenge() string {
+	return `ApiKey realm="gopherapi"`
+}
+
+// authorization splits the Authorization header into scheme and credentials
+func authorization(r *http.Request) (string, string) {
+	header := r.Header.Get("Authorization")
+	i := strings.IndexByte(header, ' ')
+	if i < 0 {
+		return "", ""
+	}
+	return header[:i], strings.TrimSpace(header[i+1:])
+}
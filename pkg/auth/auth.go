@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+var (
+	// ErrNoCredentials is returned when the request doesn't carry the credentials an authenticator understands
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrInvalidCredentials is returned when the request carries credentials that can't be verified
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// contextKeyPrincipal is kept here rather than next to the keys of the server, as the gRPC
+// interceptors and the policy read the principal too, and they can't import the server
+var contextKeyPrincipal = contextKey("principal")
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "auth" + string(c)
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// Subject identifies the caller, the API key name or the token subject
+	Subject string
+	// Method is the authentication method used, e.g. "apikey" or "jwt"
+	Method string
+	// Scopes granted to the caller
+	Scopes []string
+	// Roles of the caller
+	Roles []string
+	// Tenant the caller belongs to, if any
+	Tenant string
+}
+
+// TenantID returns the tenant the caller is bound to, the default one when it doesn't belong to any
+func (p Principal) TenantID() string {
+	if p.Tenant == "" {
+		return tenant.Default
+	}
+	return p.Tenant
+}
+
+// Authenticator identifies the caller of a request
+type Authenticator interface {
+	// Authenticate returns the principal of the request, ErrNoCredentials when the request
+	// doesn't carry credentials of this kind, or ErrInvalidCredentials when they can't be verified
+	Authenticate(r *http.Request) (Principal, error)
+	// Challenge returns the scheme and realm sent in the WWW-Authenticate header
+	Challenge() string
+}
+
+// WithPrincipal returns a copy of ctx holding the given principal
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKeyPrincipal, p)
+}
+
+// PrincipalFromContext gets the authenticated caller from context
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKeyPrincipal).(Principal)
+	return p, ok
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"net/http"
+)
+
+type clientCertAuthenticator struct{}
+
+// NewClientCertAuthenticator creates an authenticator accepting the client certificates verified
+// during the TLS handshake, the subject common name is the principal and its organizational units its roles
+func NewClientCertAuthenticator() Authenticator {
+	return clientCertAuthenticator{}
+}
+
+func (clientCertAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return Principal{}, ErrNoCredentials
+	}
+	// the certificate is only verified when the server was configured with the client CAs
+	if len(r.TLS.VerifiedChains) == 0 {
+		return Principal{}, ErrInvalidCredentials
+	}
+
+	cert := r.TLS.VerifiedChains[0][0]
+	return Principal{
+		Subject: cert.Subject.CommonName,
+		Method:  "mtls",
+		Roles:   cert.Subject.OrganizationalUnit,
+	}, nil
+}
+
+func (clientCertAuthenticator) Challenge() string {
+	return `Mutual realm="gopherapi"`
+}
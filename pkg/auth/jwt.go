@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// KeySet holds the keys used to verify JWTs indexed by key ID, HS256 tokens
+// are verified with []byte secrets and RS256 tokens with *rsa.PublicKey keys
+type KeySet map[string]interface{}
+
+// LoadKeySet reads a local JSON Web Key Set file, "oct" keys are used as
+// HS256 secrets and "RSA" keys as RS256 public keys
+func LoadKeySet(path string) (KeySet, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			K   string `json:"k"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(b, &jwks); err != nil {
+		return nil, fmt.Errorf("parsing key set file %s: %w", path, err)
+	}
+
+	keys := make(KeySet, len(jwks.Keys))
+	for _, k := range jwks.Keys {
+		switch k.Kty {
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = secret
+		case "RSA":
+			n, err := base64.RawURLEncoding.DecodeString(k.N)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			e, err := base64.RawURLEncoding.DecodeString(k.E)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+			}
+			keys[k.Kid] = &rsa.PublicKey{
+				N: new(big.Int).SetBytes(n),
+				E: int(new(big.Int).SetBytes(e).Int64()),
+			}
+		default:
+			return nil, fmt.Errorf("key %q: unsupported key type %q", k.Kid, k.Kty)
+		}
+	}
+	return keys, nil
+}
+
+// Claims are the registered JWT claims understood by the authenticator
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Scope     string   `json:"scope"`
+	Roles     []string `json:"roles"`
+	Tenant    string   `json:"tenant"`
+}
+
+// audience accepts both forms of the "aud" claim, a single string or an array
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+type jwtAuthenticator struct {
+	keys     KeySet
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewJWTAuthenticator creates an authenticator accepting HS256 and RS256 bearer tokens
+// signed by the keys of the given set, issuer and audience are only checked when not empty
+func NewJWTAuthenticator(keys KeySet, issuer, audience string) Authenticator {
+	return &jwtAuthenticator{keys: keys, issuer: issuer, audience: audience, now: time.Now}
+}
+
+func (a *jwtAuthenticator) Authenticate(r *http.Request) (Principal, error) {
+	scheme, token := authorization(r)
+	if !strings.EqualFold(scheme, "Bearer") {
+		return Principal{}, ErrNoCredentials
+	}
+
+	claims, err := a.verify(token)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+
+	return Principal{
+		Subject: claims.Subject,
+		Method:  "jwt",
+		Scopes:  strings.Fields(claims.Scope),
+		Roles:   claims.Roles,
+		Tenant:  claims.Tenant,
+	}, nil
+}
+
+func (a *jwtAuthenticator) Challenge() string {
+	return `Bearer realm="gopherapi"`
+}
+
+func (a *jwtAuthenticator) verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed header: %v", err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed signature: %v", err)
+	}
+
+	key, ok := a.keys[header.Kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", header.Kid)
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	switch k := key.(type) {
+	case []byte:
+		if header.Alg != "HS256" {
+			return nil, fmt.Errorf("unexpected algorithm %q for key %q", header.Alg, header.Kid)
+		}
+		mac := hmac.New(sha256.New, k)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, fmt.Errorf("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" {
+			return nil, fmt.Errorf("unexpected algorithm %q for key %q", header.Alg, header.Kid)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, fmt.Errorf("invalid signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key %q", header.Kid)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed claims: %v", err)
+	}
+
+	now := a.now().Unix()
+	if claims.ExpiresAt == 0 || now >= claims.ExpiresAt {
+		return nil, fmt.Errorf("token expired")
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil, fmt.Errorf("token not valid yet")
+	}
+	if a.issuer != "" && claims.Issuer != a.issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if a.audience != "" && !claims.Audience.contains(a.audience) {
+		return nil, fmt.Errorf("token not issued for %q", a.audience)
+	}
+
+	return &claims, nil
+}
+
+func (a audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JWTAuthenticator_Authenticate(t *testing.T) {
+	secret := []byte("a-very-secret-secret")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	keys := KeySet{"hs": secret, "rs": &rsaKey.PublicKey}
+	valid := map[string]interface{}{
+		"sub":   "jenny",
+		"iss":   "gopherapi-tests",
+		"aud":   []string{"gopherapi"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"scope": "gophers:read gophers:write",
+	}
+	expired := map[string]interface{}{
+		"sub": "jenny",
+		"exp": time.Now().Add(-time.Hour).Unix(),
+	}
+
+	testData := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{name: "valid HS256 token", token: signHS256(t, "hs", secret, valid)},
+		{name: "valid RS256 token", token: signRS256(t, "rs", rsaKey, valid)},
+		{name: "expired token", token: signHS256(t, "hs", secret, expired), err: ErrInvalidCredentials},
+		{name: "unknown key", token: signHS256(t, "other", secret, valid), err: ErrInvalidCredentials},
+		{name: "wrong secret", token: signHS256(t, "hs", []byte("wrong"), valid), err: ErrInvalidCredentials},
+		{name: "algorithm confusion", token: signHS256(t, "rs", secret, valid), err: ErrInvalidCredentials},
+		{name: "malformed token", token: "not-a-token", err: ErrInvalidCredentials},
+		{name: "no token", err: ErrNoCredentials},
+	}
+
+	a := NewJWTAuthenticator(keys, "gopherapi-tests", "gopherapi")
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/gophers", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			p, err := a.Authenticate(req)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, Principal{Subject: "jenny", Method: "jwt", Scopes: []string{"gophers:read", "gophers:write"}}, p)
+		})
+	}
+}
+
+func Test_APIKeyAuthenticator_Authenticate(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]APIKey{{Name: "ci", Hash: HashAPIKey("s3cr3t"), Scopes: []string{"gophers:read"}}})
+	assert.NoError(t, err)
+
+	req, _ := http.NewRequest("GET", "/gophers", nil)
+	_, err = a.Authenticate(req)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	req.Header.Set("X-API-Key", "wrong")
+	_, err = a.Authenticate(req)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	req.Header.Del("X-API-Key")
+	req.Header.Set("Authorization", "ApiKey s3cr3t")
+	p, err := a.Authenticate(req)
+	assert.NoError(t, err)
+	assert.Equal(t, Principal{Subject: "ci", Method: "apikey", Scopes: []string{"gophers:read"}}, p)
+}
+
+func signHS256(t *testing.T, kid string, secret []byte, claims map[string]interface{}) string {
+	signed := unsignedToken(t, "HS256", kid, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func signRS256(t *testing.T, kid string, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	signed := unsignedToken(t, "RS256", kid, claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func unsignedToken(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	assert.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	assert.NoError(t, err)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+}
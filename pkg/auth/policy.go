@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrUnauthenticated is returned when an operation is run without an authenticated caller
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the caller isn't allowed to run an operation
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Permission required to run an operation over gophers
+type Permission string
+
+// Declare the permissions understood by the policies
+const (
+	PermissionRead  Permission = "gophers:read"
+	PermissionWrite Permission = "gophers:write"
+	PermissionAdmin Permission = "gophers:admin"
+)
+
+// Policy maps the scopes and roles of the callers to the permissions they are granted
+type Policy struct {
+	grants map[string][]Permission
+}
+
+// NewPolicy creates a policy granting the given permissions to each scope or role
+func NewPolicy(grants map[string][]Permission) *Policy {
+	return &Policy{grants: grants}
+}
+
+// DefaultPolicy grants each permission to its own scope, where write implies read and
+// admin implies write, and the same to the reader, writer and admin roles
+func DefaultPolicy() *Policy {
+	read := []Permission{PermissionRead}
+	write := []Permission{PermissionRead, PermissionWrite}
+	admin := []Permission{PermissionRead, PermissionWrite, PermissionAdmin}
+
+	return NewPolicy(map[string][]Permission{
+		string(PermissionRead):  read,
+		string(PermissionWrite): write,
+		string(PermissionAdmin): admin,
+		"reader":                read,
+		"writer":                write,
+		"admin":                 admin,
+	})
+}
+
+// Authorize checks the caller found in the context is granted the given permission
+func (p *Policy) Authorize(ctx context.Context, permission Permission) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if p.allows(principal.Scopes, permission) || p.allows(principal.Roles, permission) {
+		return nil
+	}
+	return ErrForbidden
+}
+
+func (p *Policy) allows(names []string, permission Permission) bool {
+	for _, name := range names {
+		for _, granted := range p.grants[name] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Policy_Authorize(t *testing.T) {
+	policy := DefaultPolicy()
+
+	testData := []struct {
+		name       string
+		principal  *Principal
+		permission Permission
+		err        error
+	}{
+		{name: "no principal", permission: PermissionRead, err: ErrUnauthenticated},
+		{name: "no scopes", principal: &Principal{Subject: "jenny"}, permission: PermissionRead, err: ErrForbidden},
+		{name: "read scope reading", principal: &Principal{Scopes: []string{"gophers:read"}}, permission: PermissionRead},
+		{name: "read scope writing", principal: &Principal{Scopes: []string{"gophers:read"}}, permission: PermissionWrite, err: ErrForbidden},
+		{name: "write scope reading", principal: &Principal{Scopes: []string{"gophers:write"}}, permission: PermissionRead},
+		{name: "writer role removing", principal: &Principal{Roles: []string{"writer"}}, permission: PermissionAdmin, err: ErrForbidden},
+		{name: "admin role removing", principal: &Principal{Roles: []string{"admin"}}, permission: PermissionAdmin},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = WithPrincipal(ctx, *tt.principal)
+			}
+
+			err := policy.Authorize(ctx, tt.permission)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
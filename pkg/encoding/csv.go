@@ -0,0 +1,149 @@
+package encoding
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// CSV represents the gophers as the rows of a table, headed by the name of its columns
+var CSV Codec = csvCodec{}
+
+// formulaPrefixes start the cells the spreadsheets run as formulas, the cells written starting
+// with them are escaped with a quote, and the quote is removed when they are read back
+const formulaPrefixes = "=+-@\t\r"
+
+// csvHeader are the columns written, the ones read are found by their name so they can come in any order
+var csvHeader = []string{"ID", "name", "image", "age"}
+
+type csvCodec struct{}
+
+func (csvCodec) MediaType() string { return "text/csv" }
+func (csvCodec) Format() string    { return "csv" }
+
+func (c csvCodec) Encode(w io.Writer, g gopher.Gopher) error {
+	e := c.NewListEncoder(w)
+	if err := e.Encode(g); err != nil {
+		return err
+	}
+	return e.Close()
+}
+
+func (c csvCodec) Decode(r io.Reader, g *gopher.Gopher) error {
+	err := c.NewListDecoder(r).Decode(g)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (csvCodec) NewListEncoder(w io.Writer) ListEncoder {
+	return &csvListEncoder{writer: csv.NewWriter(w)}
+}
+
+func (csvCodec) NewListDecoder(r io.Reader) ListDecoder {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	return &csvListDecoder{reader: reader}
+}
+
+type csvListEncoder struct {
+	writer  *csv.Writer
+	started bool
+}
+
+func (e *csvListEncoder) Encode(g gopher.Gopher) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.writer.Write([]string{escapeFormula(g.ID), escapeFormula(g.Name), escapeFormula(g.Image), strconv.Itoa(g.Age)})
+}
+
+func (e *csvListEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvListEncoder) writeHeader() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	return e.writer.Write(csvHeader)
+}
+
+type csvListDecoder struct {
+	reader *csv.Reader
+	// columns is the index of each known column in the rows, -1 when missing
+	columns []int
+}
+
+func (d *csvListDecoder) Decode(g *gopher.Gopher) error {
+	if d.columns == nil {
+		if err := d.readHeader(); err != nil {
+			return err
+		}
+	}
+
+	row, err := d.reader.Read()
+	if err != nil {
+		return err
+	}
+	column := func(i int) string {
+		if d.columns[i] < 0 || d.columns[i] >= len(row) {
+			return ""
+		}
+		return unescapeFormula(row[d.columns[i]])
+	}
+
+	*g = gopher.Gopher{ID: column(0), Name: column(1), Image: column(2)}
+	if age := column(3); age != "" {
+		if g.Age, err = strconv.Atoi(age); err != nil {
+			line, _ := d.reader.FieldPos(0)
+			return fmt.Errorf("line %d: age must be an integer", line)
+		}
+	}
+	return nil
+}
+
+func (d *csvListDecoder) readHeader() error {
+	header, err := d.reader.Read()
+	if err != nil {
+		return err
+	}
+
+	d.columns = make([]int, len(csvHeader))
+	for i, name := range csvHeader {
+		d.columns[i] = -1
+		for j, column := range header {
+			if strings.EqualFold(strings.TrimSpace(column), name) {
+				d.columns[i] = j
+			}
+		}
+	}
+	return nil
+}
+
+// escapeFormula quotes the given cell when a spreadsheet would run it as a formula
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// unescapeFormula removes the quote escaping the given cell from being run as a formula
+func unescapeFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
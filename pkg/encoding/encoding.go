@@ -0,0 +1,168 @@
+package encoding
+
+import (
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// Codec writes and reads the gophers in a representation
+type Codec interface {
+	// MediaType is the media type of the representation, like text/csv
+	MediaType() string
+	// Format is the name the representation is asked for with, like csv
+	Format() string
+	// Encode writes a single gopher
+	Encode(w io.Writer, g gopher.Gopher) error
+	// Decode reads a single gopher
+	Decode(r io.Reader, g *gopher.Gopher) error
+	// NewListEncoder returns an encoder writing a list of gophers to the given writer
+	NewListEncoder(w io.Writer) ListEncoder
+	// NewListDecoder returns a decoder reading a list of gophers from the given reader
+	NewListDecoder(r io.Reader) ListDecoder
+}
+
+// ListEncoder writes a list of gophers one at a time, so the large lists are streamed
+type ListEncoder interface {
+	// Encode writes the next gopher of the list
+	Encode(g gopher.Gopher) error
+	// Close ends the list, it must be called even when the list is empty
+	Close() error
+}
+
+// ListDecoder reads a list of gophers one at a time
+type ListDecoder interface {
+	// Decode reads the next gopher of the list, it returns io.EOF when there are no more
+	Decode(g *gopher.Gopher) error
+}
+
+// Registry holds the codecs of the representations supported, the first one
+// is the default when the callers accept any
+type Registry struct {
+	codecs []Codec
+}
+
+// NewRegistry creates a registry with the given codecs
+func NewRegistry(codecs ...Codec) *Registry {
+	r := &Registry{}
+	for _, codec := range codecs {
+		r.Register(codec)
+	}
+	return r
+}
+
+// DefaultRegistry supports JSON, the default, NDJSON, CSV, XML and MessagePack
+func DefaultRegistry() *Registry {
+	return NewRegistry(JSON, NDJSON, CSV, XML, MessagePack)
+}
+
+// Register adds the given codec, replacing the one with the same media type
+func (r *Registry) Register(codec Codec) {
+	for i, registered := range r.codecs {
+		if registered.MediaType() == codec.MediaType() {
+			r.codecs[i] = codec
+			return
+		}
+	}
+	r.codecs = append(r.codecs, codec)
+}
+
+// Codecs returns the codecs registered, the default first
+func (r *Registry) Codecs() []Codec {
+	return r.codecs
+}
+
+// ByFormat returns the codec with the given format name
+func (r *Registry) ByFormat(format string) (Codec, bool) {
+	for _, codec := range r.codecs {
+		if strings.EqualFold(codec.Format(), format) {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// ByContentType returns the codec of the given Content-Type, the default one when it's empty
+func (r *Registry) ByContentType(contentType string) (Codec, bool) {
+	if contentType == "" {
+		return r.byDefault()
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	for _, codec := range r.codecs {
+		if codec.MediaType() == mediaType {
+			return codec, true
+		}
+	}
+	return nil, false
+}
+
+// Negotiate returns the codec preferred by the given Accept header, following the
+// quality of each media range, the default one when the header is empty
+func (r *Registry) Negotiate(accept string) (Codec, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return r.byDefault()
+	}
+
+	for _, mediaRange := range parseAccept(accept) {
+		for _, codec := range r.codecs {
+			if mediaRange.matches(codec.MediaType()) {
+				return codec, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (r *Registry) byDefault() (Codec, bool) {
+	if len(r.codecs) == 0 {
+		return nil, false
+	}
+	return r.codecs[0], true
+}
+
+// mediaRange is a media range of the Accept header, like text/* or application/json
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	if m.mediaType == "*/*" || m.mediaType == mediaType {
+		return true
+	}
+	prefix := strings.TrimSuffix(m.mediaType, "*")
+	return prefix != m.mediaType && strings.HasPrefix(mediaType, prefix)
+}
+
+// parseAccept returns the media ranges accepted by the given header, the preferred first,
+// the ranges with a zero quality or malformed are left out
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, accepted := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+	return ranges
+}
@@ -0,0 +1,174 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+var gophers = []gopher.Gopher{
+	{ID: "01D3XZ3ZHCP3KG9VT4FGAD8KDR", Name: "Jenny", Image: "https://via.placeholder.com/150.png", Age: 18},
+	{ID: "01D3XZ7CN92AKS9HAPSZ4D5DP9", Name: "Billy, \"the kid\""},
+}
+
+func Test_Codecs_RoundTrip(t *testing.T) {
+	for _, codec := range DefaultRegistry().Codecs() {
+		t.Run(codec.Format(), func(t *testing.T) {
+			// GIVEN a gopher and a list encoded with the codec
+			var single bytes.Buffer
+			require.NoError(t, codec.Encode(&single, gophers[0]))
+
+			var list bytes.Buffer
+			e := codec.NewListEncoder(&list)
+			for _, g := range gophers {
+				require.NoError(t, e.Encode(g))
+			}
+			require.NoError(t, e.Close())
+
+			// WHEN they are decoded
+			var got gopher.Gopher
+			require.NoError(t, codec.Decode(&single, &got))
+
+			// THEN they are read as they were written
+			assert.Equal(t, gophers[0], got)
+			assert.Equal(t, gophers, decodeList(t, codec, &list))
+		})
+	}
+}
+
+func Test_Codecs_EmptyList(t *testing.T) {
+	for _, codec := range DefaultRegistry().Codecs() {
+		t.Run(codec.Format(), func(t *testing.T) {
+			var list bytes.Buffer
+			require.NoError(t, codec.NewListEncoder(&list).Close())
+
+			assert.Empty(t, decodeList(t, codec, &list))
+		})
+	}
+}
+
+func Test_JSON_SameAsWholeSlice(t *testing.T) {
+	var list bytes.Buffer
+	e := JSON.NewListEncoder(&list)
+	for _, g := range gophers {
+		require.NoError(t, e.Encode(g))
+	}
+	require.NoError(t, e.Close())
+
+	assert.JSONEq(t, `[
+		{"ID": "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "name": "Jenny", "image": "https://via.placeholder.com/150.png", "age": 18},
+		{"ID": "01D3XZ7CN92AKS9HAPSZ4D5DP9", "name": "Billy, \"the kid\""}
+	]`, list.String())
+}
+
+func Test_CSV_ColumnsByName(t *testing.T) {
+	body := "age, name,id\n7,Jenny,01D3XZ3ZHCP3KG9VT4FGAD8KDR\n,Billy,01D3XZ7CN92AKS9HAPSZ4D5DP9\n"
+
+	got := decodeList(t, CSV, strings.NewReader(body))
+
+	assert.Equal(t, []gopher.Gopher{
+		{ID: "01D3XZ3ZHCP3KG9VT4FGAD8KDR", Name: "Jenny", Age: 7},
+		{ID: "01D3XZ7CN92AKS9HAPSZ4D5DP9", Name: "Billy"},
+	}, got)
+
+	var g gopher.Gopher
+	err := CSV.Decode(strings.NewReader("ID,age\n01D3XZ3ZHCP3KG9VT4FGAD8KDR,old\n"), &g)
+	assert.EqualError(t, err, "line 2: age must be an integer")
+	assert.ErrorIs(t, CSV.Decode(strings.NewReader("ID,age\n"), &g), io.ErrUnexpectedEOF)
+}
+
+func Test_CSV_EscapesFormulas(t *testing.T) {
+	// GIVEN gophers whose fields a spreadsheet would run as formulas
+	formulas := []gopher.Gopher{
+		{ID: "01D3XZ3ZHCP3KG9VT4FGAD8KDR", Name: "=HYPERLINK(\"https://evil.example\")", Image: "@SUM(A1)"},
+		{ID: "01D3XZ7CN92AKS9HAPSZ4D5DP9", Name: "-2+3", Image: "+1"},
+	}
+
+	// WHEN they are written as CSV
+	var list bytes.Buffer
+	e := CSV.NewListEncoder(&list)
+	for _, g := range formulas {
+		require.NoError(t, e.Encode(g))
+	}
+	require.NoError(t, e.Close())
+
+	// THEN the cells are quoted so they are shown as text
+	assert.Equal(t, "ID,name,image,age\n"+
+		"01D3XZ3ZHCP3KG9VT4FGAD8KDR,\"'=HYPERLINK(\"\"https://evil.example\"\")\",'@SUM(A1),0\n"+
+		"01D3XZ7CN92AKS9HAPSZ4D5DP9,'-2+3,'+1,0\n", list.String())
+
+	// AND they are read back as they were
+	assert.Equal(t, formulas, decodeList(t, CSV, &list))
+}
+
+func Test_Registry_Negotiate(t *testing.T) {
+	registry := DefaultRegistry()
+
+	testData := []struct {
+		name   string
+		accept string
+		codec  Codec
+	}{
+		{name: "no preference", accept: "", codec: JSON},
+		{name: "any", accept: "*/*", codec: JSON},
+		{name: "exact", accept: "text/csv", codec: CSV},
+		{name: "with parameters", accept: "application/json; version=1", codec: JSON},
+		{name: "by quality", accept: "application/json;q=0.5, application/x-ndjson", codec: NDJSON},
+		{name: "first of equal quality", accept: "application/msgpack, application/xml", codec: MessagePack},
+		{name: "range", accept: "text/*", codec: CSV},
+		{name: "refused", accept: "text/csv;q=0, application/xml;q=0.1", codec: XML},
+		{name: "unsupported", accept: "image/png"},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			codec, ok := registry.Negotiate(tt.accept)
+
+			assert.Equal(t, tt.codec != nil, ok)
+			assert.Equal(t, tt.codec, codec)
+		})
+	}
+}
+
+func Test_Registry_Lookup(t *testing.T) {
+	registry := NewRegistry(JSON, CSV)
+
+	codec, ok := registry.ByFormat("CSV")
+	assert.True(t, ok)
+	assert.Equal(t, CSV, codec)
+	_, ok = registry.ByFormat("xml")
+	assert.False(t, ok)
+
+	codec, ok = registry.ByContentType("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, codec)
+	codec, ok = registry.ByContentType("")
+	assert.True(t, ok)
+	assert.Equal(t, JSON, codec)
+	_, ok = registry.ByContentType("application/msgpack")
+	assert.False(t, ok)
+}
+
+func decodeList(t *testing.T, codec Codec, r io.Reader) []gopher.Gopher {
+	t.Helper()
+
+	var (
+		got []gopher.Gopher
+		d   = codec.NewListDecoder(r)
+	)
+	for {
+		var g gopher.Gopher
+		err := d.Decode(&g)
+		if err == io.EOF {
+			return got
+		}
+		require.NoError(t, err)
+		got = append(got, g)
+	}
+}
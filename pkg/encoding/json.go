@@ -0,0 +1,138 @@
+package encoding
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+var (
+	// JSON represents the gophers as JSON objects, and the lists as arrays
+	JSON Codec = jsonCodec{}
+	// NDJSON represents the gophers as JSON objects, and the lists as one object per line
+	NDJSON Codec = ndjsonCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) MediaType() string { return "application/json" }
+func (jsonCodec) Format() string    { return "json" }
+
+func (jsonCodec) Encode(w io.Writer, g gopher.Gopher) error {
+	return json.NewEncoder(w).Encode(g)
+}
+
+func (jsonCodec) Decode(r io.Reader, g *gopher.Gopher) error {
+	return json.NewDecoder(r).Decode(g)
+}
+
+func (jsonCodec) NewListEncoder(w io.Writer) ListEncoder {
+	return &jsonListEncoder{w: w}
+}
+
+func (jsonCodec) NewListDecoder(r io.Reader) ListDecoder {
+	return &jsonListDecoder{decoder: json.NewDecoder(r)}
+}
+
+// jsonListEncoder writes the array one item at a time, the same way as encoding the whole slice
+type jsonListEncoder struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonListEncoder) Encode(g gopher.Gopher) error {
+	item, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+
+	separator := []byte(",")
+	if !e.started {
+		separator, e.started = []byte("["), true
+	}
+	if _, err := e.w.Write(separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(item)
+	return err
+}
+
+func (e *jsonListEncoder) Close() error {
+	end := "]\n"
+	if !e.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+type jsonListDecoder struct {
+	decoder *json.Decoder
+	started bool
+}
+
+func (d *jsonListDecoder) Decode(g *gopher.Gopher) error {
+	if !d.started {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return err
+		}
+		if token != json.Delim('[') {
+			return errors.New("expected a JSON array of gophers")
+		}
+		d.started = true
+	}
+
+	if !d.decoder.More() {
+		if _, err := d.decoder.Token(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	*g = gopher.Gopher{}
+	return d.decoder.Decode(g)
+}
+
+type ndjsonCodec struct{}
+
+func (ndjsonCodec) MediaType() string { return "application/x-ndjson" }
+func (ndjsonCodec) Format() string    { return "ndjson" }
+
+func (ndjsonCodec) Encode(w io.Writer, g gopher.Gopher) error {
+	return json.NewEncoder(w).Encode(g)
+}
+
+func (ndjsonCodec) Decode(r io.Reader, g *gopher.Gopher) error {
+	return json.NewDecoder(r).Decode(g)
+}
+
+func (ndjsonCodec) NewListEncoder(w io.Writer) ListEncoder {
+	return ndjsonListEncoder{encoder: json.NewEncoder(w)}
+}
+
+func (ndjsonCodec) NewListDecoder(r io.Reader) ListDecoder {
+	return ndjsonListDecoder{decoder: json.NewDecoder(r)}
+}
+
+type ndjsonListEncoder struct {
+	encoder *json.Encoder
+}
+
+func (e ndjsonListEncoder) Encode(g gopher.Gopher) error {
+	return e.encoder.Encode(g)
+}
+
+func (ndjsonListEncoder) Close() error {
+	return nil
+}
+
+type ndjsonListDecoder struct {
+	decoder *json.Decoder
+}
+
+func (d ndjsonListDecoder) Decode(g *gopher.Gopher) error {
+	*g = gopher.Gopher{}
+	return d.decoder.Decode(g)
+}
@@ -0,0 +1,95 @@
+package encoding
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// MessagePack represents the gophers as maps with the same keys as the JSON objects,
+// and the lists as arrays
+var MessagePack Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) MediaType() string { return "application/msgpack" }
+func (msgpackCodec) Format() string    { return "msgpack" }
+
+func (msgpackCodec) Encode(w io.Writer, g gopher.Gopher) error {
+	return newMsgpackEncoder(w).Encode(g)
+}
+
+func (msgpackCodec) Decode(r io.Reader, g *gopher.Gopher) error {
+	return newMsgpackDecoder(r).Decode(g)
+}
+
+func (msgpackCodec) NewListEncoder(w io.Writer) ListEncoder {
+	e := &msgpackListEncoder{w: w}
+	e.encoder = newMsgpackEncoder(&e.items)
+	return e
+}
+
+func (msgpackCodec) NewListDecoder(r io.Reader) ListDecoder {
+	return &msgpackListDecoder{decoder: newMsgpackDecoder(r), remaining: -1}
+}
+
+func newMsgpackEncoder(w io.Writer) *msgpack.Encoder {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder
+}
+
+func newMsgpackDecoder(r io.Reader) *msgpack.Decoder {
+	decoder := msgpack.NewDecoder(r)
+	decoder.SetCustomStructTag("json")
+	return decoder
+}
+
+// msgpackListEncoder holds the items until the list is closed, as the arrays
+// are headed by their length
+type msgpackListEncoder struct {
+	w       io.Writer
+	encoder *msgpack.Encoder
+	items   bytes.Buffer
+	count   int
+}
+
+func (e *msgpackListEncoder) Encode(g gopher.Gopher) error {
+	e.count++
+	return e.encoder.Encode(g)
+}
+
+func (e *msgpackListEncoder) Close() error {
+	if err := msgpack.NewEncoder(e.w).EncodeArrayLen(e.count); err != nil {
+		return err
+	}
+	_, err := e.items.WriteTo(e.w)
+	return err
+}
+
+type msgpackListDecoder struct {
+	decoder *msgpack.Decoder
+	// remaining is the number of items left to read, -1 until the array is started
+	remaining int
+}
+
+func (d *msgpackListDecoder) Decode(g *gopher.Gopher) error {
+	if d.remaining < 0 {
+		n, err := d.decoder.DecodeArrayLen()
+		if err != nil {
+			return err
+		}
+		// a nil array is read as an empty one
+		d.remaining = max(n, 0)
+	}
+	if d.remaining <= 0 {
+		return io.EOF
+	}
+
+	d.remaining--
+	*g = gopher.Gopher{}
+	return d.decoder.Decode(g)
+}
@@ -0,0 +1,118 @@
+package encoding
+
+import (
+	"encoding/xml"
+	"io"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// XML represents the gophers as gopher elements, and the lists as a gophers element
+var XML Codec = xmlCodec{}
+
+// xmlGopher is the XML element of a gopher, with the same names as the JSON fields
+type xmlGopher struct {
+	XMLName xml.Name `xml:"gopher"`
+	ID      string   `xml:"ID"`
+	Name    string   `xml:"name,omitempty"`
+	Image   string   `xml:"image,omitempty"`
+	Age     int      `xml:"age,omitempty"`
+}
+
+func newXMLGopher(g gopher.Gopher) xmlGopher {
+	return xmlGopher{ID: g.ID, Name: g.Name, Image: g.Image, Age: g.Age}
+}
+
+func (x xmlGopher) gopher() gopher.Gopher {
+	return gopher.Gopher{ID: x.ID, Name: x.Name, Image: x.Image, Age: x.Age}
+}
+
+type xmlCodec struct{}
+
+func (xmlCodec) MediaType() string { return "application/xml" }
+func (xmlCodec) Format() string    { return "xml" }
+
+func (xmlCodec) Encode(w io.Writer, g gopher.Gopher) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(newXMLGopher(g))
+}
+
+func (xmlCodec) Decode(r io.Reader, g *gopher.Gopher) error {
+	var x xmlGopher
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return err
+	}
+	*g = x.gopher()
+	return nil
+}
+
+func (xmlCodec) NewListEncoder(w io.Writer) ListEncoder {
+	return &xmlListEncoder{w: w, encoder: xml.NewEncoder(w)}
+}
+
+func (xmlCodec) NewListDecoder(r io.Reader) ListDecoder {
+	return xmlListDecoder{decoder: xml.NewDecoder(r)}
+}
+
+var xmlGophers = xml.StartElement{Name: xml.Name{Local: "gophers"}}
+
+type xmlListEncoder struct {
+	w       io.Writer
+	encoder *xml.Encoder
+	started bool
+}
+
+func (e *xmlListEncoder) Encode(g gopher.Gopher) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.encoder.Encode(newXMLGopher(g))
+}
+
+func (e *xmlListEncoder) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	if err := e.encoder.EncodeToken(xmlGophers.End()); err != nil {
+		return err
+	}
+	return e.encoder.Flush()
+}
+
+func (e *xmlListEncoder) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if _, err := io.WriteString(e.w, xml.Header); err != nil {
+		return err
+	}
+	return e.encoder.EncodeToken(xmlGophers)
+}
+
+type xmlListDecoder struct {
+	decoder *xml.Decoder
+}
+
+// Decode reads the next gopher element, wherever it's nested
+func (d xmlListDecoder) Decode(g *gopher.Gopher) error {
+	for {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "gopher" {
+			continue
+		}
+		var x xmlGopher
+		if err := d.decoder.DecodeElement(&x, &start); err != nil {
+			return err
+		}
+		*g = x.gopher()
+		return nil
+	}
+}
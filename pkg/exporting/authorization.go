@@ -0,0 +1,26 @@
+package exporting
+
+import (
+	"context"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps an exporting service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// ExportGophers exports the gophers if the caller has read permission
+func (s *authorizingService) ExportGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return err
+	}
+	return s.next.ExportGophers(ctx, fn)
+}
@@ -0,0 +1,28 @@
+package exporting
+
+import (
+	"context"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// Service provides exporting operations.
+type Service interface {
+	// ExportGophers calls fn with each gopher saved in storage, stopping at the first error returned
+	ExportGophers(ctx context.Context, fn func(gopher.Gopher) error) error
+}
+
+type service struct {
+	repository gopher.Repository
+}
+
+// NewService creates an exporting service with the necessary dependencies
+func NewService(repository gopher.Repository) Service {
+	return &service{repository}
+}
+
+// ExportGophers reads the gophers one at a time when the storage is able to stream them,
+// so they don't have to be loaded all in memory
+func (s *service) ExportGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	return gopher.StreamGophers(ctx, s.repository, fn)
+}
@@ -0,0 +1,36 @@
+package exporting
+
+import (
+	"context"
+	"strconv"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps an exporting service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// ExportGophers exports the gophers within a span, tagging how many were exported
+func (s *tracingService) ExportGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "exporting.ExportGophers")
+	defer span.Finish()
+
+	var exported int
+	err := s.next.ExportGophers(ctx, func(g gopher.Gopher) error {
+		exported++
+		return fn(g)
+	})
+	span.Tag("gophers.exported", strconv.Itoa(exported))
+	if err != nil {
+		span.SetError(err)
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package fetching
+
+import (
+	"context"
+	"time"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps a fetching service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// FetchGophers returns all gophers if the caller has read permission
+func (s *authorizingService) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.next.FetchGophers(ctx)
+}
+
+// StreamGophers calls fn with each gopher if the caller has read permission
+func (s *authorizingService) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return err
+	}
+	return s.next.StreamGophers(ctx, fn)
+}
+
+// FetchGopherByID returns a gopher if the caller has read permission
+func (s *authorizingService) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.next.FetchGopherByID(ctx, ID)
+}
+
+// FetchGophersByIDs returns the gophers with the given IDs if the caller has read permission
+func (s *authorizingService) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.next.FetchGophersByIDs(ctx, IDs)
+}
+
+// FetchGopherRevisions returns the revision history of a gopher if the caller has read permission
+func (s *authorizingService) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.next.FetchGopherRevisions(ctx, ID)
+}
+
+// FetchGopherAsOf returns a gopher as it was at the given moment if the caller has read permission
+func (s *authorizingService) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.next.FetchGopherAsOf(ctx, ID, at)
+}
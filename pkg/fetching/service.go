@@ -2,6 +2,8 @@ package fetching
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	gopher "github.com/friendsofgo/gopherapi/pkg"
 	"github.com/friendsofgo/gopherapi/pkg/log"
@@ -10,7 +12,11 @@ import (
 // Service provides fetching operations.
 type Service interface {
 	FetchGophers(ctx context.Context) ([]gopher.Gopher, error)
-	FetchGopherByID(ctx context.Context, ID string) *gopher.Gopher
+	StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error
+	FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error)
+	FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error)
+	FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error)
+	FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error)
 }
 
 type service struct {
@@ -28,15 +34,70 @@ func (s *service) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
 	return s.repository.FetchGophers(ctx)
 }
 
-// FetchGopherByID returns a gopher
-func (s *service) FetchGopherByID(ctx context.Context, ID string) *gopher.Gopher {
+// StreamGophers calls fn with each gopher, reading them one at a time when the storage is able to
+func (s *service) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	return gopher.StreamGophers(ctx, s.repository, fn)
+}
+
+// FetchGopherByID returns a gopher, or gopher.ErrNotFound when it doesn't exist
+func (s *service) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
 	g, err := s.repository.FetchGopherByID(ctx, ID)
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.RepositoryError(ctx, "FetchGopherByID", err)
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// FetchGophersByIDs returns the gophers with the given IDs, leaving out the ones which don't exist
+func (s *service) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	gophers, err := gopher.FetchGophersByIDs(ctx, s.repository, IDs)
+	if err != nil {
+		s.logger.RepositoryError(ctx, "FetchGophersByIDs", err)
+		return nil, err
+	}
+
+	return gophers, nil
+}
 
-	// This error can be any error type of our repository
+// FetchGopherRevisions returns the revision history of a gopher, or gopher.ErrNotFound when it has none
+func (s *service) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	repository, ok := s.repository.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+
+	revisions, err := repository.FetchGopherRevisions(ctx, ID)
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		s.logger.RepositoryError(ctx, "FetchGopherRevisions", err)
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// FetchGopherAsOf returns a gopher as it was at the given moment, or gopher.ErrNotFound when it didn't exist yet
+func (s *service) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	repository, ok := s.repository.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+
+	g, err := repository.FetchGopherAsOf(ctx, ID, at)
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, err
+	}
 	if err != nil {
-		s.logger.UnexpectedError(ctx, err)
-		return nil
+		s.logger.RepositoryError(ctx, "FetchGopherAsOf", err)
+		return nil, err
 	}
 
-	return g
+	return g, nil
 }
@@ -0,0 +1,98 @@
+package fetching
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps a fetching service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// FetchGophers returns all gophers within a span
+func (s *tracingService) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "fetching.FetchGophers")
+	defer span.Finish()
+
+	gophers, err := s.next.FetchGophers(ctx)
+	if err != nil {
+		span.SetError(err)
+	}
+	return gophers, err
+}
+
+// StreamGophers calls fn with each gopher within a span
+func (s *tracingService) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "fetching.StreamGophers")
+	defer span.Finish()
+
+	err := s.next.StreamGophers(ctx, fn)
+	if err != nil {
+		span.SetError(err)
+	}
+	return err
+}
+
+// FetchGopherByID returns a gopher within a span, tagging whether it was found
+func (s *tracingService) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "fetching.FetchGopherByID")
+	span.Tag("gopher.id", ID)
+	defer span.Finish()
+
+	g, err := s.next.FetchGopherByID(ctx, ID)
+	if errors.Is(err, gopher.ErrNotFound) {
+		span.Tag("gopher.found", "false")
+	} else if err != nil {
+		span.SetError(err)
+	}
+	return g, err
+}
+
+// FetchGophersByIDs returns the gophers with the given IDs within a span
+func (s *tracingService) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "fetching.FetchGophersByIDs")
+	defer span.Finish()
+
+	gophers, err := s.next.FetchGophersByIDs(ctx, IDs)
+	if err != nil {
+		span.SetError(err)
+	}
+	return gophers, err
+}
+
+// FetchGopherRevisions returns the revision history of a gopher within a span
+func (s *tracingService) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "fetching.FetchGopherRevisions")
+	span.Tag("gopher.id", ID)
+	defer span.Finish()
+
+	revisions, err := s.next.FetchGopherRevisions(ctx, ID)
+	if err != nil {
+		span.SetError(err)
+	}
+	return revisions, err
+}
+
+// FetchGopherAsOf returns a gopher as it was at the given moment within a span
+func (s *tracingService) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "fetching.FetchGopherAsOf")
+	span.Tag("gopher.id", ID)
+	span.Tag("gopher.as_of", at.Format(time.RFC3339))
+	defer span.Finish()
+
+	g, err := s.next.FetchGopherAsOf(ctx, ID, at)
+	if err != nil {
+		span.SetError(err)
+	}
+	return g, err
+}
@@ -2,9 +2,30 @@ package gopher
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+var (
+	// ErrRevisionsNotSupported is returned when the configured storage does not keep revisions
+	ErrRevisionsNotSupported = errors.New("gopher revisions are not supported by this storage")
+	// ErrNotFound is returned by the repositories when the gopher doesn't exist
+	ErrNotFound = errors.New("gopher not found")
+	// ErrAlreadyExists is returned by the repositories when creating a gopher with the ID of another one
+	ErrAlreadyExists = errors.New("gopher already exists")
+	// ErrReservedID is returned when creating a gopher with one of the reserved IDs
+	ErrReservedID = errors.New("the gopher ID is reserved")
+)
+
+// reservedIDs name the routes next to the gophers, like /gophers/search, a gopher
+// with one of them as ID couldn't be fetched
+var reservedIDs = map[string]bool{"export": true, "import": true, "search": true}
+
+// ReservedID reports whether the given ID can't be given to a gopher
+func ReservedID(ID string) bool {
+	return reservedIDs[ID]
+}
+
 // Gopher defines the properties of a gopher to be listed
 type Gopher struct {
 	ID        string     `json:"ID"`
@@ -25,16 +46,88 @@ func New(ID, name, image string, age int) *Gopher {
 	}
 }
 
+// Revision is an immutable snapshot of a gopher stored every time it is created, updated or
+// deleted, the revisions deleting it only carry its ID
+type Revision struct {
+	Number    int       `json:"revision"`
+	Gopher    Gopher    `json:"gopher"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
 //Repository provides access to the gopher storage
 type Repository interface {
-	// CreateGopher saves a given gopher
+	// CreateGopher saves a given gopher, failing with ErrAlreadyExists if its ID is taken
 	CreateGopher(ctx context.Context, gopher *Gopher) error
 	// FetchGophers return all gophers saved in storage
 	FetchGophers(ctx context.Context) ([]Gopher, error)
 	// DeleteGopher remove gopher with given ID
 	DeleteGopher(ctx context.Context, ID string) error
-	// UpdateGopher modify gopher with given ID and given new data
+	// UpdateGopher modify gopher with given ID and given new data, failing with ErrNotFound if it doesn't exist
 	UpdateGopher(ctx context.Context, ID string, gopher Gopher) error
 	// FetchGopherByID returns the gopher with given ID
 	FetchGopherByID(ctx context.Context, ID string) (*Gopher, error)
 }
+
+// RevisionRepository provides access to the revision history of the gophers,
+// it is optional and only implemented by the storages which keep revisions
+type RevisionRepository interface {
+	// FetchGopherRevisions returns all revisions of the gopher with given ID, oldest first
+	FetchGopherRevisions(ctx context.Context, ID string) ([]Revision, error)
+	// FetchGopherAsOf returns the gopher with given ID as it was at the given moment
+	FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*Gopher, error)
+}
+
+// StreamRepository reads the gophers one at a time, it is optional and only implemented
+// by the storages which can read them without loading them all in memory
+type StreamRepository interface {
+	// StreamGophers calls fn with each gopher saved in storage, stopping at the first error returned
+	StreamGophers(ctx context.Context, fn func(Gopher) error) error
+}
+
+// StreamGophers calls fn with each gopher of the given repository, streaming them when the
+// repository is able to, and fetching them all at once otherwise
+func StreamGophers(ctx context.Context, r Repository, fn func(Gopher) error) error {
+	if stream, ok := r.(StreamRepository); ok {
+		return stream.StreamGophers(ctx, fn)
+	}
+
+	gophers, err := r.FetchGophers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, g := range gophers {
+		if err := fn(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchRepository reads several gophers by ID at once, it is optional and only implemented
+// by the storages which can look them up together instead of one at a time
+type BatchRepository interface {
+	// FetchGophersByIDs returns the gophers with the given IDs, leaving out the ones which don't exist
+	FetchGophersByIDs(ctx context.Context, IDs []string) ([]Gopher, error)
+}
+
+// FetchGophersByIDs returns the gophers of the given repository with the given IDs, looked up
+// together when the repository is able to, and one at a time otherwise
+func FetchGophersByIDs(ctx context.Context, r Repository, IDs []string) ([]Gopher, error) {
+	if batch, ok := r.(BatchRepository); ok {
+		return batch.FetchGophersByIDs(ctx, IDs)
+	}
+
+	gophers := make([]Gopher, 0, len(IDs))
+	for _, ID := range IDs {
+		g, err := r.FetchGopherByID(ctx, ID)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		gophers = append(gophers, *g)
+	}
+	return gophers, nil
+}
@@ -0,0 +1,165 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/removing"
+)
+
+// maxRequestBytes is the maximum size of the body of the requests
+const maxRequestBytes = 1 << 20
+
+type handler struct {
+	schema graphql.Schema
+	limits Limits
+	logger log.Logger
+
+	fetching fetching.Service
+}
+
+// request is a GraphQL request, as sent in the body of the POST requests
+// or in the parameters of the GET ones
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler creates the handler of the GraphQL api resolved by the given services, the
+// queries are accepted with GET and POST, and the mutations only with POST
+func NewHandler(
+	fS fetching.Service,
+	aS adding.Service,
+	mS modifying.Service,
+	rS removing.Service,
+	logger log.Logger,
+	limits Limits,
+) (http.Handler, error) {
+	schema, err := newSchema(&resolver{fetching: fS, adding: aS, modifying: mS, removing: rS, logger: logger})
+	if err != nil {
+		return nil, fmt.Errorf("building the GraphQL schema: %w", err)
+	}
+	return &handler{schema: schema, limits: limits, logger: logger, fetching: fS}, nil
+}
+
+// ServeHTTP implements http.Handler
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := readRequest(w, r)
+	if err != nil {
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		h.logger.InvalidRequest(r.Context(), errors.New(result.Errors[0].Message))
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: result.Errors})
+		return
+	}
+
+	operation, err := findOperation(doc, req.OperationName)
+	if err != nil {
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+	if operation.Operation == ast.OperationTypeMutation && r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		h.reject(w, r, http.StatusMethodNotAllowed, errors.New("mutations must be sent with POST"))
+		return
+	}
+	if err := h.limits.check(doc, operation, req.Variables); err != nil {
+		h.reject(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoader(r.Context(), newGopherLoader(h.fetching)),
+	})
+	writeResult(w, http.StatusOK, result)
+}
+
+// readRequest reads the GraphQL request from the parameters of the GET requests or the JSON body of the POST ones
+func readRequest(w http.ResponseWriter, r *http.Request) (request, error) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return req, fmt.Errorf("invalid variables: %w", err)
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+			return req, fmt.Errorf("invalid request body: %w", err)
+		}
+	default:
+		return req, fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	if req.Query == "" {
+		return req, errors.New("query is required")
+	}
+	return req, nil
+}
+
+// findOperation returns the operation of the document to execute, the one with the given
+// name or the only one when no name is given
+func findOperation(doc *ast.Document, name string) (*ast.OperationDefinition, error) {
+	var found *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if found != nil {
+				return nil, errors.New("operationName is required with several operations")
+			}
+			found = operation
+			continue
+		}
+		if operation.Name != nil && operation.Name.Value == name {
+			return operation, nil
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("operation %q not found", name)
+	}
+	return found, nil
+}
+
+// reject answers the request with the given error, without executing it
+func (h *handler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	h.logger.InvalidRequest(r.Context(), err)
+	writeResult(w, status, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,236 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/removing"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+)
+
+type result struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string            `json:"message"`
+		Extensions map[string]string `json:"extensions"`
+	} `json:"errors"`
+}
+
+// countingService counts the calls to the fetching service
+type countingService struct {
+	fetching.Service
+	fetchGophers, fetchGopherByID, fetchGophersByIDs int
+}
+
+func (s *countingService) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	s.fetchGophers++
+	return s.Service.FetchGophers(ctx)
+}
+
+func (s *countingService) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	s.fetchGopherByID++
+	return s.Service.FetchGopherByID(ctx, ID)
+}
+
+func (s *countingService) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	s.fetchGophersByIDs++
+	return s.Service.FetchGophersByIDs(ctx, IDs)
+}
+
+func Test_Handler_Gopher(t *testing.T) {
+	h, fS := buildHandler(t, DefaultLimits)
+
+	// WHEN several gophers are asked by ID in the same query
+	res := post(t, h, `{
+		jenny: gopher(id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR") { name age }
+		billy: gopher(id: "01D3XZ7CN92AKS9HAPSZ4D5DP9") { name }
+		again: gopher(id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR") { id }
+		nobody: gopher(id: "123") { name }
+	}`, nil)
+
+	// THEN they are looked up together, once each, without fetching every gopher
+	require.Empty(t, res.Errors)
+	assert.Equal(t, map[string]interface{}{"name": "Jenny", "age": float64(18)}, res.Data["jenny"])
+	assert.Equal(t, map[string]interface{}{"name": "Billy"}, res.Data["billy"])
+	assert.Equal(t, map[string]interface{}{"id": "01D3XZ3ZHCP3KG9VT4FGAD8KDR"}, res.Data["again"])
+	assert.Nil(t, res.Data["nobody"])
+	assert.Equal(t, 1, fS.fetchGophersByIDs)
+	assert.Equal(t, 0, fS.fetchGophers)
+	assert.Equal(t, 0, fS.fetchGopherByID)
+}
+
+func Test_Handler_Gophers(t *testing.T) {
+	h, _ := buildHandler(t, DefaultLimits)
+	query := `query ($after: String) {
+		gophers(first: 2, after: $after) {
+			totalCount
+			edges { node { id } }
+			pageInfo { endCursor hasNextPage }
+		}
+	}`
+
+	var (
+		IDs   []string
+		after interface{}
+	)
+	for {
+		res := post(t, h, query, map[string]interface{}{"after": after})
+		require.Empty(t, res.Errors)
+
+		gophers := res.Data["gophers"].(map[string]interface{})
+		assert.Equal(t, float64(len(sample.Gophers)), gophers["totalCount"])
+		for _, edge := range gophers["edges"].([]interface{}) {
+			IDs = append(IDs, edge.(map[string]interface{})["node"].(map[string]interface{})["id"].(string))
+		}
+
+		pageInfo := gophers["pageInfo"].(map[string]interface{})
+		if !pageInfo["hasNextPage"].(bool) {
+			break
+		}
+		after = pageInfo["endCursor"]
+	}
+	assert.Len(t, IDs, len(sample.Gophers))
+	assert.IsIncreasing(t, IDs)
+
+	res := post(t, h, `{ gophers(filter: {name: "JEN", maxAge: 20}) { edges { node { name } } } }`, nil)
+	require.Empty(t, res.Errors)
+	assert.Equal(t, []interface{}{map[string]interface{}{"node": map[string]interface{}{"name": "Jenny"}}},
+		res.Data["gophers"].(map[string]interface{})["edges"])
+}
+
+func Test_Handler_Mutations(t *testing.T) {
+	h, _ := buildHandler(t, DefaultLimits)
+
+	res := post(t, h, `mutation { createGopher(input: {id: "01DCBP0R0MSNZY975ZQF1DCQCH", name: "Eustaqio", age: 99}) { id name createdAt } }`, nil)
+	require.Empty(t, res.Errors)
+	assert.NotNil(t, res.Data["createGopher"].(map[string]interface{})["createdAt"])
+
+	res = post(t, h, `mutation { createGopher(input: {id: "01DCBP0R0MSNZY975ZQF1DCQCH", name: "Eustaqio"}) { id } }`, nil)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, codeConflict, res.Errors[0].Extensions["code"])
+
+	// only the fields given are changed
+	res = post(t, h, `mutation { updateGopher(id: "01DCBP0R0MSNZY975ZQF1DCQCH", input: {age: 100}) { name age } }`, nil)
+	require.Empty(t, res.Errors)
+	assert.Equal(t, map[string]interface{}{"name": "Eustaqio", "age": float64(100)}, res.Data["updateGopher"])
+
+	res = post(t, h, `mutation { deleteGopher(id: "01DCBP0R0MSNZY975ZQF1DCQCH") }`, nil)
+	require.Empty(t, res.Errors)
+
+	res = post(t, h, `mutation { updateGopher(id: "01DCBP0R0MSNZY975ZQF1DCQCH", input: {age: 1}) { age } }`, nil)
+	require.Len(t, res.Errors, 1)
+	assert.Equal(t, codeNotFound, res.Errors[0].Extensions["code"])
+
+	// the mutations can't be sent with GET
+	req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(`mutation { deleteGopher(id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR") }`), nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func Test_Handler_Limits(t *testing.T) {
+	h, _ := buildHandler(t, Limits{MaxDepth: 3, MaxComplexity: 50})
+
+	testData := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{name: "within the limits", query: `{ gophers(first: 10) { totalCount edges { cursor } } }`, status: http.StatusOK},
+		{name: "too deep", query: `{ gophers { edges { node { name } } } }`, status: http.StatusBadRequest},
+		{name: "too deep through a fragment", query: `{ gophers { ...edges } } fragment edges on GopherConnection { edges { node { id } } }`, status: http.StatusBadRequest},
+		{name: "too complex", query: `{ gophers(first: 30) { totalCount edges { cursor } } }`, status: http.StatusBadRequest},
+		{name: "introspection", query: `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, status: http.StatusOK},
+		{name: "invalid field", query: `{ gophers { color } }`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(map[string]string{"query": tt.query})
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func Test_Handler_Authorization(t *testing.T) {
+	policy := auth.DefaultPolicy()
+	repo := inmem.NewRepository(nil)
+	h, err := NewHandler(
+		fetching.NewAuthorizingService(fetching.NewService(repo, log.NewNoopLogger()), policy),
+		adding.NewAuthorizingService(adding.NewService(repo), policy),
+		modifying.NewAuthorizingService(modifying.NewService(repo), policy),
+		removing.NewAuthorizingService(removing.NewService(repo), policy),
+		log.NewNoopLogger(),
+		DefaultLimits,
+	)
+	require.NoError(t, err)
+
+	testData := []struct {
+		name   string
+		scopes []string
+		query  string
+	}{
+		{name: "reader removing a gopher", scopes: []string{string(auth.PermissionRead)}, query: `mutation { deleteGopher(id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR") }`},
+		{name: "nobody fetching a gopher", query: `{ gopher(id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR") { name } }`},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN a caller runs an operation it has no permission for
+			body, _ := json.Marshal(map[string]string{"query": tt.query})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "ci", Scopes: tt.scopes}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			// THEN the operation fails as forbidden
+			var res result
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+			require.Len(t, res.Errors, 1)
+			assert.Equal(t, codeForbidden, res.Errors[0].Extensions["code"], res.Errors[0].Message)
+		})
+	}
+}
+
+func post(t *testing.T, h http.Handler, query string, variables map[string]interface{}) result {
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var res result
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	return res
+}
+
+func buildHandler(t *testing.T, limits Limits) (http.Handler, *countingService) {
+	gophers := make(map[string]gopher.Gopher, len(sample.Gophers))
+	for ID, g := range sample.Gophers {
+		gophers[ID] = g
+	}
+
+	repo := inmem.NewRepository(gophers)
+	fS := &countingService{Service: fetching.NewService(repo, log.NewNoopLogger())}
+	h, err := NewHandler(fS, adding.NewService(repo), modifying.NewService(repo), removing.NewService(repo), log.NewNoopLogger(), limits)
+	require.NoError(t, err)
+	return h, fS
+}
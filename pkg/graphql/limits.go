@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bounds the cost of the queries, so a single request can't ask for the whole
+// storage many times over
+type Limits struct {
+	// MaxDepth is the maximum nesting of the fields selected
+	MaxDepth int
+	// MaxComplexity is the maximum cost of the query, each field costs 1 and the fields
+	// selected on each item of a list cost as many times as items asked for
+	MaxComplexity int
+}
+
+// DefaultLimits are the limits applied when none are given
+var DefaultLimits = Limits{MaxDepth: 10, MaxComplexity: 1000}
+
+// check rejects the operation when it exceeds the limits, the introspection fields are
+// not counted so the tools can still read the schema
+func (l Limits) check(doc *ast.Document, operation *ast.OperationDefinition, variables map[string]interface{}) error {
+	a := analysis{fragments: make(map[string]*ast.FragmentDefinition), variables: variables}
+	for _, definition := range doc.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			a.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	depth, complexity := a.selectionSet(operation.SelectionSet, 0)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, l.MaxDepth)
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, l.MaxComplexity)
+	}
+	return nil
+}
+
+type analysis struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selectionSet returns the depth and the complexity of the given selection set, nested at the given depth
+func (a analysis) selectionSet(set *ast.SelectionSet, depth int) (int, int) {
+	if set == nil {
+		return depth, 0
+	}
+
+	maxDepth, complexity := depth, 0
+	add := func(d, c int) {
+		if d > maxDepth {
+			maxDepth = d
+		}
+		complexity += c
+	}
+
+	for _, selection := range set.Selections {
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			d, c := a.selectionSet(s.SelectionSet, depth+1)
+			add(d, 1+c*a.listSize(s))
+		case *ast.InlineFragment:
+			add(a.selectionSet(s.SelectionSet, depth))
+		case *ast.FragmentSpread:
+			// the fragments cycles are rejected by the validation before the limits are checked
+			if fragment, ok := a.fragments[s.Name.Value]; ok {
+				add(a.selectionSet(fragment.SelectionSet, depth))
+			}
+		}
+	}
+	return maxDepth, complexity
+}
+
+// listSize returns how many items the given field asks for, given by its first argument
+// on the paginated lists
+func (a analysis) listSize(field *ast.Field) int {
+	if field.Name.Value != "gophers" {
+		return 1
+	}
+
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			switch n := a.variables[value.Name.Value].(type) {
+			case float64:
+				if n > 0 {
+					return int(n)
+				}
+			case int:
+				if n > 0 {
+					return n
+				}
+			}
+		}
+	}
+	return defaultPageSize
+}
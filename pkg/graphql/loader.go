@@ -0,0 +1,108 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+)
+
+var contextKeyLoader = contextKey("loader")
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "graphql" + string(c)
+}
+
+// gopherLoader batches and caches the gophers fetched by ID while resolving a query, the
+// IDs asked by the fields of the same level are fetched together when the first one is needed
+type gopherLoader struct {
+	fetching fetching.Service
+
+	mtx     sync.Mutex
+	results map[string]*loadResult
+	pending map[string]*loadResult
+}
+
+type loadResult struct {
+	gopher *gopher.Gopher
+	err    error
+}
+
+func newGopherLoader(fS fetching.Service) *gopherLoader {
+	return &gopherLoader{
+		fetching: fS,
+		results:  make(map[string]*loadResult),
+		pending:  make(map[string]*loadResult),
+	}
+}
+
+// withLoader returns a copy of ctx holding a loader for a single request
+func withLoader(ctx context.Context, loader *gopherLoader) context.Context {
+	return context.WithValue(ctx, contextKeyLoader, loader)
+}
+
+func loaderFromContext(ctx context.Context) *gopherLoader {
+	return ctx.Value(contextKeyLoader).(*gopherLoader)
+}
+
+// load queues the given ID, the thunk returned resolves it with the rest of the IDs queued
+func (l *gopherLoader) load(ctx context.Context, ID string) func() (interface{}, error) {
+	l.mtx.Lock()
+	result, ok := l.results[ID]
+	if !ok {
+		result = &loadResult{}
+		l.results[ID] = result
+		l.pending[ID] = result
+	}
+	l.mtx.Unlock()
+
+	return func() (interface{}, error) {
+		l.dispatch(ctx)
+		if result.err != nil || result.gopher == nil {
+			return nil, result.err
+		}
+		return result.gopher, nil
+	}
+}
+
+// clear forgets the gopher with the given ID, once it's changed by a mutation
+func (l *gopherLoader) clear(ID string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	delete(l.results, ID)
+}
+
+// dispatch fetches the IDs queued together, with a single lookup of all of them
+func (l *gopherLoader) dispatch(ctx context.Context) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	pending := l.pending
+	if len(pending) == 0 {
+		return
+	}
+	l.pending = make(map[string]*loadResult)
+
+	IDs := make([]string, 0, len(pending))
+	for ID := range pending {
+		IDs = append(IDs, ID)
+	}
+
+	gophers, err := l.fetching.FetchGophersByIDs(ctx, IDs)
+	byID := make(map[string]gopher.Gopher, len(gophers))
+	for _, g := range gophers {
+		byID[g.ID] = g
+	}
+	for ID, result := range pending {
+		if err != nil {
+			result.err = err
+			continue
+		}
+		if g, ok := byID[ID]; ok {
+			result.gopher = &g
+		}
+	}
+}
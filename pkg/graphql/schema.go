@@ -0,0 +1,372 @@
+package graphql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/removing"
+)
+
+// Declare the page sizes of the gopher list
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Declare the codes of the errors, given in their extensions
+const (
+	codeUnauthenticated = "UNAUTHENTICATED"
+	codeForbidden       = "FORBIDDEN"
+	codeNotFound        = "NOT_FOUND"
+	codeConflict        = "CONFLICT"
+	codeBadUserInput    = "BAD_USER_INPUT"
+	codeInternal        = "INTERNAL"
+)
+
+// codedError is an error telling the callers its code, in the extensions of the GraphQL errors
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e codedError) Error() string {
+	return e.message
+}
+
+// Extensions implements gqlerrors.ExtendedError
+func (e codedError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+// resolver maps the fields of the schema onto the services
+type resolver struct {
+	fetching  fetching.Service
+	adding    adding.Service
+	modifying modifying.Service
+	removing  removing.Service
+	logger    log.Logger
+}
+
+// newSchema builds the schema of the gophers, resolved by the given services
+func newSchema(r *resolver) (graphql.Schema, error) {
+	gopherType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Gopher",
+		Fields: graphql.Fields{
+			"id":        gopherField(graphql.NewNonNull(graphql.ID), func(g gopher.Gopher) interface{} { return g.ID }),
+			"name":      gopherField(graphql.NewNonNull(graphql.String), func(g gopher.Gopher) interface{} { return g.Name }),
+			"image":     gopherField(graphql.NewNonNull(graphql.String), func(g gopher.Gopher) interface{} { return g.Image }),
+			"age":       gopherField(graphql.NewNonNull(graphql.Int), func(g gopher.Gopher) interface{} { return g.Age }),
+			"createdAt": gopherField(graphql.DateTime, func(g gopher.Gopher) interface{} { return timeValue(g.CreatedAt) }),
+			"updatedAt": gopherField(graphql.DateTime, func(g gopher.Gopher) interface{} { return timeValue(g.UpdatedAt) }),
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "GopherEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(gopherType)},
+		},
+	})
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"endCursor":   &graphql.Field{Type: graphql.String},
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "GopherConnection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "GopherFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":   &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Part of the name, case insensitive"},
+			"minAge": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+			"maxAge": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		},
+	})
+	createInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "CreateGopherInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"id":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.ID)},
+			"name":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+			"image": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"age":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		},
+	})
+	updateInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "UpdateGopherInput",
+		Description: "The fields of the gopher to change, the ones not given are kept",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"image": &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"age":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"gopher": &graphql.Field{
+				Type:    gopherType,
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: r.gopher,
+			},
+			"gophers": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"filter": {Type: filterType},
+					"first":  {Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after":  {Type: graphql.String},
+				},
+				Resolve: r.gophers,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createGopher": &graphql.Field{
+				Type:    graphql.NewNonNull(gopherType),
+				Args:    graphql.FieldConfigArgument{"input": {Type: graphql.NewNonNull(createInputType)}},
+				Resolve: r.createGopher,
+			},
+			"updateGopher": &graphql.Field{
+				Type: graphql.NewNonNull(gopherType),
+				Args: graphql.FieldConfigArgument{
+					"id":    {Type: graphql.NewNonNull(graphql.ID)},
+					"input": {Type: graphql.NewNonNull(updateInputType)},
+				},
+				Resolve: r.updateGopher,
+			},
+			"deleteGopher": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.ID),
+				Args:    graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: r.deleteGopher,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// gopherField resolves a field of the gophers with the given function
+func gopherField(t graphql.Output, value func(g gopher.Gopher) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type: t,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			switch g := p.Source.(type) {
+			case *gopher.Gopher:
+				return value(*g), nil
+			case gopher.Gopher:
+				return value(g), nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+// gopher resolves a gopher by ID with the loader of the request, so the gophers asked
+// by several fields are fetched together
+func (r *resolver) gopher(p graphql.ResolveParams) (interface{}, error) {
+	ID, _ := p.Args["id"].(string)
+	thunk := loaderFromContext(p.Context).load(p.Context, ID)
+	return func() (interface{}, error) {
+		g, err := thunk()
+		if err != nil {
+			// graphql-go drops the extensions of the errors returned by the thunks,
+			// but keeps the ones of the errors they panic with
+			panic(r.error(p.Context, err))
+		}
+		return g, nil
+	}, nil
+}
+
+// gophers resolves a page of the gophers matching the filter, sorted by ID
+func (r *resolver) gophers(p graphql.ResolveParams) (interface{}, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 0 || first > maxPageSize {
+		return nil, codedError{codeBadUserInput, fmt.Sprintf("first must be between 0 and %d", maxPageSize)}
+	}
+	var after string
+	if cursor, ok := p.Args["after"].(string); ok {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return nil, codedError{codeBadUserInput, "invalid after cursor"}
+		}
+		after = string(decoded)
+	}
+
+	gophers, err := r.fetching.FetchGophers(p.Context)
+	if err != nil {
+		return nil, r.error(p.Context, err)
+	}
+	filter, _ := p.Args["filter"].(map[string]interface{})
+	gophers = filterGophers(gophers, filter)
+	sort.Slice(gophers, func(i, j int) bool { return gophers[i].ID < gophers[j].ID })
+
+	start := sort.Search(len(gophers), func(i int) bool { return gophers[i].ID > after })
+	end := start + first
+	if end > len(gophers) {
+		end = len(gophers)
+	}
+
+	edges := make([]map[string]interface{}, 0, end-start)
+	for _, g := range gophers[start:end] {
+		edges = append(edges, map[string]interface{}{"cursor": cursor(g.ID), "node": g})
+	}
+	pageInfo := map[string]interface{}{"hasNextPage": end < len(gophers)}
+	if end > start {
+		pageInfo["endCursor"] = cursor(gophers[end-1].ID)
+	}
+
+	return map[string]interface{}{
+		"edges":      edges,
+		"pageInfo":   pageInfo,
+		"totalCount": len(gophers),
+	}, nil
+}
+
+// filterGophers returns the gophers matching all the given conditions
+func filterGophers(gophers []gopher.Gopher, filter map[string]interface{}) []gopher.Gopher {
+	name, _ := filter["name"].(string)
+	minAge, hasMinAge := filter["minAge"].(int)
+	maxAge, hasMaxAge := filter["maxAge"].(int)
+
+	filtered := make([]gopher.Gopher, 0, len(gophers))
+	for _, g := range gophers {
+		if name != "" && !strings.Contains(strings.ToLower(g.Name), strings.ToLower(name)) {
+			continue
+		}
+		if (hasMinAge && g.Age < minAge) || (hasMaxAge && g.Age > maxAge) {
+			continue
+		}
+		filtered = append(filtered, g)
+	}
+	return filtered
+}
+
+// cursor hides the ID of a gopher in the list, so the callers don't rely on its format
+func cursor(ID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(ID))
+}
+
+// createGopher saves a new gopher, failing if there is already one with the same ID
+func (r *resolver) createGopher(p graphql.ResolveParams) (interface{}, error) {
+	input, _ := p.Args["input"].(map[string]interface{})
+	ID, _ := input["id"].(string)
+	name, _ := input["name"].(string)
+	image, _ := input["image"].(string)
+	age, _ := input["age"].(int)
+
+	err := r.adding.AddGopher(p.Context, ID, name, image, age)
+	if errors.Is(err, gopher.ErrAlreadyExists) {
+		return nil, codedError{codeConflict, fmt.Sprintf("gopher %s already exists", ID)}
+	}
+	if errors.Is(err, gopher.ErrReservedID) {
+		return nil, codedError{codeBadUserInput, err.Error()}
+	}
+	if err != nil {
+		return nil, r.error(p.Context, err)
+	}
+	return r.saved(p.Context, gopher.New(ID, name, image, age)), nil
+}
+
+// updateGopher changes the given fields of an existing gopher
+func (r *resolver) updateGopher(p graphql.ResolveParams) (interface{}, error) {
+	ID, _ := p.Args["id"].(string)
+	input, _ := p.Args["input"].(map[string]interface{})
+
+	g, err := r.fetching.FetchGopherByID(p.Context, ID)
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, codedError{codeNotFound, fmt.Sprintf("gopher %s not found", ID)}
+	}
+	if err != nil {
+		return nil, r.error(p.Context, err)
+	}
+	if name, ok := input["name"].(string); ok {
+		g.Name = name
+	}
+	if image, ok := input["image"].(string); ok {
+		g.Image = image
+	}
+	if age, ok := input["age"].(int); ok {
+		g.Age = age
+	}
+
+	err = r.modifying.ModifyGopher(p.Context, ID, g.Name, g.Image, g.Age)
+	if errors.Is(err, gopher.ErrNotFound) {
+		// the gopher was removed since it was fetched
+		return nil, codedError{codeNotFound, fmt.Sprintf("gopher %s not found", ID)}
+	}
+	if err != nil {
+		return nil, r.error(p.Context, err)
+	}
+	return r.saved(p.Context, g), nil
+}
+
+// deleteGopher removes a gopher, returning its ID
+func (r *resolver) deleteGopher(p graphql.ResolveParams) (interface{}, error) {
+	ID, _ := p.Args["id"].(string)
+	err := r.removing.RemoveGopher(p.Context, ID)
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, codedError{codeNotFound, fmt.Sprintf("gopher %s not found", ID)}
+	}
+	if err != nil {
+		return nil, r.error(p.Context, err)
+	}
+	loaderFromContext(p.Context).clear(ID)
+	return ID, nil
+}
+
+// saved returns the gopher as stored after a mutation, with its timestamps, or as given
+// when it can't be fetched back
+func (r *resolver) saved(ctx context.Context, g *gopher.Gopher) *gopher.Gopher {
+	loaderFromContext(ctx).clear(g.ID)
+	if stored, err := r.fetching.FetchGopherByID(ctx, g.ID); err == nil {
+		return stored
+	}
+	return g
+}
+
+// error translates the errors of the services to the errors given to the callers, the
+// unexpected ones are logged and hidden
+func (r *resolver) error(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return codedError{codeUnauthenticated, err.Error()}
+	case errors.Is(err, auth.ErrForbidden):
+		return codedError{codeForbidden, err.Error()}
+	default:
+		r.logger.UnexpectedError(ctx, err)
+		return codedError{codeInternal, "internal error"}
+	}
+}
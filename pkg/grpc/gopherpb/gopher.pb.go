@@ -0,0 +1,679 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: gopher.proto
+
+package gopherpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GopherEvent_Type int32
+
+const (
+	GopherEvent_TYPE_UNSPECIFIED GopherEvent_Type = 0
+	GopherEvent_CREATED          GopherEvent_Type = 1
+	GopherEvent_UPDATED          GopherEvent_Type = 2
+	GopherEvent_DELETED          GopherEvent_Type = 3
+)
+
+// Enum value maps for GopherEvent_Type.
+var (
+	GopherEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "DELETED",
+	}
+	GopherEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"DELETED":          3,
+	}
+)
+
+func (x GopherEvent_Type) Enum() *GopherEvent_Type {
+	p := new(GopherEvent_Type)
+	*p = x
+	return p
+}
+
+func (x GopherEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GopherEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_gopher_proto_enumTypes[0].Descriptor()
+}
+
+func (GopherEvent_Type) Type() protoreflect.EnumType {
+	return &file_gopher_proto_enumTypes[0]
+}
+
+func (x GopherEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GopherEvent_Type.Descriptor instead.
+func (GopherEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{8, 0}
+}
+
+type Gopher struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Image         string                 `protobuf:"bytes,3,opt,name=image,proto3" json:"image,omitempty"`
+	Age           int32                  `protobuf:"varint,4,opt,name=age,proto3" json:"age,omitempty"`
+	CreateTime    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	UpdateTime    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Gopher) Reset() {
+	*x = Gopher{}
+	mi := &file_gopher_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Gopher) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Gopher) ProtoMessage() {}
+
+func (x *Gopher) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Gopher.ProtoReflect.Descriptor instead.
+func (*Gopher) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Gopher) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Gopher) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Gopher) GetImage() string {
+	if x != nil {
+		return x.Image
+	}
+	return ""
+}
+
+func (x *Gopher) GetAge() int32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+func (x *Gopher) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *Gopher) GetUpdateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return nil
+}
+
+type GetGopherRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetGopherRequest) Reset() {
+	*x = GetGopherRequest{}
+	mi := &file_gopher_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetGopherRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetGopherRequest) ProtoMessage() {}
+
+func (x *GetGopherRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetGopherRequest.ProtoReflect.Descriptor instead.
+func (*GetGopherRequest) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetGopherRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListGophersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// page_size is the maximum number of gophers returned, 20 by default and 100 at most
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of the previous page, empty for the first one
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGophersRequest) Reset() {
+	*x = ListGophersRequest{}
+	mi := &file_gopher_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGophersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGophersRequest) ProtoMessage() {}
+
+func (x *ListGophersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGophersRequest.ProtoReflect.Descriptor instead.
+func (*ListGophersRequest) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListGophersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListGophersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListGophersResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Gophers []*Gopher              `protobuf:"bytes,1,rep,name=gophers,proto3" json:"gophers,omitempty"`
+	// next_page_token fetches the following page, empty on the last one
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	TotalSize     int32  `protobuf:"varint,3,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGophersResponse) Reset() {
+	*x = ListGophersResponse{}
+	mi := &file_gopher_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGophersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGophersResponse) ProtoMessage() {}
+
+func (x *ListGophersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGophersResponse.ProtoReflect.Descriptor instead.
+func (*ListGophersResponse) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListGophersResponse) GetGophers() []*Gopher {
+	if x != nil {
+		return x.Gophers
+	}
+	return nil
+}
+
+func (x *ListGophersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *ListGophersResponse) GetTotalSize() int32 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type CreateGopherRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Gopher        *Gopher                `protobuf:"bytes,1,opt,name=gopher,proto3" json:"gopher,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGopherRequest) Reset() {
+	*x = CreateGopherRequest{}
+	mi := &file_gopher_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGopherRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGopherRequest) ProtoMessage() {}
+
+func (x *CreateGopherRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGopherRequest.ProtoReflect.Descriptor instead.
+func (*CreateGopherRequest) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CreateGopherRequest) GetGopher() *Gopher {
+	if x != nil {
+		return x.Gopher
+	}
+	return nil
+}
+
+type UpdateGopherRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// gopher is the new data of the gopher with the same id, its timestamps are ignored
+	Gopher        *Gopher `protobuf:"bytes,1,opt,name=gopher,proto3" json:"gopher,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateGopherRequest) Reset() {
+	*x = UpdateGopherRequest{}
+	mi := &file_gopher_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGopherRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGopherRequest) ProtoMessage() {}
+
+func (x *UpdateGopherRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGopherRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGopherRequest) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *UpdateGopherRequest) GetGopher() *Gopher {
+	if x != nil {
+		return x.Gopher
+	}
+	return nil
+}
+
+type DeleteGopherRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteGopherRequest) Reset() {
+	*x = DeleteGopherRequest{}
+	mi := &file_gopher_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteGopherRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteGopherRequest) ProtoMessage() {}
+
+func (x *DeleteGopherRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteGopherRequest.ProtoReflect.Descriptor instead.
+func (*DeleteGopherRequest) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteGopherRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type WatchGophersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchGophersRequest) Reset() {
+	*x = WatchGophersRequest{}
+	mi := &file_gopher_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchGophersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchGophersRequest) ProtoMessage() {}
+
+func (x *WatchGophersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchGophersRequest.ProtoReflect.Descriptor instead.
+func (*WatchGophersRequest) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{7}
+}
+
+type GopherEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  GopherEvent_Type       `protobuf:"varint,1,opt,name=type,proto3,enum=gopherapi.v1.GopherEvent_Type" json:"type,omitempty"`
+	// gopher is the gopher changed, only its id for the deletions
+	Gopher        *Gopher                `protobuf:"bytes,2,opt,name=gopher,proto3" json:"gopher,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GopherEvent) Reset() {
+	*x = GopherEvent{}
+	mi := &file_gopher_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GopherEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GopherEvent) ProtoMessage() {}
+
+func (x *GopherEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_gopher_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GopherEvent.ProtoReflect.Descriptor instead.
+func (*GopherEvent) Descriptor() ([]byte, []int) {
+	return file_gopher_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GopherEvent) GetType() GopherEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return GopherEvent_TYPE_UNSPECIFIED
+}
+
+func (x *GopherEvent) GetGopher() *Gopher {
+	if x != nil {
+		return x.Gopher
+	}
+	return nil
+}
+
+func (x *GopherEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_gopher_proto protoreflect.FileDescriptor
+
+const file_gopher_proto_rawDesc = "" +
+	"\n" +
+	"\fgopher.proto\x12\fgopherapi.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xce\x01\n" +
+	"\x06Gopher\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05image\x18\x03 \x01(\tR\x05image\x12\x10\n" +
+	"\x03age\x18\x04 \x01(\x05R\x03age\x12;\n" +
+	"\vcreate_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12;\n" +
+	"\vupdate_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"updateTime\"\"\n" +
+	"\x10GetGopherRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"P\n" +
+	"\x12ListGophersRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"\x8c\x01\n" +
+	"\x13ListGophersResponse\x12.\n" +
+	"\agophers\x18\x01 \x03(\v2\x14.gopherapi.v1.GopherR\agophers\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x03 \x01(\x05R\ttotalSize\"C\n" +
+	"\x13CreateGopherRequest\x12,\n" +
+	"\x06gopher\x18\x01 \x01(\v2\x14.gopherapi.v1.GopherR\x06gopher\"C\n" +
+	"\x13UpdateGopherRequest\x12,\n" +
+	"\x06gopher\x18\x01 \x01(\v2\x14.gopherapi.v1.GopherR\x06gopher\"%\n" +
+	"\x13DeleteGopherRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x15\n" +
+	"\x13WatchGophersRequest\"\xe4\x01\n" +
+	"\vGopherEvent\x122\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1e.gopherapi.v1.GopherEvent.TypeR\x04type\x12,\n" +
+	"\x06gopher\x18\x02 \x01(\v2\x14.gopherapi.v1.GopherR\x06gopher\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"C\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\v\n" +
+	"\aDELETED\x10\x032\xd3\x03\n" +
+	"\rGopherService\x12A\n" +
+	"\tGetGopher\x12\x1e.gopherapi.v1.GetGopherRequest\x1a\x14.gopherapi.v1.Gopher\x12R\n" +
+	"\vListGophers\x12 .gopherapi.v1.ListGophersRequest\x1a!.gopherapi.v1.ListGophersResponse\x12G\n" +
+	"\fCreateGopher\x12!.gopherapi.v1.CreateGopherRequest\x1a\x14.gopherapi.v1.Gopher\x12G\n" +
+	"\fUpdateGopher\x12!.gopherapi.v1.UpdateGopherRequest\x1a\x14.gopherapi.v1.Gopher\x12I\n" +
+	"\fDeleteGopher\x12!.gopherapi.v1.DeleteGopherRequest\x1a\x16.google.protobuf.Empty\x12N\n" +
+	"\fWatchGophers\x12!.gopherapi.v1.WatchGophersRequest\x1a\x19.gopherapi.v1.GopherEvent0\x01B4Z2github.com/friendsofgo/gopherapi/pkg/grpc/gopherpbb\x06proto3"
+
+var (
+	file_gopher_proto_rawDescOnce sync.Once
+	file_gopher_proto_rawDescData []byte
+)
+
+func file_gopher_proto_rawDescGZIP() []byte {
+	file_gopher_proto_rawDescOnce.Do(func() {
+		file_gopher_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_gopher_proto_rawDesc), len(file_gopher_proto_rawDesc)))
+	})
+	return file_gopher_proto_rawDescData
+}
+
+var file_gopher_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_gopher_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_gopher_proto_goTypes = []any{
+	(GopherEvent_Type)(0),         // 0: gopherapi.v1.GopherEvent.Type
+	(*Gopher)(nil),                // 1: gopherapi.v1.Gopher
+	(*GetGopherRequest)(nil),      // 2: gopherapi.v1.GetGopherRequest
+	(*ListGophersRequest)(nil),    // 3: gopherapi.v1.ListGophersRequest
+	(*ListGophersResponse)(nil),   // 4: gopherapi.v1.ListGophersResponse
+	(*CreateGopherRequest)(nil),   // 5: gopherapi.v1.CreateGopherRequest
+	(*UpdateGopherRequest)(nil),   // 6: gopherapi.v1.UpdateGopherRequest
+	(*DeleteGopherRequest)(nil),   // 7: gopherapi.v1.DeleteGopherRequest
+	(*WatchGophersRequest)(nil),   // 8: gopherapi.v1.WatchGophersRequest
+	(*GopherEvent)(nil),           // 9: gopherapi.v1.GopherEvent
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),         // 11: google.protobuf.Empty
+}
+var file_gopher_proto_depIdxs = []int32{
+	10, // 0: gopherapi.v1.Gopher.create_time:type_name -> google.protobuf.Timestamp
+	10, // 1: gopherapi.v1.Gopher.update_time:type_name -> google.protobuf.Timestamp
+	1,  // 2: gopherapi.v1.ListGophersResponse.gophers:type_name -> gopherapi.v1.Gopher
+	1,  // 3: gopherapi.v1.CreateGopherRequest.gopher:type_name -> gopherapi.v1.Gopher
+	1,  // 4: gopherapi.v1.UpdateGopherRequest.gopher:type_name -> gopherapi.v1.Gopher
+	0,  // 5: gopherapi.v1.GopherEvent.type:type_name -> gopherapi.v1.GopherEvent.Type
+	1,  // 6: gopherapi.v1.GopherEvent.gopher:type_name -> gopherapi.v1.Gopher
+	10, // 7: gopherapi.v1.GopherEvent.time:type_name -> google.protobuf.Timestamp
+	2,  // 8: gopherapi.v1.GopherService.GetGopher:input_type -> gopherapi.v1.GetGopherRequest
+	3,  // 9: gopherapi.v1.GopherService.ListGophers:input_type -> gopherapi.v1.ListGophersRequest
+	5,  // 10: gopherapi.v1.GopherService.CreateGopher:input_type -> gopherapi.v1.CreateGopherRequest
+	6,  // 11: gopherapi.v1.GopherService.UpdateGopher:input_type -> gopherapi.v1.UpdateGopherRequest
+	7,  // 12: gopherapi.v1.GopherService.DeleteGopher:input_type -> gopherapi.v1.DeleteGopherRequest
+	8,  // 13: gopherapi.v1.GopherService.WatchGophers:input_type -> gopherapi.v1.WatchGophersRequest
+	1,  // 14: gopherapi.v1.GopherService.GetGopher:output_type -> gopherapi.v1.Gopher
+	4,  // 15: gopherapi.v1.GopherService.ListGophers:output_type -> gopherapi.v1.ListGophersResponse
+	1,  // 16: gopherapi.v1.GopherService.CreateGopher:output_type -> gopherapi.v1.Gopher
+	1,  // 17: gopherapi.v1.GopherService.UpdateGopher:output_type -> gopherapi.v1.Gopher
+	11, // 18: gopherapi.v1.GopherService.DeleteGopher:output_type -> google.protobuf.Empty
+	9,  // 19: gopherapi.v1.GopherService.WatchGophers:output_type -> gopherapi.v1.GopherEvent
+	14, // [14:20] is the sub-list for method output_type
+	8,  // [8:14] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_gopher_proto_init() }
+func file_gopher_proto_init() {
+	if File_gopher_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_gopher_proto_rawDesc), len(file_gopher_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_gopher_proto_goTypes,
+		DependencyIndexes: file_gopher_proto_depIdxs,
+		EnumInfos:         file_gopher_proto_enumTypes,
+		MessageInfos:      file_gopher_proto_msgTypes,
+	}.Build()
+	File_gopher_proto = out.File
+	file_gopher_proto_goTypes = nil
+	file_gopher_proto_depIdxs = nil
+}
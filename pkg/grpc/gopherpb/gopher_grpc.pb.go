@@ -0,0 +1,332 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: gopher.proto
+
+package gopherpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	GopherService_GetGopher_FullMethodName    = "/gopherapi.v1.GopherService/GetGopher"
+	GopherService_ListGophers_FullMethodName  = "/gopherapi.v1.GopherService/ListGophers"
+	GopherService_CreateGopher_FullMethodName = "/gopherapi.v1.GopherService/CreateGopher"
+	GopherService_UpdateGopher_FullMethodName = "/gopherapi.v1.GopherService/UpdateGopher"
+	GopherService_DeleteGopher_FullMethodName = "/gopherapi.v1.GopherService/DeleteGopher"
+	GopherService_WatchGophers_FullMethodName = "/gopherapi.v1.GopherService/WatchGophers"
+)
+
+// GopherServiceClient is the client API for GopherService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// GopherService manages the gophers, like the /v2/gophers routes of the HTTP api
+type GopherServiceClient interface {
+	// GetGopher returns the gopher with the given ID
+	GetGopher(ctx context.Context, in *GetGopherRequest, opts ...grpc.CallOption) (*Gopher, error)
+	// ListGophers returns a page of the gophers, sorted by ID
+	ListGophers(ctx context.Context, in *ListGophersRequest, opts ...grpc.CallOption) (*ListGophersResponse, error)
+	// CreateGopher saves a new gopher
+	CreateGopher(ctx context.Context, in *CreateGopherRequest, opts ...grpc.CallOption) (*Gopher, error)
+	// UpdateGopher modifies the data of an existing gopher
+	UpdateGopher(ctx context.Context, in *UpdateGopherRequest, opts ...grpc.CallOption) (*Gopher, error)
+	// DeleteGopher removes the gopher with the given ID
+	DeleteGopher(ctx context.Context, in *DeleteGopherRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// WatchGophers streams the changes of the gophers of the caller's tenant until the call is cancelled
+	WatchGophers(ctx context.Context, in *WatchGophersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GopherEvent], error)
+}
+
+type gopherServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGopherServiceClient(cc grpc.ClientConnInterface) GopherServiceClient {
+	return &gopherServiceClient{cc}
+}
+
+func (c *gopherServiceClient) GetGopher(ctx context.Context, in *GetGopherRequest, opts ...grpc.CallOption) (*Gopher, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Gopher)
+	err := c.cc.Invoke(ctx, GopherService_GetGopher_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gopherServiceClient) ListGophers(ctx context.Context, in *ListGophersRequest, opts ...grpc.CallOption) (*ListGophersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListGophersResponse)
+	err := c.cc.Invoke(ctx, GopherService_ListGophers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gopherServiceClient) CreateGopher(ctx context.Context, in *CreateGopherRequest, opts ...grpc.CallOption) (*Gopher, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Gopher)
+	err := c.cc.Invoke(ctx, GopherService_CreateGopher_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gopherServiceClient) UpdateGopher(ctx context.Context, in *UpdateGopherRequest, opts ...grpc.CallOption) (*Gopher, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Gopher)
+	err := c.cc.Invoke(ctx, GopherService_UpdateGopher_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gopherServiceClient) DeleteGopher(ctx context.Context, in *DeleteGopherRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, GopherService_DeleteGopher_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *gopherServiceClient) WatchGophers(ctx context.Context, in *WatchGophersRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GopherEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &GopherService_ServiceDesc.Streams[0], GopherService_WatchGophers_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchGophersRequest, GopherEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GopherService_WatchGophersClient = grpc.ServerStreamingClient[GopherEvent]
+
+// GopherServiceServer is the server API for GopherService service.
+// All implementations must embed UnimplementedGopherServiceServer
+// for forward compatibility.
+//
+// GopherService manages the gophers, like the /v2/gophers routes of the HTTP api
+type GopherServiceServer interface {
+	// GetGopher returns the gopher with the given ID
+	GetGopher(context.Context, *GetGopherRequest) (*Gopher, error)
+	// ListGophers returns a page of the gophers, sorted by ID
+	ListGophers(context.Context, *ListGophersRequest) (*ListGophersResponse, error)
+	// CreateGopher saves a new gopher
+	CreateGopher(context.Context, *CreateGopherRequest) (*Gopher, error)
+	// UpdateGopher modifies the data of an existing gopher
+	UpdateGopher(context.Context, *UpdateGopherRequest) (*Gopher, error)
+	// DeleteGopher removes the gopher with the given ID
+	DeleteGopher(context.Context, *DeleteGopherRequest) (*emptypb.Empty, error)
+	// WatchGophers streams the changes of the gophers of the caller's tenant until the call is cancelled
+	WatchGophers(*WatchGophersRequest, grpc.ServerStreamingServer[GopherEvent]) error
+	mustEmbedUnimplementedGopherServiceServer()
+}
+
+// UnimplementedGopherServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedGopherServiceServer struct{}
+
+func (UnimplementedGopherServiceServer) GetGopher(context.Context, *GetGopherRequest) (*Gopher, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGopher not implemented")
+}
+func (UnimplementedGopherServiceServer) ListGophers(context.Context, *ListGophersRequest) (*ListGophersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGophers not implemented")
+}
+func (UnimplementedGopherServiceServer) CreateGopher(context.Context, *CreateGopherRequest) (*Gopher, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGopher not implemented")
+}
+func (UnimplementedGopherServiceServer) UpdateGopher(context.Context, *UpdateGopherRequest) (*Gopher, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateGopher not implemented")
+}
+func (UnimplementedGopherServiceServer) DeleteGopher(context.Context, *DeleteGopherRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteGopher not implemented")
+}
+func (UnimplementedGopherServiceServer) WatchGophers(*WatchGophersRequest, grpc.ServerStreamingServer[GopherEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchGophers not implemented")
+}
+func (UnimplementedGopherServiceServer) mustEmbedUnimplementedGopherServiceServer() {}
+func (UnimplementedGopherServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeGopherServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GopherServiceServer will
+// result in compilation errors.
+type UnsafeGopherServiceServer interface {
+	mustEmbedUnimplementedGopherServiceServer()
+}
+
+func RegisterGopherServiceServer(s grpc.ServiceRegistrar, srv GopherServiceServer) {
+	// If the following call pancis, it indicates UnimplementedGopherServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&GopherService_ServiceDesc, srv)
+}
+
+func _GopherService_GetGopher_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGopherRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GopherServiceServer).GetGopher(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GopherService_GetGopher_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GopherServiceServer).GetGopher(ctx, req.(*GetGopherRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GopherService_ListGophers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGophersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GopherServiceServer).ListGophers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GopherService_ListGophers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GopherServiceServer).ListGophers(ctx, req.(*ListGophersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GopherService_CreateGopher_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGopherRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GopherServiceServer).CreateGopher(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GopherService_CreateGopher_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GopherServiceServer).CreateGopher(ctx, req.(*CreateGopherRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GopherService_UpdateGopher_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateGopherRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GopherServiceServer).UpdateGopher(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GopherService_UpdateGopher_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GopherServiceServer).UpdateGopher(ctx, req.(*UpdateGopherRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GopherService_DeleteGopher_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteGopherRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GopherServiceServer).DeleteGopher(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: GopherService_DeleteGopher_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GopherServiceServer).DeleteGopher(ctx, req.(*DeleteGopherRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GopherService_WatchGophers_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchGophersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GopherServiceServer).WatchGophers(m, &grpc.GenericServerStream[WatchGophersRequest, GopherEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type GopherService_WatchGophersServer = grpc.ServerStreamingServer[GopherEvent]
+
+// GopherService_ServiceDesc is the grpc.ServiceDesc for GopherService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var GopherService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gopherapi.v1.GopherService",
+	HandlerType: (*GopherServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetGopher",
+			Handler:    _GopherService_GetGopher_Handler,
+		},
+		{
+			MethodName: "ListGophers",
+			Handler:    _GopherService_ListGophers_Handler,
+		},
+		{
+			MethodName: "CreateGopher",
+			Handler:    _GopherService_CreateGopher_Handler,
+		},
+		{
+			MethodName: "UpdateGopher",
+			Handler:    _GopherService_UpdateGopher_Handler,
+		},
+		{
+			MethodName: "DeleteGopher",
+			Handler:    _GopherService_DeleteGopher_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchGophers",
+			Handler:       _GopherService_WatchGophers_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "gopher.proto",
+}
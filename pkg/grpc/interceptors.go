@@ -0,0 +1,325 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+// accessMethod is the method of the calls in the access log, next to the HTTP methods
+const accessMethod = "GRPC"
+
+// httpStatus maps the status codes of the calls to the HTTP ones, so the access
+// logs of both protocols can be read the same way
+var httpStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+}
+
+// serverStream is a server stream carrying the context enriched by the interceptors
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func newTracingUnaryInterceptor(trc tracer.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		span, ctx := startSpan(ctx, trc, info.FullMethod)
+		defer span.Finish()
+
+		res, err := handler(ctx, req)
+		finishSpan(span, err)
+		return res, err
+	}
+}
+
+func newTracingStreamInterceptor(trc tracer.Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		span, ctx := startSpan(ss.Context(), trc, info.FullMethod)
+		defer span.Finish()
+
+		err := handler(srv, &serverStream{ss, ctx})
+		finishSpan(span, err)
+		return err
+	}
+}
+
+func startSpan(ctx context.Context, trc tracer.Tracer, method string) (tracer.Span, context.Context) {
+	span, ctx := trc.StartSpanFromContext(ctx, method)
+	span.Tag("rpc.system", "grpc")
+	span.Tag("rpc.method", method)
+	return span, ctx
+}
+
+func finishSpan(span tracer.Span, err error) {
+	span.Tag("rpc.grpc.status_code", status.Code(err).String())
+	if err != nil {
+		span.SetError(err)
+	}
+}
+
+func newRecoveryUnaryInterceptor(logger log.Logger, m *metrics.GRPC) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (res interface{}, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoveredStatus(ctx, logger, m, info.FullMethod, recovered)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func newRecoveryStreamInterceptor(logger log.Logger, m *metrics.GRPC) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err = recoveredStatus(ss.Context(), logger, m, info.FullMethod, recovered)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// recoveredStatus reports a panic recovered while serving a call to the logs and the metrics
+// if any, answering the caller with an internal error instead of crashing the server
+func recoveredStatus(ctx context.Context, logger log.Logger, m *metrics.GRPC, method string, recovered interface{}) error {
+	logger.PanicRecovered(ctx, recovered, debug.Stack())
+	if m != nil {
+		m.Panicked(method)
+	}
+	return status.Error(codes.Internal, "internal error")
+}
+
+func newLoggingUnaryInterceptor(logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		logger.RequestStarted(ctx, accessMethod, info.FullMethod)
+
+		res, err := handler(ctx, req)
+
+		size := 0
+		if m, ok := res.(proto.Message); ok && err == nil {
+			size = proto.Size(m)
+		}
+		logFinished(ctx, logger, info.FullMethod, err, size, start)
+		return res, err
+	}
+}
+
+func newLoggingStreamInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		logger.RequestStarted(ss.Context(), accessMethod, info.FullMethod)
+
+		err := handler(srv, ss)
+		logFinished(ss.Context(), logger, info.FullMethod, err, 0, start)
+		return err
+	}
+}
+
+func logFinished(ctx context.Context, logger log.Logger, method string, err error, size int, start time.Time) {
+	code := status.Code(err)
+	httpCode, ok := httpStatus[code]
+	if !ok {
+		httpCode = http.StatusInternalServerError
+	}
+
+	logger.RequestFinished(ctx, log.Access{
+		Method:   accessMethod,
+		Path:     method,
+		Status:   httpCode,
+		Bytes:    size,
+		Duration: time.Since(start),
+	})
+}
+
+func newRateLimitUnaryInterceptor(store ratelimit.Store, limit ratelimit.Limit, logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := takeToken(ctx, store, limit, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func newRateLimitStreamInterceptor(store ratelimit.Store, limit ratelimit.Limit, logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := takeToken(ss.Context(), store, limit, logger); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// takeToken takes a token from the bucket of the IP of the caller, the same bucket its
+// HTTP requests take from, failing with ResourceExhausted when there's none left
+func takeToken(ctx context.Context, store ratelimit.Store, limit ratelimit.Limit, logger log.Logger) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	clientIP, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		clientIP = p.Addr.String()
+	}
+
+	result, err := store.Take(ctx, ratelimit.IPKey(clientIP), limit)
+	if err != nil {
+		// the rate limiter must not take the api down with it
+		logger.UnexpectedError(ctx, err)
+		return nil
+	}
+	if !result.Allowed {
+		retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+		return status.Error(codes.ResourceExhausted, "too many requests")
+	}
+	return nil
+}
+
+func newAuthUnaryInterceptor(authenticators []auth.Authenticator, resolver tenant.Resolver, logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, info.FullMethod, authenticators, resolver, logger)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func newAuthStreamInterceptor(authenticators []auth.Authenticator, resolver tenant.Resolver, logger log.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), info.FullMethod, authenticators, resolver, logger)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ss, ctx})
+	}
+}
+
+// authenticate identifies the caller with the authenticators of the HTTP api, when there is any,
+// and scopes the call to its tenant, the authenticated callers are bound to their tenant
+func authenticate(ctx context.Context, method string, authenticators []auth.Authenticator, resolver tenant.Resolver, logger log.Logger) (context.Context, error) {
+	r := newRequest(ctx, method)
+
+	if len(authenticators) > 0 {
+		p, err := authenticatePrincipal(r, authenticators)
+		if err != nil {
+			_ = grpc.SetHeader(ctx, challenges(authenticators, err))
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		ctx = auth.WithPrincipal(ctx, p)
+	}
+
+	ID, _ := resolver.Resolve(r)
+	if p, ok := auth.PrincipalFromContext(ctx); ok {
+		if ID != "" && ID != p.TenantID() {
+			return nil, status.Error(codes.PermissionDenied, "tenant not allowed")
+		}
+		ID = p.TenantID()
+	}
+	if ID == "" {
+		ID = tenant.Default
+	}
+	if !tenant.Valid(ID) {
+		err := fmt.Errorf("invalid tenant %q", ID)
+		logger.InvalidRequest(ctx, err)
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return tenant.WithTenant(ctx, ID), nil
+}
+
+func authenticatePrincipal(r *http.Request, authenticators []auth.Authenticator) (auth.Principal, error) {
+	err := auth.ErrNoCredentials
+	for _, a := range authenticators {
+		p, authErr := a.Authenticate(r)
+		if authErr == nil {
+			return p, nil
+		}
+		if errors.Is(authErr, auth.ErrInvalidCredentials) {
+			err = authErr
+		}
+	}
+	return auth.Principal{}, err
+}
+
+// challenges tells the callers how to authenticate, like the WWW-Authenticate headers of the HTTP api
+func challenges(authenticators []auth.Authenticator, err error) metadata.MD {
+	md := metadata.MD{}
+	for _, a := range authenticators {
+		challenge := a.Challenge()
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			challenge = fmt.Sprintf(`%s, error="invalid_token"`, challenge)
+		}
+		md.Append("www-authenticate", challenge)
+	}
+	return md
+}
+
+// newRequest builds the HTTP request the authenticators and the tenant resolver understand
+// from the metadata and the peer of the call
+func newRequest(ctx context.Context, method string) *http.Request {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	r := &http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Path: method},
+		Header: header,
+	}
+	if authority := md.Get(":authority"); len(authority) > 0 {
+		r.Host = authority[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		r.RemoteAddr = p.Addr.String()
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state := info.State
+			r.TLS = &state
+		}
+	}
+	return r.WithContext(ctx)
+}
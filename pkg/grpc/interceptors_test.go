@@ -0,0 +1,56 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
+)
+
+func Test_RecoveryInterceptor(t *testing.T) {
+	// GIVEN a method which panics
+	logger := &panicLogger{Logger: log.NewNoopLogger()}
+	registry := prometheus.NewRegistry()
+	m := metrics.NewGRPC(registry)
+	info := &grpc.UnaryServerInfo{FullMethod: "/gopherapi.v1.GopherService/GetGopher"}
+
+	// WHEN it's called
+	_, err := newRecoveryUnaryInterceptor(logger, m)(context.Background(), nil, info, func(context.Context, interface{}) (interface{}, error) {
+		panic("boom")
+	})
+
+	// THEN the caller gets an internal error, and the panic is logged and counted
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []interface{}{"boom"}, logger.recovered)
+	assert.Equal(t, 1, testutil.CollectAndCount(registry, "gopherapi_grpc_panics_total"))
+}
+
+func Test_RecoveryInterceptor_Stream(t *testing.T) {
+	logger := &panicLogger{Logger: log.NewNoopLogger()}
+	info := &grpc.StreamServerInfo{FullMethod: "/gopherapi.v1.GopherService/WatchGophers"}
+
+	err := newRecoveryStreamInterceptor(logger, nil)(nil, &serverStream{ctx: context.Background()}, info, func(interface{}, grpc.ServerStream) error {
+		panic("boom")
+	})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, []interface{}{"boom"}, logger.recovered)
+}
+
+// panicLogger records the panics recovered, the rest of messages are discarded
+type panicLogger struct {
+	log.Logger
+	recovered []interface{}
+}
+
+func (l *panicLogger) PanicRecovered(_ context.Context, recovered interface{}, _ []byte) {
+	l.recovered = append(l.recovered, recovered)
+}
@@ -0,0 +1,363 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/grpc/gopherpb"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
+	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+	"github.com/friendsofgo/gopherapi/pkg/removing"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+	"github.com/friendsofgo/gopherapi/pkg/watching"
+)
+
+// Declare the page sizes of the gopher list
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type server struct {
+	gopherpb.UnimplementedGopherServiceServer
+
+	tracer         tracer.Tracer
+	fetching       fetching.Service
+	adding         adding.Service
+	modifying      modifying.Service
+	removing       removing.Service
+	watching       watching.Service
+	authenticators []auth.Authenticator
+	tenantResolver tenant.Resolver
+	logger         log.Logger
+	tlsConfig      *tls.Config
+	rateLimiter    ratelimit.Store
+	rateLimit      ratelimit.Limit
+	metrics        *metrics.GRPC
+}
+
+// Option configures the optional behaviour of the gRPC server
+type Option func(*server)
+
+// WithAuthenticators requires the callers to be authenticated by any of the given authenticators,
+// reading their credentials from the metadata as the HTTP api reads them from the headers
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(s *server) {
+		s.authenticators = authenticators
+	}
+}
+
+// WithTenantResolver resolves the tenant of the calls from the metadata
+func WithTenantResolver(resolver tenant.Resolver) Option {
+	return func(s *server) {
+		s.tenantResolver = resolver
+	}
+}
+
+// WithLogger logs the calls and the unexpected errors with the given logger
+func WithLogger(logger log.Logger) Option {
+	return func(s *server) {
+		s.logger = logger
+	}
+}
+
+// WithRateLimit limits the calls of each client IP with the given store, before they are
+// authenticated, sharing the buckets of the HTTP api when the store is the same
+func WithRateLimit(store ratelimit.Store, limit ratelimit.Limit) Option {
+	return func(s *server) {
+		s.rateLimiter = store
+		s.rateLimit = limit
+	}
+}
+
+// WithMetrics records the gRPC metrics in the given registry
+func WithMetrics(registry prometheus.Registerer) Option {
+	return func(s *server) {
+		s.metrics = metrics.NewGRPC(registry)
+	}
+}
+
+// WithTLSConfig serves the calls over TLS, required to authenticate the client certificates
+func WithTLSConfig(config *tls.Config) Option {
+	return func(s *server) {
+		s.tlsConfig = config
+	}
+}
+
+// NewServer creates a gRPC server serving the GopherService with the same services as the HTTP api
+func NewServer(
+	trc tracer.Tracer,
+	fS fetching.Service,
+	aS adding.Service,
+	mS modifying.Service,
+	rS removing.Service,
+	wS watching.Service,
+	opts ...Option,
+) *grpc.Server {
+	s := &server{
+		tracer:    trc,
+		fetching:  fS,
+		adding:    aS,
+		modifying: mS,
+		removing:  rS,
+		watching:  wS,
+		logger:    log.NewNoopLogger(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	// the calls are logged once authenticated, so their logs carry the caller and its tenant
+	unary := []grpc.UnaryServerInterceptor{
+		newTracingUnaryInterceptor(s.tracer),
+		newRecoveryUnaryInterceptor(s.logger, s.metrics),
+	}
+	stream := []grpc.StreamServerInterceptor{
+		newTracingStreamInterceptor(s.tracer),
+		newRecoveryStreamInterceptor(s.logger, s.metrics),
+	}
+	if s.rateLimiter != nil {
+		unary = append(unary, newRateLimitUnaryInterceptor(s.rateLimiter, s.rateLimit, s.logger))
+		stream = append(stream, newRateLimitStreamInterceptor(s.rateLimiter, s.rateLimit, s.logger))
+	}
+	unary = append(unary,
+		newAuthUnaryInterceptor(s.authenticators, s.tenantResolver, s.logger),
+		newLoggingUnaryInterceptor(s.logger),
+	)
+	stream = append(stream,
+		newAuthStreamInterceptor(s.authenticators, s.tenantResolver, s.logger),
+		newLoggingStreamInterceptor(s.logger),
+	)
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if s.tlsConfig != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+
+	g := grpc.NewServer(serverOpts...)
+	gopherpb.RegisterGopherServiceServer(g, s)
+	return g
+}
+
+// GetGopher returns the gopher with the given ID
+func (s *server) GetGopher(ctx context.Context, req *gopherpb.GetGopherRequest) (*gopherpb.Gopher, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	g, err := s.fetching.FetchGopherByID(ctx, req.GetId())
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "gopher %s not found", req.GetId())
+	}
+	if err != nil {
+		return nil, s.status(ctx, err)
+	}
+	return newGopher(*g), nil
+}
+
+// ListGophers returns a page of the gophers, sorted by ID, starting after the one in the page token
+func (s *server) ListGophers(ctx context.Context, req *gopherpb.ListGophersRequest) (*gopherpb.ListGophersResponse, error) {
+	size := int(req.GetPageSize())
+	switch {
+	case size < 0:
+		return nil, status.Error(codes.InvalidArgument, "page_size can't be negative")
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+
+	after, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+	}
+
+	gophers, err := s.fetching.FetchGophers(ctx)
+	if err != nil {
+		return nil, s.status(ctx, err)
+	}
+	sort.Slice(gophers, func(i, j int) bool { return gophers[i].ID < gophers[j].ID })
+
+	start := sort.Search(len(gophers), func(i int) bool { return gophers[i].ID > after })
+	end := start + size
+	if end > len(gophers) {
+		end = len(gophers)
+	}
+
+	res := &gopherpb.ListGophersResponse{
+		Gophers:   make([]*gopherpb.Gopher, 0, end-start),
+		TotalSize: int32(len(gophers)),
+	}
+	for _, g := range gophers[start:end] {
+		res.Gophers = append(res.Gophers, newGopher(g))
+	}
+	if end < len(gophers) {
+		res.NextPageToken = encodePageToken(gophers[end-1].ID)
+	}
+	return res, nil
+}
+
+// CreateGopher saves a new gopher, failing if there is already one with the same ID
+func (s *server) CreateGopher(ctx context.Context, req *gopherpb.CreateGopherRequest) (*gopherpb.Gopher, error) {
+	g := req.GetGopher()
+	if g.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "gopher.id is required")
+	}
+
+	err := s.adding.AddGopher(ctx, g.GetId(), g.GetName(), g.GetImage(), int(g.GetAge()))
+	if errors.Is(err, gopher.ErrAlreadyExists) {
+		return nil, status.Errorf(codes.AlreadyExists, "gopher %s already exists", g.GetId())
+	}
+	if errors.Is(err, gopher.ErrReservedID) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		return nil, s.status(ctx, err)
+	}
+	return s.saved(ctx, g), nil
+}
+
+// UpdateGopher modifies the data of an existing gopher
+func (s *server) UpdateGopher(ctx context.Context, req *gopherpb.UpdateGopherRequest) (*gopherpb.Gopher, error) {
+	g := req.GetGopher()
+	if g.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "gopher.id is required")
+	}
+
+	err := s.modifying.ModifyGopher(ctx, g.GetId(), g.GetName(), g.GetImage(), int(g.GetAge()))
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "gopher %s not found", g.GetId())
+	}
+	if err != nil {
+		return nil, s.status(ctx, err)
+	}
+	return s.saved(ctx, g), nil
+}
+
+// DeleteGopher removes the gopher with the given ID
+func (s *server) DeleteGopher(ctx context.Context, req *gopherpb.DeleteGopherRequest) (*emptypb.Empty, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	err := s.removing.RemoveGopher(ctx, req.GetId())
+	if errors.Is(err, gopher.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "gopher %s not found", req.GetId())
+	}
+	if err != nil {
+		return nil, s.status(ctx, err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// WatchGophers streams the changes of the gophers of the caller's tenant until the call is cancelled
+func (s *server) WatchGophers(_ *gopherpb.WatchGophersRequest, stream gopherpb.GopherService_WatchGophersServer) error {
+	ctx := stream.Context()
+	events, errs := s.watching.WatchGophers(ctx)
+	// the headers tell the caller the watch started, so it doesn't miss the following changes
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for event := range events {
+		if err := stream.Send(newGopherEvent(event)); err != nil {
+			return err
+		}
+	}
+
+	err := <-errs
+	if ctx.Err() != nil {
+		// the caller went away, there is nobody to tell why the watch ended
+		return nil
+	}
+	return s.status(ctx, err)
+}
+
+// saved returns the gopher as stored, with its timestamps, or as given when it can't be fetched back
+func (s *server) saved(ctx context.Context, g *gopherpb.Gopher) *gopherpb.Gopher {
+	if stored, err := s.fetching.FetchGopherByID(ctx, g.GetId()); err == nil {
+		return newGopher(*stored)
+	}
+	return &gopherpb.Gopher{Id: g.GetId(), Name: g.GetName(), Image: g.GetImage(), Age: g.GetAge()}
+}
+
+// status translates the errors of the services to the status of the call, the unexpected
+// errors are logged and hidden from the caller
+func (s *server) status(ctx context.Context, err error) error {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, auth.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, watching.ErrWatcherTooSlow):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		s.logger.UnexpectedError(ctx, err)
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+func newGopher(g gopher.Gopher) *gopherpb.Gopher {
+	pb := &gopherpb.Gopher{
+		Id:    g.ID,
+		Name:  g.Name,
+		Image: g.Image,
+		Age:   int32(g.Age),
+	}
+	if g.CreatedAt != nil {
+		pb.CreateTime = timestamppb.New(*g.CreatedAt)
+	}
+	if g.UpdatedAt != nil {
+		pb.UpdateTime = timestamppb.New(*g.UpdatedAt)
+	}
+	return pb
+}
+
+func newGopherEvent(event watching.Event) *gopherpb.GopherEvent {
+	types := map[watching.EventType]gopherpb.GopherEvent_Type{
+		watching.EventCreated: gopherpb.GopherEvent_CREATED,
+		watching.EventUpdated: gopherpb.GopherEvent_UPDATED,
+		watching.EventDeleted: gopherpb.GopherEvent_DELETED,
+	}
+	return &gopherpb.GopherEvent{
+		Type:   types[event.Type],
+		Gopher: newGopher(event.Gopher),
+		Time:   timestamppb.New(event.At),
+	}
+}
+
+// encodePageToken hides the ID of the last gopher of a page, so the callers don't rely on its format
+func encodePageToken(lastID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastID))
+}
+
+func decodePageToken(token string) (string, error) {
+	lastID, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", fmt.Errorf("decoding page token: %w", err)
+	}
+	return string(lastID), nil
+}
@@ -0,0 +1,223 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/grpc/gopherpb"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+	"github.com/friendsofgo/gopherapi/pkg/removing"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+	"github.com/friendsofgo/gopherapi/pkg/watching"
+)
+
+func Test_Server_GopherCRUD(t *testing.T) {
+	client := buildClient(t, nil)
+	ctx := context.Background()
+
+	created, err := client.CreateGopher(ctx, &gopherpb.CreateGopherRequest{Gopher: &gopherpb.Gopher{Id: "01DCBP0R0MSNZY975ZQF1DCQCH", Name: "Eustaqio", Age: 99}})
+	require.NoError(t, err)
+	assert.Equal(t, "Eustaqio", created.GetName())
+	assert.NotNil(t, created.GetCreateTime())
+
+	_, err = client.CreateGopher(ctx, &gopherpb.CreateGopherRequest{Gopher: created})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	updated, err := client.UpdateGopher(ctx, &gopherpb.UpdateGopherRequest{Gopher: &gopherpb.Gopher{Id: created.GetId(), Name: "Eustaquio", Age: 100}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(100), updated.GetAge())
+	assert.Equal(t, created.GetCreateTime().AsTime(), updated.GetCreateTime().AsTime())
+
+	got, err := client.GetGopher(ctx, &gopherpb.GetGopherRequest{Id: created.GetId()})
+	require.NoError(t, err)
+	assert.Equal(t, "Eustaquio", got.GetName())
+
+	_, err = client.DeleteGopher(ctx, &gopherpb.DeleteGopherRequest{Id: created.GetId()})
+	require.NoError(t, err)
+
+	_, err = client.GetGopher(ctx, &gopherpb.GetGopherRequest{Id: created.GetId()})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.UpdateGopher(ctx, &gopherpb.UpdateGopherRequest{Gopher: created})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_Server_ListGophers(t *testing.T) {
+	client := buildClient(t, nil)
+	ctx := context.Background()
+
+	var (
+		IDs   []string
+		token string
+	)
+	for {
+		// WHEN the gophers are listed two by two
+		res, err := client.ListGophers(ctx, &gopherpb.ListGophersRequest{PageSize: 2, PageToken: token})
+		require.NoError(t, err)
+		assert.Equal(t, int32(len(sample.Gophers)), res.GetTotalSize())
+		assert.LessOrEqual(t, len(res.GetGophers()), 2)
+
+		for _, g := range res.GetGophers() {
+			IDs = append(IDs, g.GetId())
+		}
+		if token = res.GetNextPageToken(); token == "" {
+			break
+		}
+	}
+
+	// THEN every gopher is returned once, sorted by ID
+	assert.Len(t, IDs, len(sample.Gophers))
+	assert.IsIncreasing(t, IDs)
+
+	_, err := client.ListGophers(ctx, &gopherpb.ListGophersRequest{PageToken: "not a token!"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_Server_WatchGophers(t *testing.T) {
+	client := buildClient(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.WatchGophers(ctx, &gopherpb.WatchGophersRequest{})
+	require.NoError(t, err)
+	// the headers are sent once the watcher is subscribed
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	// WHEN a gopher is created and deleted
+	g := &gopherpb.Gopher{Id: "01DCBP0R0MSNZY975ZQF1DCQCH", Name: "Eustaqio"}
+	_, err = client.CreateGopher(ctx, &gopherpb.CreateGopherRequest{Gopher: g})
+	require.NoError(t, err)
+	_, err = client.DeleteGopher(ctx, &gopherpb.DeleteGopherRequest{Id: g.GetId()})
+	require.NoError(t, err)
+
+	// THEN the watcher receives both changes in order
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, gopherpb.GopherEvent_CREATED, event.GetType())
+	assert.Equal(t, "Eustaqio", event.GetGopher().GetName())
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, gopherpb.GopherEvent_DELETED, event.GetType())
+	assert.Equal(t, g.GetId(), event.GetGopher().GetId())
+}
+
+func Test_Server_Authentication(t *testing.T) {
+	authenticator, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "ci", Hash: auth.HashAPIKey("s3cr3t"), Scopes: []string{"gophers:read"}},
+		{Name: "nobody", Hash: auth.HashAPIKey("n0b0dy")},
+	})
+	require.NoError(t, err)
+	client := buildClient(t, []auth.Authenticator{authenticator})
+
+	testData := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{name: "without credentials", md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "with an invalid key", md: metadata.Pairs("x-api-key", "wrong"), code: codes.Unauthenticated},
+		{name: "reading with a read key", md: metadata.Pairs("x-api-key", "s3cr3t"), code: codes.OK},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			_, err := client.ListGophers(ctx, &gopherpb.ListGophersRequest{})
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+
+	// the caller is authenticated but isn't allowed to read
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "n0b0dy")
+	_, err = client.GetGopher(ctx, &gopherpb.GetGopherRequest{Id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// the caller is authenticated but isn't allowed to write
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "s3cr3t")
+	_, err = client.DeleteGopher(ctx, &gopherpb.DeleteGopherRequest{Id: "01D3XZ3ZHCP3KG9VT4FGAD8KDR"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func Test_Server_RateLimit(t *testing.T) {
+	// GIVEN a server requiring credentials which limits each client IP to 2 calls
+	authenticator, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "ci", Hash: auth.HashAPIKey("s3cr3t"), Scopes: []string{"gophers:read"}},
+	})
+	require.NoError(t, err)
+	limit := ratelimit.Limit{Rate: 0.001, Burst: 2}
+	client := buildClient(t, []auth.Authenticator{authenticator}, WithRateLimit(ratelimit.NewMemoryStore(), limit))
+
+	// WHEN the client calls it with wrong credentials
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wrong")
+	for i := 0; i < limit.Burst; i++ {
+		_, err := client.ListGophers(ctx, &gopherpb.ListGophersRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// THEN its calls are limited before they are authenticated, even with the right credentials
+	var header metadata.MD
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "s3cr3t")
+	_, err = client.ListGophers(ctx, &gopherpb.ListGophersRequest{}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NotEmpty(t, header.Get("retry-after"))
+}
+
+func buildClient(t *testing.T, authenticators []auth.Authenticator, opts ...Option) gopherpb.GopherServiceClient {
+	gophers := make(map[string]gopher.Gopher, len(sample.Gophers))
+	for ID, g := range sample.Gophers {
+		gophers[ID] = g
+	}
+
+	broker := watching.NewBroker()
+	repo := watching.NewRepository(inmem.NewRepository(gophers), broker)
+	var (
+		fS = fetching.NewService(repo, log.NewNoopLogger())
+		aS = adding.NewService(repo)
+		mS = modifying.NewService(repo)
+		rS = removing.NewService(repo)
+		wS = watching.NewService(broker)
+	)
+	if len(authenticators) > 0 {
+		policy := auth.DefaultPolicy()
+		fS = fetching.NewAuthorizingService(fS, policy)
+		aS = adding.NewAuthorizingService(aS, policy)
+		mS = modifying.NewAuthorizingService(mS, policy)
+		rS = removing.NewAuthorizingService(rS, policy)
+		wS = watching.NewAuthorizingService(wS, policy)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	s := NewServer(tracer.NewNoopTracer(), fS, aS, mS, rS, wS, append(opts, WithAuthenticators(authenticators...))...)
+	go func() { _ = s.Serve(listener) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return gopherpb.NewGopherServiceClient(conn)
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Declare the statuses reported by the probe
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Checker is implemented by the dependencies able to report their health,
+// e.g. the repositories pinging their database
+type Checker interface {
+	// HealthCheck returns an error when the dependency can't be used
+	HealthCheck(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface
+type CheckerFunc func(ctx context.Context) error
+
+// HealthCheck satisfies the Checker interface
+func (f CheckerFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Result is the outcome of checking a dependency, only its status is reported, its latency
+// and error are kept to be logged, as they may tell the internals of the dependency
+type Result struct {
+	Status  string        `json:"status"`
+	Latency time.Duration `json:"-"`
+	Err     error         `json:"-"`
+}
+
+// Report is the readiness of the server and the results of each dependency
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready reports whether the server and all its dependencies are up
+func (r Report) Ready() bool {
+	return r.Status == StatusUp
+}
+
+// Probe checks the readiness of the server
+type Probe struct {
+	timeout      time.Duration
+	shuttingDown int32
+
+	mtx      sync.RWMutex
+	checkers map[string]Checker
+}
+
+// NewProbe creates a probe giving each dependency the given time to answer
+func NewProbe(timeout time.Duration) *Probe {
+	return &Probe{timeout: timeout, checkers: make(map[string]Checker)}
+}
+
+// Register adds a dependency to check
+func (p *Probe) Register(name string, checker Checker) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.checkers[name] = checker
+}
+
+// ShutDown flips the probe to not ready, so no more traffic is sent while draining
+func (p *Probe) ShutDown() {
+	atomic.StoreInt32(&p.shuttingDown, 1)
+}
+
+// Check checks every dependency concurrently
+func (p *Probe) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+
+	var (
+		wg  sync.WaitGroup
+		mtx sync.Mutex
+	)
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(p.checkers))}
+	for name, checker := range p.checkers {
+		wg.Add(1)
+		go func(name string, checker Checker) {
+			defer wg.Done()
+			result := check(ctx, checker)
+
+			mtx.Lock()
+			defer mtx.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(&p.shuttingDown) == 1 {
+		report.Status = StatusDown
+	}
+	return report
+}
+
+func check(ctx context.Context, checker Checker) Result {
+	start := time.Now()
+	err := checker.HealthCheck(ctx)
+	result := Result{Status: StatusUp, Latency: time.Since(start), Err: err}
+	if err != nil {
+		result.Status = StatusDown
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package importing
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps an importing service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// ImportGophers starts importing the gophers if the caller has write permission, as they
+// can be created and replaced
+func (s *authorizingService) ImportGophers(ctx context.Context, source Source, policy Policy) (Job, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionWrite); err != nil {
+		_ = source.Close()
+		return Job{}, err
+	}
+	return s.next.ImportGophers(ctx, source, policy)
+}
+
+// FetchJob returns the import job if the caller has read permission
+func (s *authorizingService) FetchJob(ctx context.Context, ID string) (Job, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return Job{}, err
+	}
+	return s.next.FetchJob(ctx, ID)
+}
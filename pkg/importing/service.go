@@ -0,0 +1,280 @@
+package importing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+// maxRowErrors is the number of row errors described by a job, the rest are only counted
+const maxRowErrors = 100
+
+// jobRetention is how long the finished jobs can still be polled
+const jobRetention = time.Hour
+
+var (
+	// ErrJobNotFound is returned when the import job doesn't exist, or isn't kept anymore
+	ErrJobNotFound = errors.New("import job not found")
+	// ErrInvalidPolicy is returned when the conflict policy isn't one of the known ones
+	ErrInvalidPolicy = errors.New("the conflict policy must be upsert, skip or fail")
+	// ErrGopherExists is the row error of the gophers which already exist, under the fail policy
+	ErrGopherExists = errors.New("the gopher already exists")
+)
+
+// validID matches the IDs accepted by the api
+var validID = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Policy tells what to do with the gophers imported which already exist
+type Policy string
+
+// Declare the conflict policies
+const (
+	// PolicyUpsert replaces the gophers which exist
+	PolicyUpsert Policy = "upsert"
+	// PolicySkip keeps the gophers which exist
+	PolicySkip Policy = "skip"
+	// PolicyFail stops the import at the first gopher which exists or can't be imported,
+	// keeping the ones imported before
+	PolicyFail Policy = "fail"
+)
+
+// Valid reports whether the policy is one of the known ones
+func (p Policy) Valid() bool {
+	return p == PolicyUpsert || p == PolicySkip || p == PolicyFail
+}
+
+// Status of an import job
+type Status string
+
+// Declare the statuses of the import jobs
+const (
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// RowError describes why a gopher of the source wasn't imported
+type RowError struct {
+	// Row is the position of the gopher in the source, from 1
+	Row     int
+	ID      string
+	Message string
+}
+
+// Job is the progress of an import
+type Job struct {
+	ID     string
+	Status Status
+	Policy Policy
+	// Processed is the number of gophers read from the source
+	Processed int
+	Created   int
+	Updated   int
+	Skipped   int
+	Failed    int
+	// Errors describes the first gophers which failed
+	Errors []RowError
+	// Error is why the import stopped before the end of the source
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Source is the list of gophers to import, closed once they are read
+type Source interface {
+	encoding.ListDecoder
+	io.Closer
+}
+
+// Service provides importing operations.
+type Service interface {
+	// ImportGophers starts importing the gophers of the given source in the background,
+	// returning the job to poll its progress
+	ImportGophers(ctx context.Context, source Source, policy Policy) (Job, error)
+	// FetchJob returns the import job with the given ID
+	FetchJob(ctx context.Context, ID string) (Job, error)
+}
+
+type service struct {
+	repository gopher.Repository
+
+	mtx  sync.Mutex
+	jobs map[jobKey]*Job
+}
+
+// jobKey identifies a job within its tenant, so the jobs are only seen by their tenant
+type jobKey struct {
+	tenantID, ID string
+}
+
+// NewService creates an importing service with the necessary dependencies, the jobs are
+// kept in memory so they are lost when the server stops
+func NewService(repository gopher.Repository) Service {
+	return &service{repository: repository, jobs: make(map[jobKey]*Job)}
+}
+
+// ImportGophers starts importing the gophers of the given source in the background
+func (s *service) ImportGophers(ctx context.Context, source Source, policy Policy) (Job, error) {
+	if !policy.Valid() {
+		_ = source.Close()
+		return Job{}, ErrInvalidPolicy
+	}
+
+	job := &Job{ID: ulid.Make().String(), Status: StatusRunning, Policy: policy, CreatedAt: time.Now()}
+
+	s.mtx.Lock()
+	s.prune(job.CreatedAt)
+	s.jobs[jobKey{tenant.ID(ctx), job.ID}] = job
+	started := job.snapshot()
+	s.mtx.Unlock()
+
+	// the import outlives the request which started it, within the same tenant and caller
+	go s.run(context.WithoutCancel(ctx), job, source)
+	return started, nil
+}
+
+// FetchJob returns the import job with the given ID
+func (s *service) FetchJob(ctx context.Context, ID string) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	job, ok := s.jobs[jobKey{tenant.ID(ctx), ID}]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job.snapshot(), nil
+}
+
+// outcome is what was done with a gopher imported
+type outcome int
+
+const (
+	outcomeCreated outcome = iota
+	outcomeUpdated
+	outcomeSkipped
+)
+
+func (s *service) run(ctx context.Context, job *Job, source Source) {
+	defer func() { _ = source.Close() }()
+
+	for row := 1; ; row++ {
+		var g gopher.Gopher
+		err := source.Decode(&g)
+		if err == io.EOF {
+			s.finish(job, nil)
+			return
+		}
+		if err != nil {
+			s.finish(job, fmt.Errorf("row %d can't be read: %w", row, err))
+			return
+		}
+
+		result, err := s.importGopher(ctx, g, job.Policy)
+		s.record(job, row, g.ID, result, err)
+		if err != nil && job.Policy == PolicyFail {
+			s.finish(job, fmt.Errorf("row %d: %w", row, err))
+			return
+		}
+	}
+}
+
+// importGopher saves the given gopher following the conflict policy
+func (s *service) importGopher(ctx context.Context, g gopher.Gopher, policy Policy) (outcome, error) {
+	if err := validate(g); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	existing, err := s.repository.FetchGopherByID(ctx, g.ID)
+	if errors.Is(err, gopher.ErrNotFound) {
+		g.CreatedAt, g.UpdatedAt = &now, &now
+		return outcomeCreated, s.repository.CreateGopher(ctx, &g)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch policy {
+	case PolicySkip:
+		return outcomeSkipped, nil
+	case PolicyFail:
+		return 0, ErrGopherExists
+	}
+	g.CreatedAt, g.UpdatedAt = existing.CreatedAt, &now
+	return outcomeUpdated, s.repository.UpdateGopher(ctx, g.ID, g)
+}
+
+func validate(g gopher.Gopher) error {
+	switch {
+	case !validID.MatchString(g.ID):
+		return errors.New("the ID must be made of letters, digits and underscores")
+	case gopher.ReservedID(g.ID):
+		return gopher.ErrReservedID
+	case g.Name == "":
+		return errors.New("the name is required")
+	case g.Age < 0:
+		return errors.New("the age can't be negative")
+	}
+	return nil
+}
+
+func (s *service) record(job *Job, row int, ID string, result outcome, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	job.Processed++
+	if err != nil {
+		job.Failed++
+		if len(job.Errors) < maxRowErrors {
+			job.Errors = append(job.Errors, RowError{Row: row, ID: ID, Message: err.Error()})
+		}
+		return
+	}
+
+	switch result {
+	case outcomeCreated:
+		job.Created++
+	case outcomeUpdated:
+		job.Updated++
+	case outcomeSkipped:
+		job.Skipped++
+	}
+}
+
+func (s *service) finish(job *Job, err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = StatusSucceeded
+	if err != nil {
+		job.Status, job.Error = StatusFailed, err.Error()
+	}
+}
+
+// prune forgets the jobs finished for longer than the retention, it must be called holding the lock
+func (s *service) prune(now time.Time) {
+	for key, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobRetention {
+			delete(s.jobs, key)
+		}
+	}
+}
+
+// snapshot copies the job, so it can be read while it's updated, it must be called holding the lock
+func (j *Job) snapshot() Job {
+	snapshot := *j
+	snapshot.Errors = append([]RowError(nil), j.Errors...)
+	return snapshot
+}
@@ -0,0 +1,175 @@
+package importing
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+const rows = `{"ID": "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "name": "Jenny", "age": 19}
+{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}
+{"ID": "not valid!", "name": "Nobody"}
+{"ID": "01DCBP0R0MSNZY975ZQF1DCQCJ"}
+`
+
+func Test_Service_ImportGophers(t *testing.T) {
+	testData := []struct {
+		name    string
+		policy  Policy
+		status  Status
+		created int
+		updated int
+		skipped int
+		failed  int
+		age     int
+	}{
+		{name: "upsert", policy: PolicyUpsert, status: StatusSucceeded, created: 1, updated: 1, failed: 2, age: 19},
+		{name: "skip", policy: PolicySkip, status: StatusSucceeded, created: 1, skipped: 1, failed: 2, age: 18},
+		{name: "fail", policy: PolicyFail, status: StatusFailed, failed: 1, age: 18},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a storage where the first gopher already exists
+			repo := inmem.NewRepository(nil)
+			require.NoError(t, repo.CreateGopher(context.Background(), gopher.New("01D3XZ3ZHCP3KG9VT4FGAD8KDR", "Jenny", "", 18)))
+			s := NewService(repo)
+
+			// WHEN the gophers are imported
+			job, err := s.ImportGophers(context.Background(), newSource(rows), tt.policy)
+			require.NoError(t, err)
+			job = wait(t, s, context.Background(), job.ID)
+
+			// THEN the existing one is handled by the policy, and the invalid ones are reported
+			assert.Equal(t, tt.status, job.Status)
+			assert.Equal(t, tt.created, job.Created)
+			assert.Equal(t, tt.updated, job.Updated)
+			assert.Equal(t, tt.skipped, job.Skipped)
+			assert.Equal(t, tt.failed, job.Failed)
+			assert.Len(t, job.Errors, tt.failed)
+			assert.NotNil(t, job.FinishedAt)
+
+			g, err := repo.FetchGopherByID(context.Background(), "01D3XZ3ZHCP3KG9VT4FGAD8KDR")
+			require.NoError(t, err)
+			assert.Equal(t, tt.age, g.Age)
+		})
+	}
+}
+
+func Test_Service_RowErrors(t *testing.T) {
+	s := NewService(inmem.NewRepository(nil))
+
+	job, err := s.ImportGophers(context.Background(), newSource(rows), PolicySkip)
+	require.NoError(t, err)
+	job = wait(t, s, context.Background(), job.ID)
+
+	assert.Equal(t, []RowError{
+		{Row: 3, ID: "not valid!", Message: "the ID must be made of letters, digits and underscores"},
+		{Row: 4, ID: "01DCBP0R0MSNZY975ZQF1DCQCJ", Message: "the name is required"},
+	}, job.Errors)
+	assert.Equal(t, 4, job.Processed)
+}
+
+func Test_Service_MalformedSource(t *testing.T) {
+	s := NewService(inmem.NewRepository(nil))
+
+	job, err := s.ImportGophers(context.Background(), newSource(`{"ID": "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "name": "Jenny"}`+"\n{oops"), PolicyUpsert)
+	require.NoError(t, err)
+	job = wait(t, s, context.Background(), job.ID)
+
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Equal(t, 1, job.Created)
+	assert.Contains(t, job.Error, "row 2 can't be read")
+}
+
+func Test_Service_InvalidPolicy(t *testing.T) {
+	s := NewService(inmem.NewRepository(nil))
+	source := newSource(rows)
+
+	_, err := s.ImportGophers(context.Background(), source, "merge")
+
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+	assert.True(t, source.closed)
+}
+
+func Test_Service_JobsOfTheTenant(t *testing.T) {
+	s := NewService(inmem.NewRepository(nil))
+	acme := tenant.WithTenant(context.Background(), "acme")
+
+	job, err := s.ImportGophers(acme, newSource(rows), PolicySkip)
+	require.NoError(t, err)
+	wait(t, s, acme, job.ID)
+
+	_, err = s.FetchJob(context.Background(), job.ID)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+type source struct {
+	encoding.ListDecoder
+	closed bool
+}
+
+func newSource(body string) *source {
+	return &source{ListDecoder: encoding.NDJSON.NewListDecoder(strings.NewReader(body))}
+}
+
+func (s *source) Close() error {
+	s.closed = true
+	return nil
+}
+
+// wait polls the job until it's finished
+func wait(t *testing.T, s Service, ctx context.Context, ID string) Job {
+	t.Helper()
+
+	var job Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = s.FetchJob(ctx, ID)
+		require.NoError(t, err)
+		return job.Status != StatusRunning
+	}, time.Second, time.Millisecond)
+	return job
+}
+
+func Test_Service_RepositoryErrors(t *testing.T) {
+	// GIVEN a storage failing to tell whether the gophers exist
+	repo := &failingRepository{Repository: inmem.NewRepository(nil), err: errors.New("connection refused")}
+	s := NewService(repo)
+
+	// WHEN the gophers are imported
+	job, err := s.ImportGophers(context.Background(), newSource(rows), PolicyUpsert)
+	require.NoError(t, err)
+	job = wait(t, s, context.Background(), job.ID)
+
+	// THEN they are reported as failed instead of created
+	assert.Equal(t, 0, job.Created)
+	assert.Equal(t, 4, job.Failed)
+	assert.Equal(t, "connection refused", job.Errors[0].Message)
+	assert.False(t, repo.created)
+}
+
+type failingRepository struct {
+	gopher.Repository
+	err     error
+	created bool
+}
+
+func (r *failingRepository) FetchGopherByID(context.Context, string) (*gopher.Gopher, error) {
+	return nil, r.err
+}
+
+func (r *failingRepository) CreateGopher(ctx context.Context, g *gopher.Gopher) error {
+	r.created = true
+	return r.Repository.CreateGopher(ctx, g)
+}
@@ -0,0 +1,45 @@
+package importing
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps an importing service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// ImportGophers starts importing the gophers within a span, tagging the job started
+func (s *tracingService) ImportGophers(ctx context.Context, source Source, policy Policy) (Job, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "importing.ImportGophers")
+	span.Tag("import.policy", string(policy))
+	defer span.Finish()
+
+	job, err := s.next.ImportGophers(ctx, source, policy)
+	if err != nil {
+		span.SetError(err)
+		return job, err
+	}
+	span.Tag("import.job", job.ID)
+	return job, nil
+}
+
+// FetchJob returns the import job within a span
+func (s *tracingService) FetchJob(ctx context.Context, ID string) (Job, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "importing.FetchJob")
+	span.Tag("import.job", ID)
+	defer span.Finish()
+
+	job, err := s.next.FetchJob(ctx, ID)
+	if err != nil {
+		span.SetError(err)
+	}
+	return job, err
+}
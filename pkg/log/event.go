@@ -0,0 +1,31 @@
+package log
+
+import "fmt"
+
+// Event is a message of the catalog logged by every logger, its ID is logged along
+// with it, as logid, so its entries can be found whatever they were formatted with
+type Event struct {
+	ID      string
+	Message string
+}
+
+// Format returns the message of the event formatted with the given args
+func (e Event) Format(args ...interface{}) string {
+	if len(args) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf(e.Message, args...)
+}
+
+// Declare the events logged by each method of the Logger interface
+var (
+	UnexpectedErrorMessage = Event{"01DK2XFX9PQ85ZPZ5CP68P108Y", "Unexpected error: %v"}
+	RequestStartedMessage  = Event{"01M59VR5PQDHPGFV964P3D3XXG", "Request started: %s %s"}
+	RequestFinishedMessage = Event{"01M59VR5QWZZBAZ35EW764KAGM", "Request finished: %s %s %d"}
+	InvalidRequestMessage  = Event{"01M59VR5S1A02N8PNZBXYKG2ZR", "Invalid request: %v"}
+	PanicRecoveredMessage  = Event{"01M59VR5YTWAWXTSJHRHVZ9QBH", "Panic recovered: %v"}
+	RepositoryErrorMessage = Event{"01M59VR5T6GB5C69M4YTX5QN8Q", "Repository error on %s: %v"}
+	ServerStartedMessage   = Event{"01M59VR5VB377AFE81TK3E9WX9", "The gopher server is on tap now: %s"}
+	ServerStoppingMessage  = Event{"01M59VR5WGMPW3STR26VDCS141", "Shutting down the gopher server, draining in-flight requests"}
+	StartupFailedMessage   = Event{"01M59VR5XN0775DFN4ZCBEWPCH", "The gopher server can't start: %v"}
+)
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"context"
+)
+
+// Field is a value of the context logged along with every message
+type Field struct {
+	Key   string
+	Value string
+}
+
+// FieldsFunc reads from the context the fields logged along with every message, the packages
+// storing values in the context provide theirs, like the server with the values of its requests
+type FieldsFunc func(ctx context.Context) []Field
+
+// ContextFields returns the fields read from the context by each of the given functions, in order
+func ContextFields(ctx context.Context, fns []FieldsFunc) []Field {
+	var fields []Field
+	for _, fn := range fns {
+		fields = append(fields, fn(ctx)...)
+	}
+	return fields
+}
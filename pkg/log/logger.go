@@ -2,10 +2,65 @@ package log
 
 import (
 	"context"
+	"time"
 )
 
+// Declare the levels of the messages, from the most verbose
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// Declare the formats of the messages
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Config determines which messages are logged and how
+type Config struct {
+	// Level is the minimum level of the messages logged, info by default
+	Level string
+	// Format is text or json, text by default
+	Format string
+	// Fields read from the context the fields logged along with every message, none by default
+	Fields []FieldsFunc
+}
+
+// Access describes a request served by the server
+type Access struct {
+	// Method is the HTTP method of the request
+	Method string
+	// Path is the template of the route matched, instead of the raw path, to keep the cardinality low
+	Path string
+	// Status is the status code of the response
+	Status int
+	// Bytes is the size of the response body
+	Bytes int
+	// Duration is the time taken to serve the request
+	Duration time.Duration
+}
+
 // Logger determine the way to centralize log messages format
 type Logger interface {
 	// UnexpectedError is a standard error message for unexpected errors
 	UnexpectedError(ctx context.Context, err error)
+	// RequestStarted is a debug message for each request received
+	RequestStarted(ctx context.Context, method, path string)
+	// RequestFinished is an info message for each request served, its access log entry
+	RequestFinished(ctx context.Context, access Access)
+	// InvalidRequest is a warning message for the requests rejected as malformed
+	InvalidRequest(ctx context.Context, err error)
+	// PanicRecovered is an error message for the panics recovered while serving a request, with their stack trace
+	PanicRecovered(ctx context.Context, recovered interface{}, stack []byte)
+	// RepositoryError is an error message for the failed storage operations
+	RepositoryError(ctx context.Context, operation string, err error)
+	// ServerStarted is an info message once the server is listening on the given address
+	ServerStarted(ctx context.Context, addr string)
+	// ServerStopping is an info message when the server starts draining its requests on shutdown
+	ServerStopping(ctx context.Context)
+	// StartupFailed is an error message for the errors preventing the server to start, the caller exits the process
+	StartupFailed(ctx context.Context, err error)
 }
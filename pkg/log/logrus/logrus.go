@@ -2,55 +2,105 @@ package logrus
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/friendsofgo/gopherapi/pkg/log"
-	"github.com/friendsofgo/gopherapi/pkg/server"
 )
 
-// Event stores messages to log later, from our standard interface
-type Event struct {
-	id      string
-	message string
-}
-
 // Logger centralize log messages format
 type logger struct {
 	*logrus.Logger
+	fields []log.FieldsFunc
 }
 
-// NewLogger initializes the standard logger
-func NewLogger(hooks ...logrus.Hook) log.Logger {
-	return &logger{logrus.New()}
-}
+// NewLogger initializes the standard logger with the level and format of the given config,
+// the given hooks are fired for each message logged
+func NewLogger(config log.Config, hooks ...logrus.Hook) (log.Logger, error) {
+	l := logrus.New()
+	for _, hook := range hooks {
+		l.AddHook(hook)
+	}
 
-// Declare variables to store log messages as new Events
-var (
-	unexpectedErrorMessage = Event{"01DK2XFX9PQ85ZPZ5CP68P108Y", "Unexpected error: %v"}
-)
+	if config.Level != "" {
+		level, err := logrus.ParseLevel(config.Level)
+		if err != nil {
+			return nil, err
+		}
+		l.SetLevel(level)
+	}
+
+	switch config.Format {
+	case "", log.FormatText:
+	case log.FormatJSON:
+		l.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+
+	return &logger{l, config.Fields}, nil
+}
 
 func (l *logger) UnexpectedError(ctx context.Context, err error) {
-	l.WithDefaultFields(ctx).WithField("logid", unexpectedErrorMessage.id).
-		Errorf(unexpectedErrorMessage.message, err)
+	l.WithDefaultFields(ctx).WithField("logid", log.UnexpectedErrorMessage.ID).
+		Errorf(log.UnexpectedErrorMessage.Message, err)
 }
 
-func (l *logger) WithDefaultFields(ctx context.Context) *logrus.Entry {
-	serverID, _ := server.ID(ctx)
-	endpoint, _ := server.Endpoint(ctx)
-	clientIP, _ := server.ClientIP(ctx)
-	fields := logrus.Fields{
-		"serverid": serverID,
-		"endpoint": endpoint,
-		"clientip": clientIP,
-	}
+func (l *logger) RequestStarted(ctx context.Context, method, path string) {
+	l.WithDefaultFields(ctx).WithField("logid", log.RequestStartedMessage.ID).
+		Debugf(log.RequestStartedMessage.Message, method, path)
+}
 
-	if xForwardedFor, ok := server.XForwardedFor(ctx); ok {
-		fields["xforwardedfor"] = xForwardedFor
-	}
-	if xForwardedProto, ok := server.XForwardedProto(ctx); ok {
-		fields["xforwardedproto"] = xForwardedProto
-	}
+func (l *logger) RequestFinished(ctx context.Context, access log.Access) {
+	l.WithDefaultFields(ctx).WithField("logid", log.RequestFinishedMessage.ID).
+		WithFields(logrus.Fields{
+			"method":  access.Method,
+			"path":    access.Path,
+			"status":  access.Status,
+			"bytes":   access.Bytes,
+			"latency": access.Duration.Seconds(),
+		}).
+		Infof(log.RequestFinishedMessage.Message, access.Method, access.Path, access.Status)
+}
+
+func (l *logger) InvalidRequest(ctx context.Context, err error) {
+	l.WithDefaultFields(ctx).WithField("logid", log.InvalidRequestMessage.ID).
+		Warnf(log.InvalidRequestMessage.Message, err)
+}
+
+func (l *logger) PanicRecovered(ctx context.Context, recovered interface{}, stack []byte) {
+	l.WithDefaultFields(ctx).WithField("logid", log.PanicRecoveredMessage.ID).
+		WithField("stack", string(stack)).
+		Errorf(log.PanicRecoveredMessage.Message, recovered)
+}
+
+func (l *logger) RepositoryError(ctx context.Context, operation string, err error) {
+	l.WithDefaultFields(ctx).WithField("logid", log.RepositoryErrorMessage.ID).
+		WithField("operation", operation).
+		Errorf(log.RepositoryErrorMessage.Message, operation, err)
+}
 
+func (l *logger) ServerStarted(ctx context.Context, addr string) {
+	l.WithDefaultFields(ctx).WithField("logid", log.ServerStartedMessage.ID).
+		Infof(log.ServerStartedMessage.Message, addr)
+}
+
+func (l *logger) ServerStopping(ctx context.Context) {
+	l.WithDefaultFields(ctx).WithField("logid", log.ServerStoppingMessage.ID).
+		Info(log.ServerStoppingMessage.Message)
+}
+
+func (l *logger) StartupFailed(ctx context.Context, err error) {
+	l.WithDefaultFields(ctx).WithField("logid", log.StartupFailedMessage.ID).
+		Errorf(log.StartupFailedMessage.Message, err)
+}
+
+// WithDefaultFields returns an entry with the fields of the context logged along with every message
+func (l *logger) WithDefaultFields(ctx context.Context) *logrus.Entry {
+	fields := logrus.Fields{}
+	for _, field := range log.ContextFields(ctx, l.fields) {
+		fields[field.Key] = field.Value
+	}
 	return l.WithFields(fields)
 }
@@ -0,0 +1,55 @@
+package logrus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+func TestNewLogger_InvalidConfig(t *testing.T) {
+	_, err := NewLogger(log.Config{Level: "loud"})
+	assert.Error(t, err)
+
+	_, err = NewLogger(log.Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestLogger_Level(t *testing.T) {
+	l, err := NewLogger(log.Config{Level: log.LevelWarn, Format: log.FormatJSON})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	l.(*logger).Out = &out
+
+	ctx := context.Background()
+	l.RequestFinished(ctx, log.Access{Method: "GET", Path: "/gophers", Status: 200, Duration: time.Millisecond})
+	l.InvalidRequest(ctx, errors.New("malformed body"))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry), "only the warning is expected to be logged")
+	assert.Equal(t, "warning", entry["level"])
+	assert.Equal(t, log.InvalidRequestMessage.ID, entry["logid"])
+	assert.Equal(t, "Invalid request: malformed body", entry["msg"])
+}
+
+func TestNewLogger_Hooks(t *testing.T) {
+	hook := test.NewLocal(logrus.New())
+	l, err := NewLogger(log.Config{}, hook)
+	require.NoError(t, err)
+	l.(*logger).Out = &bytes.Buffer{}
+
+	l.UnexpectedError(context.Background(), errors.New("boom"))
+
+	require.Len(t, hook.AllEntries(), 1)
+	assert.Equal(t, log.UnexpectedErrorMessage.ID, hook.LastEntry().Data["logid"])
+}
@@ -1,6 +1,8 @@
 package log
 
-import "context"
+import (
+	"context"
+)
 
 type noop struct {
 }
@@ -13,3 +15,35 @@ func NewNoopLogger() Logger {
 func (l *noop) UnexpectedError(ctx context.Context, err error) {
 	// nothing to do here
 }
+
+func (l *noop) RequestStarted(ctx context.Context, method, path string) {
+	// nothing to do here
+}
+
+func (l *noop) RequestFinished(ctx context.Context, access Access) {
+	// nothing to do here
+}
+
+func (l *noop) InvalidRequest(ctx context.Context, err error) {
+	// nothing to do here
+}
+
+func (l *noop) PanicRecovered(ctx context.Context, recovered interface{}, stack []byte) {
+	// nothing to do here
+}
+
+func (l *noop) RepositoryError(ctx context.Context, operation string, err error) {
+	// nothing to do here
+}
+
+func (l *noop) ServerStarted(ctx context.Context, addr string) {
+	// nothing to do here
+}
+
+func (l *noop) ServerStopping(ctx context.Context) {
+	// nothing to do here
+}
+
+func (l *noop) StartupFailed(ctx context.Context, err error) {
+	// nothing to do here
+}
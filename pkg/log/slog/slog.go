@@ -0,0 +1,102 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+// logger centralize log messages format
+type logger struct {
+	*slog.Logger
+	fields []log.FieldsFunc
+}
+
+// NewLogger initializes a log/slog logger writing to the standard error
+// with the level and format of the given config
+func NewLogger(config log.Config) (log.Logger, error) {
+	return newLogger(config, os.Stderr)
+}
+
+func newLogger(config log.Config, w io.Writer) (log.Logger, error) {
+	level := slog.LevelInfo
+	if config.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch config.Format {
+	case "", log.FormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case log.FormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+
+	return &logger{slog.New(handler), config.Fields}, nil
+}
+
+func (l *logger) UnexpectedError(ctx context.Context, err error) {
+	l.log(ctx, slog.LevelError, log.UnexpectedErrorMessage, []interface{}{err})
+}
+
+func (l *logger) RequestStarted(ctx context.Context, method, path string) {
+	l.log(ctx, slog.LevelDebug, log.RequestStartedMessage, []interface{}{method, path})
+}
+
+func (l *logger) RequestFinished(ctx context.Context, access log.Access) {
+	l.log(ctx, slog.LevelInfo, log.RequestFinishedMessage, []interface{}{access.Method, access.Path, access.Status},
+		slog.String("method", access.Method),
+		slog.String("path", access.Path),
+		slog.Int("status", access.Status),
+		slog.Int("bytes", access.Bytes),
+		slog.Float64("latency", access.Duration.Seconds()),
+	)
+}
+
+func (l *logger) InvalidRequest(ctx context.Context, err error) {
+	l.log(ctx, slog.LevelWarn, log.InvalidRequestMessage, []interface{}{err})
+}
+
+func (l *logger) PanicRecovered(ctx context.Context, recovered interface{}, stack []byte) {
+	l.log(ctx, slog.LevelError, log.PanicRecoveredMessage, []interface{}{recovered}, slog.String("stack", string(stack)))
+}
+
+func (l *logger) RepositoryError(ctx context.Context, operation string, err error) {
+	l.log(ctx, slog.LevelError, log.RepositoryErrorMessage, []interface{}{operation, err}, slog.String("operation", operation))
+}
+
+func (l *logger) ServerStarted(ctx context.Context, addr string) {
+	l.log(ctx, slog.LevelInfo, log.ServerStartedMessage, []interface{}{addr})
+}
+
+func (l *logger) ServerStopping(ctx context.Context) {
+	l.log(ctx, slog.LevelInfo, log.ServerStoppingMessage, nil)
+}
+
+func (l *logger) StartupFailed(ctx context.Context, err error) {
+	l.log(ctx, slog.LevelError, log.StartupFailedMessage, []interface{}{err})
+}
+
+// log logs the event formatted with the given args, along with the fields of the context and the given attributes
+func (l *logger) log(ctx context.Context, level slog.Level, event log.Event, args []interface{}, attrs ...slog.Attr) {
+	if !l.Enabled(ctx, level) {
+		return
+	}
+
+	fields := log.ContextFields(ctx, l.fields)
+	all := make([]slog.Attr, 0, len(fields)+1+len(attrs))
+	for _, field := range fields {
+		all = append(all, slog.String(field.Key, field.Value))
+	}
+	all = append(all, slog.String("logid", event.ID))
+	l.LogAttrs(ctx, level, event.Format(args...), append(all, attrs...)...)
+}
@@ -0,0 +1,63 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+func TestNewLogger_InvalidConfig(t *testing.T) {
+	_, err := NewLogger(log.Config{Level: "loud"})
+	assert.Error(t, err)
+
+	_, err = NewLogger(log.Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestLogger_DefaultFields(t *testing.T) {
+	var out bytes.Buffer
+	l, err := newLogger(log.Config{Level: log.LevelWarn, Format: log.FormatJSON, Fields: requestFields}, &out)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	l.RequestFinished(ctx, log.Access{Method: "GET", Path: "/gophers", Status: 200, Duration: time.Millisecond})
+	l.InvalidRequest(ctx, errors.New("malformed body"))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry), "only the warning is expected to be logged")
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, log.InvalidRequestMessage.ID, entry["logid"])
+	assert.Equal(t, "Invalid request: malformed body", entry["msg"])
+	for _, field := range []string{"serverid", "requestid"} {
+		assert.Contains(t, entry, field)
+	}
+}
+
+var requestFields = []log.FieldsFunc{
+	func(context.Context) []log.Field { return []log.Field{{Key: "serverid", Value: "gopherapi"}} },
+	func(context.Context) []log.Field {
+		return []log.Field{{Key: "requestid", Value: "01D3XZ3ZHCP3KG9VT4FGAD8KDR"}}
+	},
+}
+
+func TestLogger_StartupFailed(t *testing.T) {
+	var out bytes.Buffer
+	l, err := newLogger(log.Config{Format: log.FormatJSON}, &out)
+	require.NoError(t, err)
+
+	// the caller exits the process, the logger returns once the error is logged
+	l.StartupFailed(context.Background(), errors.New("address already in use"))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, log.StartupFailedMessage.ID, entry["logid"])
+}
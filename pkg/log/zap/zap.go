@@ -0,0 +1,107 @@
+package zap
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+// logger centralize log messages format
+type logger struct {
+	*zap.Logger
+	fields []log.FieldsFunc
+}
+
+// NewLogger initializes a zap logger writing to the standard error with the level
+// and format of the given config, the given options are applied to it, like zap.Hooks
+func NewLogger(config log.Config, opts ...zap.Option) (log.Logger, error) {
+	zapConfig := zap.NewProductionConfig()
+	zapConfig.Sampling = nil
+	// every message is logged from the same helper, so its caller says nothing
+	zapConfig.DisableCaller = true
+
+	if config.Level != "" {
+		level, err := zap.ParseAtomicLevel(config.Level)
+		if err != nil {
+			return nil, err
+		}
+		zapConfig.Level = level
+	}
+
+	switch config.Format {
+	case "", log.FormatText:
+		zapConfig.Encoding = "console"
+		zapConfig.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case log.FormatJSON:
+		zapConfig.Encoding = "json"
+	default:
+		return nil, fmt.Errorf("unknown log format %q", config.Format)
+	}
+
+	l, err := zapConfig.Build(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &logger{l, config.Fields}, nil
+}
+
+func (l *logger) UnexpectedError(ctx context.Context, err error) {
+	l.log(ctx, zapcore.ErrorLevel, log.UnexpectedErrorMessage, []interface{}{err})
+}
+
+func (l *logger) RequestStarted(ctx context.Context, method, path string) {
+	l.log(ctx, zapcore.DebugLevel, log.RequestStartedMessage, []interface{}{method, path})
+}
+
+func (l *logger) RequestFinished(ctx context.Context, access log.Access) {
+	l.log(ctx, zapcore.InfoLevel, log.RequestFinishedMessage, []interface{}{access.Method, access.Path, access.Status},
+		zap.String("method", access.Method),
+		zap.String("path", access.Path),
+		zap.Int("status", access.Status),
+		zap.Int("bytes", access.Bytes),
+		zap.Float64("latency", access.Duration.Seconds()),
+	)
+}
+
+func (l *logger) InvalidRequest(ctx context.Context, err error) {
+	l.log(ctx, zapcore.WarnLevel, log.InvalidRequestMessage, []interface{}{err})
+}
+
+func (l *logger) PanicRecovered(ctx context.Context, recovered interface{}, stack []byte) {
+	l.log(ctx, zapcore.ErrorLevel, log.PanicRecoveredMessage, []interface{}{recovered}, zap.ByteString("stack", stack))
+}
+
+func (l *logger) RepositoryError(ctx context.Context, operation string, err error) {
+	l.log(ctx, zapcore.ErrorLevel, log.RepositoryErrorMessage, []interface{}{operation, err}, zap.String("operation", operation))
+}
+
+func (l *logger) ServerStarted(ctx context.Context, addr string) {
+	l.log(ctx, zapcore.InfoLevel, log.ServerStartedMessage, []interface{}{addr})
+}
+
+func (l *logger) ServerStopping(ctx context.Context) {
+	l.log(ctx, zapcore.InfoLevel, log.ServerStoppingMessage, nil)
+}
+
+func (l *logger) StartupFailed(ctx context.Context, err error) {
+	l.log(ctx, zapcore.ErrorLevel, log.StartupFailedMessage, []interface{}{err})
+}
+
+// log logs the event formatted with the given args, along with the fields of the context and the given fields
+func (l *logger) log(ctx context.Context, level zapcore.Level, event log.Event, args []interface{}, fields ...zap.Field) {
+	if !l.Core().Enabled(level) {
+		return
+	}
+
+	contextFields := log.ContextFields(ctx, l.fields)
+	all := make([]zap.Field, 0, len(contextFields)+1+len(fields))
+	for _, field := range contextFields {
+		all = append(all, zap.String(field.Key, field.Value))
+	}
+	all = append(all, zap.String("logid", event.ID))
+	l.Log(level, event.Format(args...), append(all, fields...)...)
+}
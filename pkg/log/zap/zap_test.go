@@ -0,0 +1,52 @@
+package zap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+func TestNewLogger_InvalidConfig(t *testing.T) {
+	_, err := NewLogger(log.Config{Level: "loud"})
+	assert.Error(t, err)
+
+	_, err = NewLogger(log.Config{Format: "xml"})
+	assert.Error(t, err)
+}
+
+func TestLogger_DefaultFields(t *testing.T) {
+	core, logs := observer.New(zapcore.WarnLevel)
+	l, err := NewLogger(log.Config{Level: log.LevelWarn, Fields: requestFields}, zap.WrapCore(func(zapcore.Core) zapcore.Core { return core }))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	l.RequestFinished(ctx, log.Access{Method: "GET", Path: "/gophers", Status: 200, Duration: time.Millisecond})
+	l.InvalidRequest(ctx, errors.New("malformed body"))
+
+	require.Equal(t, 1, logs.Len(), "only the warning is expected to be logged")
+	entry := logs.All()[0]
+	assert.Equal(t, zapcore.WarnLevel, entry.Level)
+	assert.Equal(t, "Invalid request: malformed body", entry.Message)
+
+	fields := entry.ContextMap()
+	assert.Equal(t, log.InvalidRequestMessage.ID, fields["logid"])
+	for _, field := range []string{"serverid", "requestid"} {
+		assert.Contains(t, fields, field)
+	}
+}
+
+var requestFields = []log.FieldsFunc{
+	func(context.Context) []log.Field { return []log.Field{{Key: "serverid", Value: "gopherapi"}} },
+	func(context.Context) []log.Field {
+		return []log.Field{{Key: "requestid", Value: "01D3XZ3ZHCP3KG9VT4FGAD8KDR"}}
+	},
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "gopherapi"
+
+// HTTP records the rate, errors and duration of the requests served
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	panics   *prometheus.CounterVec
+}
+
+// NewHTTP creates the HTTP metrics and registers them with the given registerer
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	return &HTTP{
+		requests: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests served by route, method and status.",
+		}, []string{"route", "method", "status"})).(*prometheus.CounterVec),
+		duration: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the HTTP requests by route, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"})).(*prometheus.HistogramVec),
+		panics: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "panics_total",
+			Help:      "Number of panics recovered while serving HTTP requests by route.",
+		}, []string{"route"})).(*prometheus.CounterVec),
+	}
+}
+
+// Observe records a request served on the given route template
+func (m *HTTP) Observe(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(route, method, code).Inc()
+	m.duration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// Panicked records a panic recovered while serving a request on the given route template
+func (m *HTTP) Panicked(route string) {
+	m.panics.WithLabelValues(route).Inc()
+}
+
+// GRPC records the panics recovered while serving the gRPC calls
+type GRPC struct {
+	panics *prometheus.CounterVec
+}
+
+// NewGRPC creates the gRPC metrics and registers them with the given registerer
+func NewGRPC(reg prometheus.Registerer) *GRPC {
+	return &GRPC{
+		panics: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc",
+			Name:      "panics_total",
+			Help:      "Number of panics recovered while serving gRPC calls by method.",
+		}, []string{"method"})).(*prometheus.CounterVec),
+	}
+}
+
+// Panicked records a panic recovered while serving a call of the given method
+func (m *GRPC) Panicked(method string) {
+	m.panics.WithLabelValues(method).Inc()
+}
+
+// register registers the collector, returning the one already registered when
+// it was previously created, e.g. by another server sharing the registry
+func register(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
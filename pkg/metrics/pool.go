@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type redisPoolCollector struct {
+	pool   *redis.Pool
+	active *prometheus.Desc
+	idle   *prometheus.Desc
+}
+
+// NewRedisPoolCollector exposes the active and idle connections of a redis pool,
+// use prometheus/collectors.NewDBStatsCollector for the SQL ones
+func NewRedisPoolCollector(pool *redis.Pool, name string) prometheus.Collector {
+	labels := prometheus.Labels{"pool": name}
+	return &redisPoolCollector{
+		pool: pool,
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "redis_pool", "active_connections"),
+			"Number of connections of the redis pool, in use or idle.",
+			nil, labels,
+		),
+		idle: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "redis_pool", "idle_connections"),
+			"Number of idle connections of the redis pool.",
+			nil, labels,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.active
+	ch <- c.idle
+}
+
+// Collect implements prometheus.Collector
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, float64(stats.ActiveCount))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.IdleCount))
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type repository struct {
+	next     gopher.Repository
+	backend  string
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewRepository wraps a gopher repository recording the latency and errors of each operation,
+// labelled with the given backend name
+func NewRepository(next gopher.Repository, backend string, reg prometheus.Registerer) gopher.Repository {
+	return &repository{
+		next:    next,
+		backend: backend,
+		duration: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of the gopher repository operations by backend and operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"backend", "operation"})).(*prometheus.HistogramVec),
+		errors: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "repository",
+			Name:      "operation_errors_total",
+			Help:      "Number of gopher repository operations failed unexpectedly by backend and operation.",
+		}, []string{"backend", "operation"})).(*prometheus.CounterVec),
+	}
+}
+
+// CreateGopher saves a given gopher
+func (r *repository) CreateGopher(ctx context.Context, g *gopher.Gopher) error {
+	start := time.Now()
+	err := r.next.CreateGopher(ctx, g)
+	r.record("CreateGopher", start, err)
+	return err
+}
+
+// FetchGophers return all gophers saved in storage
+func (r *repository) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	start := time.Now()
+	gophers, err := r.next.FetchGophers(ctx)
+	r.record("FetchGophers", start, err)
+	return gophers, err
+}
+
+// DeleteGopher remove gopher with given ID
+func (r *repository) DeleteGopher(ctx context.Context, ID string) error {
+	start := time.Now()
+	err := r.next.DeleteGopher(ctx, ID)
+	r.record("DeleteGopher", start, err)
+	return err
+}
+
+// UpdateGopher modify gopher with given ID and given new data
+func (r *repository) UpdateGopher(ctx context.Context, ID string, g gopher.Gopher) error {
+	start := time.Now()
+	err := r.next.UpdateGopher(ctx, ID, g)
+	r.record("UpdateGopher", start, err)
+	return err
+}
+
+// FetchGopherByID returns the gopher with given ID
+func (r *repository) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	start := time.Now()
+	g, err := r.next.FetchGopherByID(ctx, ID)
+	r.record("FetchGopherByID", start, err)
+	return g, err
+}
+
+// FetchGopherRevisions returns all revisions of the gopher with given ID when the wrapped repository keeps them
+func (r *repository) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	revisions, ok := r.next.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+	start := time.Now()
+	result, err := revisions.FetchGopherRevisions(ctx, ID)
+	r.record("FetchGopherRevisions", start, err)
+	return result, err
+}
+
+// FetchGopherAsOf returns the gopher with given ID as it was at the given moment when the wrapped repository keeps revisions
+func (r *repository) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	revisions, ok := r.next.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+	start := time.Now()
+	g, err := revisions.FetchGopherAsOf(ctx, ID, at)
+	r.record("FetchGopherAsOf", start, err)
+	return g, err
+}
+
+// StreamGophers calls fn with each gopher saved in storage, streamed when the wrapped repository is able to
+func (r *repository) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	start := time.Now()
+	err := gopher.StreamGophers(ctx, r.next, fn)
+	r.record("StreamGophers", start, err)
+	return err
+}
+
+// FetchGophersByIDs returns the gophers with the given IDs, looked up together when the wrapped repository is able to
+func (r *repository) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	start := time.Now()
+	gophers, err := gopher.FetchGophersByIDs(ctx, r.next, IDs)
+	r.record("FetchGophersByIDs", start, err)
+	return gophers, err
+}
+
+// record observes the latency of the operation, counting its error only when it is unexpected,
+// the gophers not found or already existing are answers of the storage, not failures
+func (r *repository) record(operation string, start time.Time, err error) {
+	r.duration.WithLabelValues(r.backend, operation).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, gopher.ErrNotFound) && !errors.Is(err, gopher.ErrAlreadyExists) {
+		r.errors.WithLabelValues(r.backend, operation).Inc()
+	}
+}
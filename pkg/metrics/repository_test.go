@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/health"
+)
+
+type failingRepository struct {
+	gopher.Repository
+}
+
+func (failingRepository) FetchGopherByID(context.Context, string) (*gopher.Gopher, error) {
+	return nil, errors.New("connection refused")
+}
+
+type missingRepository struct {
+	gopher.Repository
+}
+
+func (missingRepository) FetchGopherByID(_ context.Context, ID string) (*gopher.Gopher, error) {
+	return nil, fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+}
+
+func (missingRepository) CreateGopher(_ context.Context, g *gopher.Gopher) error {
+	return fmt.Errorf("%w: %s", gopher.ErrAlreadyExists, g.ID)
+}
+
+func Test_Repository_RecordsOperations(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	repo := NewRepository(failingRepository{}, "mysql", reg)
+
+	// WHEN the wrapped repository fails
+	_, err := repo.FetchGopherByID(context.Background(), "123ABC")
+	assert.Error(t, err)
+
+	// THEN the latency and the error are recorded for the backend and operation
+	expected := `
+# HELP gopherapi_repository_operation_errors_total Number of gopher repository operations failed unexpectedly by backend and operation.
+# TYPE gopherapi_repository_operation_errors_total counter
+gopherapi_repository_operation_errors_total{backend="mysql",operation="FetchGopherByID"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "gopherapi_repository_operation_errors_total"))
+
+	count, err := testutil.GatherAndCount(reg, "gopherapi_repository_operation_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func Test_Repository_IgnoresExpectedErrors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	repo := NewRepository(missingRepository{}, "mysql", reg)
+
+	// WHEN the wrapped repository doesn't find a gopher or already has it
+	_, err := repo.FetchGopherByID(context.Background(), "123ABC")
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+	err = repo.CreateGopher(context.Background(), gopher.New("123ABC", "Jenny", "", 18))
+	assert.ErrorIs(t, err, gopher.ErrAlreadyExists)
+
+	// THEN the latencies are recorded but no error is counted
+	count, err := testutil.GatherAndCount(reg, "gopherapi_repository_operation_errors_total")
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	count, err = testutil.GatherAndCount(reg, "gopherapi_repository_operation_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func Test_Repository_ForwardsOptionalInterfaces(t *testing.T) {
+	repo := NewRepository(failingRepository{}, "redis", prometheus.NewRegistry())
+
+	revisions, ok := repo.(gopher.RevisionRepository)
+	assert.True(t, ok)
+
+	_, err := revisions.FetchGopherRevisions(context.Background(), "123ABC")
+	assert.Equal(t, gopher.ErrRevisionsNotSupported, err)
+
+	// the health is checked on the storage, not through its decorators
+	_, ok = repo.(health.Checker)
+	assert.False(t, ok)
+}
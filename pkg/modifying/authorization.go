@@ -0,0 +1,33 @@
+package modifying
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps a modifying service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// ModifyGopher modify a gopher data if the caller has write permission
+func (s *authorizingService) ModifyGopher(ctx context.Context, ID, name, image string, age int) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionWrite); err != nil {
+		return err
+	}
+	return s.next.ModifyGopher(ctx, ID, name, image, age)
+}
+
+// RollbackGopher restores a gopher revision if the caller has admin permission
+func (s *authorizingService) RollbackGopher(ctx context.Context, ID string, revision int) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionAdmin); err != nil {
+		return err
+	}
+	return s.next.RollbackGopher(ctx, ID, revision)
+}
@@ -2,6 +2,8 @@ package modifying
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	gopher "github.com/friendsofgo/gopherapi/pkg"
 )
@@ -9,6 +11,7 @@ import (
 // Service provides modifying operations.
 type Service interface {
 	ModifyGopher(ctx context.Context, ID, name, image string, age int) error
+	RollbackGopher(ctx context.Context, ID string, revision int) error
 }
 
 type service struct {
@@ -23,5 +26,36 @@ func NewService(repository gopher.Repository) Service {
 // ModifyGopher modify a gopher data
 func (s *service) ModifyGopher(ctx context.Context, ID, name, image string, age int) error {
 	g := gopher.New(ID, name, image, age)
+	now := time.Now()
+	g.UpdatedAt = &now
 	return s.repository.UpdateGopher(ctx, ID, *g)
 }
+
+// RollbackGopher restores the gopher data stored in the given revision, or removes the gopher
+// again if the revision removed it, the rollback itself is stored as a new revision so the
+// history is never rewritten
+func (s *service) RollbackGopher(ctx context.Context, ID string, revision int) error {
+	repository, ok := s.repository.(gopher.RevisionRepository)
+	if !ok {
+		return gopher.ErrRevisionsNotSupported
+	}
+
+	revisions, err := repository.FetchGopherRevisions(ctx, ID)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range revisions {
+		if r.Number == revision {
+			if r.Deleted {
+				return s.repository.DeleteGopher(ctx, ID)
+			}
+			g := r.Gopher
+			now := time.Now()
+			g.UpdatedAt = &now
+			return s.repository.UpdateGopher(ctx, ID, g)
+		}
+	}
+
+	return fmt.Errorf("%w: revision %d of gopher %s", gopher.ErrNotFound, revision, ID)
+}
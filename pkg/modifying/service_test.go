@@ -0,0 +1,79 @@
+package modifying
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+)
+
+const jennyID = "01D3XZ3ZHCP3KG9VT4FGAD8KDR"
+
+func Test_Service_RollbackGopher(t *testing.T) {
+	// GIVEN a gopher modified after it was created
+	ctx := context.Background()
+	repo := inmem.NewRepository(map[string]gopher.Gopher{jennyID: *gopher.New(jennyID, "Jenny", "jenny.png", 18)})
+	s := NewService(repo)
+	require.NoError(t, s.ModifyGopher(ctx, jennyID, "Jennifer", "jennifer.png", 19))
+
+	// WHEN it is rolled back to the revision it was created with
+	err := s.RollbackGopher(ctx, jennyID, 1)
+
+	// THEN it has the data of that revision, stored as a new revision updated now
+	require.NoError(t, err)
+	g, err := repo.FetchGopherByID(ctx, jennyID)
+	require.NoError(t, err)
+	assert.Equal(t, "Jenny", g.Name)
+	assert.Equal(t, "jenny.png", g.Image)
+	assert.Equal(t, 18, g.Age)
+	assert.NotNil(t, g.UpdatedAt)
+
+	revisions, err := repo.(gopher.RevisionRepository).FetchGopherRevisions(ctx, jennyID)
+	require.NoError(t, err)
+	assert.Len(t, revisions, 3)
+}
+
+func Test_Service_RollbackGopher_NotFound(t *testing.T) {
+	testData := []struct {
+		name     string
+		ID       string
+		revision int
+	}{
+		{name: "unknown revision", ID: jennyID, revision: 7},
+		{name: "unknown gopher", ID: "01DCBP0R0MSNZY975ZQF1DCQCH", revision: 1},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a gopher with a single revision
+			repo := inmem.NewRepository(map[string]gopher.Gopher{jennyID: *gopher.New(jennyID, "Jenny", "", 18)})
+			s := NewService(repo)
+
+			// WHEN a revision that doesn't exist is rolled back to
+			err := s.RollbackGopher(context.Background(), tt.ID, tt.revision)
+
+			// THEN it is not found
+			assert.ErrorIs(t, err, gopher.ErrNotFound)
+		})
+	}
+}
+
+func Test_Service_RollbackGopher_Deletion(t *testing.T) {
+	// GIVEN a gopher removed and then created again
+	ctx := context.Background()
+	repo := inmem.NewRepository(map[string]gopher.Gopher{jennyID: *gopher.New(jennyID, "Jenny", "", 18)})
+	require.NoError(t, repo.DeleteGopher(ctx, jennyID))
+	require.NoError(t, repo.CreateGopher(ctx, gopher.New(jennyID, "Jennifer", "", 19)))
+
+	// WHEN it is rolled back to the revision that removed it
+	err := NewService(repo).RollbackGopher(ctx, jennyID, 2)
+
+	// THEN it is removed again
+	require.NoError(t, err)
+	_, err = repo.FetchGopherByID(ctx, jennyID)
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+}
@@ -0,0 +1,45 @@
+package modifying
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps a modifying service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// ModifyGopher modifies the given gopher within a span
+func (s *tracingService) ModifyGopher(ctx context.Context, ID, name, image string, age int) error {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "modifying.ModifyGopher")
+	span.Tag("gopher.id", ID)
+	defer span.Finish()
+
+	err := s.next.ModifyGopher(ctx, ID, name, image, age)
+	if err != nil {
+		span.SetError(err)
+	}
+	return err
+}
+
+// RollbackGopher restores the given revision of a gopher within a span
+func (s *tracingService) RollbackGopher(ctx context.Context, ID string, revision int) error {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "modifying.RollbackGopher")
+	span.Tag("gopher.id", ID)
+	span.Tag("gopher.revision", strconv.Itoa(revision))
+	defer span.Finish()
+
+	err := s.next.RollbackGopher(ctx, ID, revision)
+	if err != nil {
+		span.SetError(err)
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit defines a token bucket refilled with Rate tokens per second up to Burst tokens
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit parses a limit written as "rate:burst", e.g. "0.5:10"
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("invalid limit %q, expected rate:burst", s)
+	}
+
+	rate, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || rate <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate in limit %q", s)
+	}
+	burst, err := strconv.Atoi(parts[1])
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid burst in limit %q", s)
+	}
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// Result is the state of a bucket after taking a token from it
+type Result struct {
+	// Allowed reports whether a token was available
+	Allowed bool
+	// Remaining tokens in the bucket
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token is available, only set when not allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets of the clients
+type Store interface {
+	// Take takes a token from the bucket identified by key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// IPKey is the key of the bucket shared by all the requests of the given client IP,
+// whatever the api they are sent to
+func IPKey(clientIP string) string {
+	return "ip:" + clientIP
+}
+
+// NewResult computes the result of taking a token from a bucket left with the given tokens
+func NewResult(allowed bool, tokens float64, limit Limit) Result {
+	r := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// evictEvery is how often the memory store forgets the buckets already full again
+const evictEvery = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket is full again, after which it behaves as a new one
+	full time.Time
+}
+
+type memoryStore struct {
+	mtx       sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastEvict time.Time
+}
+
+// NewMemoryStore creates a store keeping the buckets in memory, only suitable
+// for single instance deployments as every instance counts its own requests
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: make(map[string]*bucket), now: time.Now}
+}
+
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := s.now()
+	s.evict(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	result := NewResult(allowed, b.tokens, limit)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+// evict forgets the buckets already full again, by the limit each one was taken with,
+// at most once every evictEvery so the scan is not paid by every request
+func (s *memoryStore) evict(now time.Time) {
+	if now.Sub(s.lastEvict) < evictEvery {
+		return
+	}
+	s.lastEvict = now
+	for key, b := range s.buckets {
+		if now.After(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
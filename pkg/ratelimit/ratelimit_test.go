@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MemoryStore_Take(t *testing.T) {
+	now := time.Now()
+	s := &memoryStore{buckets: make(map[string]*bucket), now: func() time.Time { return now }}
+	limit := Limit{Rate: 1, Burst: 2}
+
+	// GIVEN a full bucket, the burst is allowed
+	for i := 1; i >= 0; i-- {
+		r, err := s.Take(context.Background(), "client", limit)
+		assert.NoError(t, err)
+		assert.True(t, r.Allowed)
+		assert.Equal(t, i, r.Remaining)
+	}
+
+	// WHEN the bucket is empty THEN the request is rejected until a token is refilled
+	r, err := s.Take(context.Background(), "client", limit)
+	assert.NoError(t, err)
+	assert.False(t, r.Allowed)
+	assert.Equal(t, time.Second, r.RetryAfter)
+
+	// AND other clients have their own bucket
+	r, err = s.Take(context.Background(), "other", limit)
+	assert.NoError(t, err)
+	assert.True(t, r.Allowed)
+
+	now = now.Add(time.Second)
+	r, err = s.Take(context.Background(), "client", limit)
+	assert.NoError(t, err)
+	assert.True(t, r.Allowed)
+}
+
+func Test_MemoryStore_Evict(t *testing.T) {
+	now := time.Now()
+	s := &memoryStore{buckets: make(map[string]*bucket), now: func() time.Time { return now }, lastEvict: now}
+
+	// GIVEN a bucket refilled in a second and another one refilled in ten minutes
+	_, err := s.Take(context.Background(), "fast", Limit{Rate: 1, Burst: 1})
+	assert.NoError(t, err)
+	_, err = s.Take(context.Background(), "slow", Limit{Rate: 1.0 / 600, Burst: 1})
+	assert.NoError(t, err)
+
+	// WHEN the buckets are evicted
+	now = now.Add(evictEvery)
+	_, err = s.Take(context.Background(), "other", Limit{Rate: 1, Burst: 1})
+	assert.NoError(t, err)
+
+	// THEN only the bucket already full again is forgotten, by the limit it was taken with
+	assert.NotContains(t, s.buckets, "fast")
+	assert.Contains(t, s.buckets, "slow")
+}
+
+func Test_ParseLimit(t *testing.T) {
+	l, err := ParseLimit("0.5:10")
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 0.5, Burst: 10}, l)
+
+	for _, invalid := range []string{"", "10", "a:1", "1:b", "0:1", "1:0"} {
+		_, err := ParseLimit(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
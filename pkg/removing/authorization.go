@@ -0,0 +1,25 @@
+package removing
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps a removing service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// RemoveGopher remove gopher from the storage if the caller has admin permission
+func (s *authorizingService) RemoveGopher(ctx context.Context, ID string) error {
+	if err := s.policy.Authorize(ctx, auth.PermissionAdmin); err != nil {
+		return err
+	}
+	return s.next.RemoveGopher(ctx, ID)
+}
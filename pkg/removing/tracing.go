@@ -0,0 +1,30 @@
+package removing
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps a removing service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// RemoveGopher remove gopher from the storage within a span
+func (s *tracingService) RemoveGopher(ctx context.Context, ID string) error {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "removing.RemoveGopher")
+	span.Tag("gopher.id", ID)
+	defer span.Finish()
+
+	err := s.next.RemoveGopher(ctx, ID)
+	if err != nil {
+		span.SetError(err)
+	}
+	return err
+}
@@ -0,0 +1,25 @@
+package searching
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps a searching service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// SearchGophers searches the gophers if the caller has read permission
+func (s *authorizingService) SearchGophers(ctx context.Context, query string, limit int) ([]Result, error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		return nil, err
+	}
+	return s.next.SearchGophers(ctx, query, limit)
+}
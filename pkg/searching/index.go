@@ -0,0 +1,268 @@
+package searching
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+// Index finds the gophers of the tenant in the context matching a query
+type Index interface {
+	// Index adds the given gopher to the index, or replaces it
+	Index(ctx context.Context, g gopher.Gopher)
+	// Remove removes the gopher with the given ID from the index
+	Remove(ctx context.Context, ID string)
+	// Search returns the gophers matching the query, best first, up to the given limit
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+type memoryIndex struct {
+	source gopher.Repository
+
+	// mtx only guards the tenants, each tenant index has its own lock
+	mtx     sync.Mutex
+	tenants map[string]*tenantIndex
+}
+
+// tenantIndex is the inverted index of the gophers of a tenant
+type tenantIndex struct {
+	mtx sync.RWMutex
+	// loaded is false until the gophers of the tenant are read, and forever if they couldn't be
+	loaded bool
+
+	gophers map[string]gopher.Gopher
+	// postings are the IDs of the gophers containing each term
+	postings map[string]map[string]struct{}
+	// terms are the terms of the postings sorted, to look up the ones starting with a prefix
+	terms []string
+	// trigrams are the terms containing each trigram, the candidates of the fuzzy matches
+	trigrams map[string]map[string]struct{}
+}
+
+// NewMemoryIndex creates an in-process inverted index of the gophers of the given repository,
+// the gophers of each tenant are read the first time it searches, and it only sees the changes
+// made afterwards through this process, so it suits the storages no other process writes to,
+// like inmem, a storage shared by several instances, like redis, would leave it stale
+func NewMemoryIndex(source gopher.Repository) Index {
+	return &memoryIndex{source: source, tenants: make(map[string]*tenantIndex)}
+}
+
+// Index adds the given gopher to the index of its tenant, when it is already loaded
+func (i *memoryIndex) Index(ctx context.Context, g gopher.Gopher) {
+	t, ok := i.tenant(ctx)
+	if !ok {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	// the updates don't carry when the gopher was created
+	if indexed, ok := t.gophers[g.ID]; ok && g.CreatedAt == nil {
+		g.CreatedAt = indexed.CreatedAt
+	}
+	t.remove(g.ID)
+	t.add(g)
+}
+
+// Remove removes the gopher with the given ID from the index of its tenant
+func (i *memoryIndex) Remove(ctx context.Context, ID string) {
+	t, ok := i.tenant(ctx)
+	if !ok {
+		return
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.remove(ID)
+}
+
+// Search looks up the terms of the query, the terms they start and the ones sharing
+// a trigram with them in the postings, ranking the gophers containing any of them
+func (i *memoryIndex) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	t, err := i.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mtx.RLock()
+	candidates := make(map[string]struct{})
+	for _, term := range Terms(query) {
+		for _, indexed := range t.candidateTerms(term) {
+			if match(term, indexed) == 0 {
+				continue
+			}
+			for ID := range t.postings[indexed] {
+				candidates[ID] = struct{}{}
+			}
+		}
+	}
+
+	gophers := make([]gopher.Gopher, 0, len(candidates))
+	for ID := range candidates {
+		gophers = append(gophers, t.gophers[ID])
+	}
+	t.mtx.RUnlock()
+
+	return Rank(query, gophers, limit), nil
+}
+
+// tenant returns the index of the tenant in the context, if it was ever searched
+func (i *memoryIndex) tenant(ctx context.Context) (*tenantIndex, bool) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	t, ok := i.tenants[tenant.ID(ctx)]
+	return t, ok
+}
+
+// load returns the index of the tenant in the context, reading its gophers the first time,
+// the index is registered before reading them, so the changes made meanwhile wait for the
+// read to finish instead of being missed, without blocking the other tenants
+func (i *memoryIndex) load(ctx context.Context) (*tenantIndex, error) {
+	tenantID := tenant.ID(ctx)
+	for {
+		i.mtx.Lock()
+		t, ok := i.tenants[tenantID]
+		if !ok {
+			t = &tenantIndex{
+				gophers:  make(map[string]gopher.Gopher),
+				postings: make(map[string]map[string]struct{}),
+				trigrams: make(map[string]map[string]struct{}),
+			}
+			t.mtx.Lock()
+			i.tenants[tenantID] = t
+		}
+		i.mtx.Unlock()
+
+		if !ok {
+			return t, i.fill(ctx, tenantID, t)
+		}
+
+		t.mtx.RLock()
+		loaded := t.loaded
+		t.mtx.RUnlock()
+		if loaded {
+			return t, nil
+		}
+		// the gophers couldn't be read by the search loading it, this one tries again
+	}
+}
+
+// fill reads the gophers of the tenant into its index, which must be locked, and forgets
+// the index when they can't be read so the next search tries again
+func (i *memoryIndex) fill(ctx context.Context, tenantID string, t *tenantIndex) error {
+	defer t.mtx.Unlock()
+
+	err := gopher.StreamGophers(ctx, i.source, func(g gopher.Gopher) error {
+		t.add(g)
+		return nil
+	})
+	if err != nil {
+		i.mtx.Lock()
+		delete(i.tenants, tenantID)
+		i.mtx.Unlock()
+		return err
+	}
+
+	t.loaded = true
+	return nil
+}
+
+// candidateTerms returns the indexed terms which may match the given one: itself, the ones
+// it starts and, when it's long enough to have typos, the ones sharing a trigram with it
+func (t *tenantIndex) candidateTerms(term string) []string {
+	var candidates []string
+	if _, ok := t.postings[term]; ok {
+		candidates = append(candidates, term)
+	}
+
+	if utf8.RuneCountInString(term) >= minPrefixLen {
+		for n := sort.SearchStrings(t.terms, term); n < len(t.terms) && strings.HasPrefix(t.terms[n], term); n++ {
+			if t.terms[n] != term {
+				candidates = append(candidates, t.terms[n])
+			}
+		}
+	}
+
+	if maxEdits(utf8.RuneCountInString(term)) > 0 {
+		seen := make(map[string]bool, len(candidates))
+		for _, c := range candidates {
+			seen[c] = true
+		}
+		for _, trigram := range Trigrams(term) {
+			for indexed := range t.trigrams[trigram] {
+				if !seen[indexed] {
+					seen[indexed] = true
+					candidates = append(candidates, indexed)
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+func (t *tenantIndex) add(g gopher.Gopher) {
+	t.gophers[g.ID] = g
+	for _, term := range Terms(g.Name) {
+		IDs, ok := t.postings[term]
+		if !ok {
+			IDs = make(map[string]struct{})
+			t.postings[term] = IDs
+			t.addTerm(term)
+		}
+		IDs[g.ID] = struct{}{}
+	}
+}
+
+func (t *tenantIndex) remove(ID string) {
+	g, ok := t.gophers[ID]
+	if !ok {
+		return
+	}
+
+	delete(t.gophers, ID)
+	for _, term := range Terms(g.Name) {
+		delete(t.postings[term], ID)
+		if len(t.postings[term]) == 0 {
+			delete(t.postings, term)
+			t.removeTerm(term)
+		}
+	}
+}
+
+// addTerm adds a term new to the postings to the sorted terms and the trigrams
+func (t *tenantIndex) addTerm(term string) {
+	n := sort.SearchStrings(t.terms, term)
+	t.terms = append(t.terms, "")
+	copy(t.terms[n+1:], t.terms[n:])
+	t.terms[n] = term
+
+	for _, trigram := range Trigrams(term) {
+		terms, ok := t.trigrams[trigram]
+		if !ok {
+			terms = make(map[string]struct{})
+			t.trigrams[trigram] = terms
+		}
+		terms[term] = struct{}{}
+	}
+}
+
+// removeTerm removes a term gone from the postings from the sorted terms and the trigrams
+func (t *tenantIndex) removeTerm(term string) {
+	if n := sort.SearchStrings(t.terms, term); n < len(t.terms) && t.terms[n] == term {
+		t.terms = append(t.terms[:n], t.terms[n+1:]...)
+	}
+
+	for _, trigram := range Trigrams(term) {
+		delete(t.trigrams[trigram], term)
+		if len(t.trigrams[trigram]) == 0 {
+			delete(t.trigrams, trigram)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package searching
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// Declare the scores of each kind of match, the exact ones rank first
+const (
+	scoreExact       = 1.0
+	scorePrefix      = 0.75
+	scoreFuzzy       = 0.5
+	scoreFuzzyPrefix = 0.35
+)
+
+// minPrefixLen is the length from which the query terms match the words they start
+const minPrefixLen = 2
+
+// Result is a gopher matching a search
+type Result struct {
+	Gopher gopher.Gopher
+	// Score ranks the results, the higher the better
+	Score float64
+	// Highlights are the HTML fragments of the fields matched, by field name,
+	// with the words matched wrapped in <em>
+	Highlights map[string]string
+}
+
+// Terms returns the normalized words of the given text, in order and without duplicates
+func Terms(text string) []string {
+	var terms []string
+	seen := make(map[string]bool)
+	for _, w := range words(text) {
+		if !seen[w.term] {
+			seen[w.term] = true
+			terms = append(terms, w.term)
+		}
+	}
+	return terms
+}
+
+// Trigrams returns the sequences of three letters of the given term, the ones
+// a term with a typo still shares with the word it was meant to be
+func Trigrams(term string) []string {
+	r := []rune(term)
+	if len(r) <= 3 {
+		return []string{term}
+	}
+
+	trigrams := make([]string, 0, len(r)-2)
+	for i := 0; i+3 <= len(r); i++ {
+		trigrams = append(trigrams, string(r[i:i+3]))
+	}
+	return trigrams
+}
+
+// Rank scores the given gophers against the query, returning the ones matching it
+// best first, up to the given limit
+func Rank(query string, gophers []gopher.Gopher, limit int) []Result {
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var results []Result
+	for _, g := range gophers {
+		ws := words(g.Name)
+		matched := make([]bool, len(ws))
+
+		var score float64
+		for _, term := range terms {
+			best, bestWord := 0.0, -1
+			for i, w := range ws {
+				if s := match(term, w.term); s > best {
+					best, bestWord = s, i
+				}
+			}
+			if bestWord >= 0 {
+				score += best
+				matched[bestWord] = true
+			}
+		}
+		if score == 0 {
+			continue
+		}
+
+		results = append(results, Result{
+			Gopher:     g,
+			Score:      score,
+			Highlights: map[string]string{"name": highlight(g.Name, ws, matched)},
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Gopher.Name != results[j].Gopher.Name {
+			return results[i].Gopher.Name < results[j].Gopher.Name
+		}
+		return results[i].Gopher.ID < results[j].Gopher.ID
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// match scores how well the query term matches the given word, 0 when it doesn't
+func match(term, word string) float64 {
+	if term == word {
+		return scoreExact
+	}
+
+	termLen, wordLen := utf8.RuneCountInString(term), utf8.RuneCountInString(word)
+	if termLen >= minPrefixLen && strings.HasPrefix(word, term) {
+		// the longer part of the word typed, the closer to an exact match
+		return scorePrefix + (scoreExact-scorePrefix)*float64(termLen)/float64(wordLen+1)
+	}
+
+	edits := maxEdits(termLen)
+	if edits == 0 {
+		return 0
+	}
+	if d := distance(term, word); d <= edits {
+		return scoreFuzzy * (1 - float64(d)/float64(termLen+1))
+	}
+	if wordLen > termLen {
+		if d := distance(term, string([]rune(word)[:termLen])); d <= edits {
+			return scoreFuzzyPrefix * (1 - float64(d)/float64(termLen+1))
+		}
+	}
+	return 0
+}
+
+// maxEdits is the number of typos tolerated in a term of the given length,
+// the shorter terms would match too many words otherwise
+func maxEdits(termLen int) int {
+	switch {
+	case termLen < 4:
+		return 0
+	case termLen < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance returns the Levenshtein distance between the given terms, where
+// swapping two adjacent letters is a single edit
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// word is a normalized word of a text, with its position in the original text
+type word struct {
+	term       string
+	start, end int
+}
+
+// words splits the given text in its words of letters and digits
+func words(text string) []word {
+	var ws []word
+	start := -1
+	for i, r := range text {
+		alnum := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		switch {
+		case alnum && start < 0:
+			start = i
+		case !alnum && start >= 0:
+			ws = append(ws, word{term: normalize(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		ws = append(ws, word{term: normalize(text[start:]), start: start, end: len(text)})
+	}
+	return ws
+}
+
+// normalize lowercases the given text and strips its accents
+func normalize(text string) string {
+	// the transformers keep state, so they can't be shared between goroutines
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.ToLower(folded)
+}
+
+// highlight wraps the matched words of the given text in <em>, escaping the rest
+func highlight(text string, ws []word, matched []bool) string {
+	var b strings.Builder
+	last := 0
+	for i, w := range ws {
+		if !matched[i] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:w.start]))
+		b.WriteString("<em>")
+		b.WriteString(html.EscapeString(text[w.start:w.end]))
+		b.WriteString("</em>")
+		last = w.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
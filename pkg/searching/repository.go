@@ -0,0 +1,84 @@
+package searching
+
+import (
+	"context"
+	"time"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+type repository struct {
+	next  gopher.Repository
+	index Index
+}
+
+// NewRepository wraps a gopher repository keeping the index in sync with the changes saved successfully
+func NewRepository(next gopher.Repository, index Index) gopher.Repository {
+	return &repository{next, index}
+}
+
+// CreateGopher saves a given gopher
+func (r *repository) CreateGopher(ctx context.Context, g *gopher.Gopher) error {
+	if err := r.next.CreateGopher(ctx, g); err != nil {
+		return err
+	}
+	r.index.Index(ctx, *g)
+	return nil
+}
+
+// FetchGophers return all gophers saved in storage
+func (r *repository) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	return r.next.FetchGophers(ctx)
+}
+
+// DeleteGopher remove gopher with given ID
+func (r *repository) DeleteGopher(ctx context.Context, ID string) error {
+	if err := r.next.DeleteGopher(ctx, ID); err != nil {
+		return err
+	}
+	r.index.Remove(ctx, ID)
+	return nil
+}
+
+// UpdateGopher modify gopher with given ID and given new data
+func (r *repository) UpdateGopher(ctx context.Context, ID string, g gopher.Gopher) error {
+	if err := r.next.UpdateGopher(ctx, ID, g); err != nil {
+		return err
+	}
+	g.ID = ID
+	r.index.Index(ctx, g)
+	return nil
+}
+
+// FetchGopherByID returns the gopher with given ID
+func (r *repository) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	return r.next.FetchGopherByID(ctx, ID)
+}
+
+// FetchGopherRevisions returns all revisions of the gopher with given ID when the wrapped repository keeps them
+func (r *repository) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	revisions, ok := r.next.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+	return revisions.FetchGopherRevisions(ctx, ID)
+}
+
+// FetchGopherAsOf returns the gopher with given ID as it was at the given moment when the wrapped repository keeps revisions
+func (r *repository) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	revisions, ok := r.next.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+	return revisions.FetchGopherAsOf(ctx, ID, at)
+}
+
+// StreamGophers calls fn with each gopher saved in storage, streamed when the wrapped repository is able to
+func (r *repository) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	return gopher.StreamGophers(ctx, r.next, fn)
+}
+
+// FetchGophersByIDs returns the gophers with the given IDs, looked up together when the wrapped repository is able to
+func (r *repository) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	return gopher.FetchGophersByIDs(ctx, r.next, IDs)
+}
@@ -0,0 +1,45 @@
+package searching
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxQueryLen is the length of the longest query accepted
+const maxQueryLen = 200
+
+var (
+	// ErrEmptyQuery is returned when the query has no words to look for
+	ErrEmptyQuery = errors.New("the query must contain letters or digits")
+	// ErrQueryTooLong is returned when the query is longer than the accepted
+	ErrQueryTooLong = errors.New("the query can't be longer than 200 characters")
+)
+
+// Service provides searching operations.
+type Service interface {
+	// SearchGophers returns the gophers matching the query, best first, up to the given limit
+	SearchGophers(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+type service struct {
+	index Index
+}
+
+// NewService creates a searching service with the necessary dependencies
+func NewService(index Index) Service {
+	return &service{index}
+}
+
+// SearchGophers returns the gophers matching the query
+func (s *service) SearchGophers(ctx context.Context, query string, limit int) ([]Result, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) > maxQueryLen {
+		return nil, ErrQueryTooLong
+	}
+	if len(Terms(query)) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	return s.index.Search(ctx, query, limit)
+}
@@ -0,0 +1,189 @@
+package searching
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+func Test_Service_SearchGophers(t *testing.T) {
+	testData := []struct {
+		name      string
+		query     string
+		expected  []string
+		highlight string
+	}{
+		{name: "exact", query: "jenny", expected: []string{"Jenny"}, highlight: "<em>Jenny</em>"},
+		{name: "case and accents", query: "EUSTAQUIO", expected: []string{"Eustáquio Pérez"}, highlight: "<em>Eustáquio</em> Pérez"},
+		{name: "accented query", query: "pérez", expected: []string{"Eustáquio Pérez"}, highlight: "Eustáquio <em>Pérez</em>"},
+		{name: "prefix", query: "bil", expected: []string{"Billy", "Billy Bob"}, highlight: "<em>Billy</em>"},
+		{name: "typo", query: "jeny", expected: []string{"Jenny"}, highlight: "<em>Jenny</em>"},
+		{name: "transposed letters", query: "bilyl", expected: []string{"Billy", "Billy Bob"}, highlight: "<em>Billy</em>"},
+		{name: "every word matched ranks first", query: "billy bob", expected: []string{"Billy Bob", "Billy"}, highlight: "<em>Billy</em> <em>Bob</em>"},
+		{name: "nothing close", query: "zorro", expected: nil},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN an index of the gophers of the storage
+			s := NewService(NewMemoryIndex(buildRepository(t)))
+
+			// WHEN the gophers are searched
+			results, err := s.SearchGophers(context.Background(), tt.query, 10)
+			require.NoError(t, err)
+
+			// THEN the ones matching are found best first, with the words matched highlighted
+			var names []string
+			for _, r := range results {
+				names = append(names, r.Gopher.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+			if len(results) > 0 {
+				assert.Equal(t, tt.highlight, results[0].Highlights["name"])
+			}
+		})
+	}
+}
+
+func Test_Service_SearchGophers_Limit(t *testing.T) {
+	s := NewService(NewMemoryIndex(buildRepository(t)))
+
+	results, err := s.SearchGophers(context.Background(), "billy", 1)
+
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func Test_Service_SearchGophers_InvalidQuery(t *testing.T) {
+	s := NewService(NewMemoryIndex(buildRepository(t)))
+
+	_, err := s.SearchGophers(context.Background(), " -- ", 10)
+	assert.ErrorIs(t, err, ErrEmptyQuery)
+
+	_, err = s.SearchGophers(context.Background(), string(make([]byte, maxQueryLen+1)), 10)
+	assert.ErrorIs(t, err, ErrQueryTooLong)
+}
+
+func Test_Repository_KeepsTheIndexInSync(t *testing.T) {
+	// GIVEN an index loaded before the gophers change
+	storage := buildRepository(t)
+	index := NewMemoryIndex(storage)
+	repo := NewRepository(storage, index)
+	s := NewService(index)
+	ctx := context.Background()
+	_, err := s.SearchGophers(ctx, "jenny", 10)
+	require.NoError(t, err)
+
+	// WHEN the gophers are created, updated and deleted
+	require.NoError(t, repo.CreateGopher(ctx, gopher.New("01DCBP0R0MSNZY975ZQF1DCQCH", "Mortadelo", "", 40)))
+	require.NoError(t, repo.UpdateGopher(ctx, "01D3XZ3ZHCP3KG9VT4FGAD8KDR", *gopher.New("", "Filemón", "", 19)))
+	require.NoError(t, repo.DeleteGopher(ctx, "01D3XZ7CN92AKS9HAPSZ4D5DP9"))
+
+	// THEN the searches find them as they are now
+	assertFound(t, s, "mortadelo", "Mortadelo")
+	assertFound(t, s, "filemon", "Filemón")
+	assertFound(t, s, "jenny")
+	assertFound(t, s, "billy", "Billy Bob")
+}
+
+func Test_MemoryIndex_SearchesTheTenant(t *testing.T) {
+	repo := buildRepository(t)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	require.NoError(t, repo.CreateGopher(acme, gopher.New("01DCBP0R0MSNZY975ZQF1DCQCH", "Jenny Acme", "", 40)))
+	s := NewService(NewMemoryIndex(repo))
+
+	results, err := s.SearchGophers(acme, "jenny", 10)
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Jenny Acme", results[0].Gopher.Name)
+}
+
+// flakyRepository fails to stream the gophers the given number of times, and blocks
+// the streams until released when there is a release channel
+type flakyRepository struct {
+	gopher.Repository
+	failures int
+	release  chan struct{}
+}
+
+func (r *flakyRepository) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	if r.release != nil {
+		<-r.release
+	}
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("storage unavailable")
+	}
+	return gopher.StreamGophers(ctx, r.Repository, fn)
+}
+
+func Test_MemoryIndex_RetriesTheLoad(t *testing.T) {
+	// GIVEN an index whose storage fails the first time it is read
+	s := NewService(NewMemoryIndex(&flakyRepository{Repository: buildRepository(t), failures: 1}))
+
+	// WHEN the gophers are searched twice
+	_, err := s.SearchGophers(context.Background(), "jenny", 10)
+	require.Error(t, err)
+
+	// THEN the second search reads them again
+	assertFound(t, s, "jenny", "Jenny")
+}
+
+func Test_MemoryIndex_KeepsTheChangesMadeWhileLoading(t *testing.T) {
+	// GIVEN an index being loaded
+	storage := buildRepository(t)
+	source := &flakyRepository{Repository: storage, release: make(chan struct{})}
+	index := NewMemoryIndex(source)
+	s := NewService(index)
+
+	searched := make(chan error)
+	go func() {
+		_, err := s.SearchGophers(context.Background(), "jenny", 10)
+		searched <- err
+	}()
+
+	// WHEN a gopher is created meanwhile, after the storage was read
+	changed := make(chan error)
+	go func() {
+		// the index is registered before the storage is read, the change must wait for it
+		changed <- NewRepository(storage, index).CreateGopher(context.Background(), gopher.New("01DCBP0R0MSNZY975ZQF1DCQCH", "Mortadelo", "", 40))
+	}()
+	require.NoError(t, <-changed)
+	close(source.release)
+	require.NoError(t, <-searched)
+
+	// THEN it is found
+	assertFound(t, s, "mortadelo", "Mortadelo")
+}
+
+func buildRepository(t *testing.T) gopher.Repository {
+	t.Helper()
+
+	return inmem.NewRepository(map[string]gopher.Gopher{
+		"01D3XZ3ZHCP3KG9VT4FGAD8KDR": *gopher.New("01D3XZ3ZHCP3KG9VT4FGAD8KDR", "Jenny", "", 18),
+		"01D3XZ7CN92AKS9HAPSZ4D5DP9": *gopher.New("01D3XZ7CN92AKS9HAPSZ4D5DP9", "Billy", "", 35),
+		"01D3XZ89NFJZ9QT2DHVD462AC2": *gopher.New("01D3XZ89NFJZ9QT2DHVD462AC2", "Billy Bob", "", 21),
+		"01D3XZ8JXHTDA6XY0EA0TP6ZG9": *gopher.New("01D3XZ8JXHTDA6XY0EA0TP6ZG9", "Eustáquio Pérez", "", 99),
+	})
+}
+
+func assertFound(t *testing.T, s Service, query string, expected ...string) {
+	t.Helper()
+
+	results, err := s.SearchGophers(context.Background(), query, 10)
+	require.NoError(t, err)
+
+	var names []string
+	for _, r := range results {
+		names = append(names, r.Gopher.Name)
+	}
+	assert.Equal(t, expected, names, "searching %q", query)
+}
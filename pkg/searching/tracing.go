@@ -0,0 +1,31 @@
+package searching
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+type tracingService struct {
+	next   Service
+	tracer tracer.Tracer
+}
+
+// NewTracingService wraps a searching service creating a span for each operation
+func NewTracingService(next Service, trc tracer.Tracer) Service {
+	return &tracingService{next, trc}
+}
+
+// SearchGophers searches the gophers within a span, tagging how many were found
+func (s *tracingService) SearchGophers(ctx context.Context, query string, limit int) ([]Result, error) {
+	span, ctx := s.tracer.StartSpanFromContext(ctx, "searching.SearchGophers")
+	defer span.Finish()
+
+	results, err := s.next.SearchGophers(ctx, query, limit)
+	span.Tag("gophers.found", strconv.Itoa(len(results)))
+	if err != nil {
+		span.SetError(err)
+	}
+	return results, err
+}
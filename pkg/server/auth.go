@@ -0,0 +1,39 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+func newAuthMiddleware(authenticators []auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			invalid := false
+			for _, a := range authenticators {
+				p, err := a.Authenticate(r)
+				if err == nil {
+					next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), p)))
+					return
+				}
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					invalid = true
+				}
+			}
+
+			for _, a := range authenticators {
+				challenge := a.Challenge()
+				if invalid {
+					challenge = fmt.Sprintf(`%s, error="invalid_token"`, challenge)
+				}
+				w.Header().Add("WWW-Authenticate", challenge)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode("Unauthorized")
+		})
+	}
+}
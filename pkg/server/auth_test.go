@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	apiKeys, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{{Name: "ci", Hash: auth.HashAPIKey("s3cr3t")}})
+	if err != nil {
+		t.Fatalf("could not create authenticator: %v", err)
+	}
+	jwt := auth.NewJWTAuthenticator(auth.KeySet{}, "", "")
+
+	testData := []struct {
+		name      string
+		header    string
+		value     string
+		status    int
+		challenge string
+	}{
+		{name: "no credentials", status: http.StatusUnauthorized, challenge: `ApiKey realm="gopherapi"`},
+		{name: "invalid api key", header: "X-API-Key", value: "wrong", status: http.StatusUnauthorized, challenge: `ApiKey realm="gopherapi", error="invalid_token"`},
+		{name: "invalid token", header: "Authorization", value: "Bearer a.b.c", status: http.StatusUnauthorized, challenge: `Bearer realm="gopherapi", error="invalid_token"`},
+		{name: "valid api key", header: "X-API-Key", value: "s3cr3t", status: http.StatusOK},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/gophers", nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			s := buildServer(WithAuthenticators(apiKeys, jwt))
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+			if tt.status != res.StatusCode {
+				t.Errorf("expected %d, got: %d", tt.status, res.StatusCode)
+			}
+
+			challenges := strings.Join(res.Header.Values("WWW-Authenticate"), "; ")
+			if tt.challenge != "" && !strings.Contains(challenges, tt.challenge) {
+				t.Errorf("expected challenge %q, got: %q", tt.challenge, challenges)
+			}
+		})
+	}
+}
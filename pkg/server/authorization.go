@@ -0,0 +1,79 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+// Declare the names of the gopher routes, used to look up the permission they require
+const (
+	routeFetchGophers         = "fetchGophers"
+	routeFetchGopher          = "fetchGopher"
+	routeFetchGopherRevisions = "fetchGopherRevisions"
+	routeAddGopher            = "addGopher"
+	routeModifyGopher         = "modifyGopher"
+	routeRemoveGopher         = "removeGopher"
+	routeExportGophers        = "exportGophers"
+	routeImportGophers        = "importGophers"
+	routeFetchImportJob       = "fetchImportJob"
+	routeSearchGophers        = "searchGophers"
+	routeGraphQL              = "graphql"
+)
+
+var routePermissions = map[string]auth.Permission{
+	routeFetchGophers:         auth.PermissionRead,
+	routeFetchGopher:          auth.PermissionRead,
+	routeFetchGopherRevisions: auth.PermissionRead,
+	routeAddGopher:            auth.PermissionWrite,
+	routeModifyGopher:         auth.PermissionWrite,
+	routeRemoveGopher:         auth.PermissionAdmin,
+	routeExportGophers:        auth.PermissionRead,
+	routeImportGophers:        auth.PermissionWrite,
+	routeFetchImportJob:       auth.PermissionRead,
+	routeSearchGophers:        auth.PermissionRead,
+	// the GraphQL mutations are authorized by the services, as the route serves the queries too
+	routeGraphQL: auth.PermissionRead,
+}
+
+func newAuthorizationMiddleware(policy *auth.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			permission := auth.PermissionAdmin
+			if route := mux.CurrentRoute(r); route != nil {
+				if p, ok := routePermissions[route.GetName()]; ok {
+					permission = p
+				}
+			}
+
+			if err := policy.Authorize(r.Context(), permission); err != nil {
+				writeAuthorizationError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeAuthorizationError writes the response for the errors of the auth package, and
+// reports whether the given error was one of them
+func writeAuthorizationError(w http.ResponseWriter, err error) bool {
+	var status int
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		status = http.StatusUnauthorized
+	case errors.Is(err, auth.ErrForbidden):
+		status = http.StatusForbidden
+	default:
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(http.StatusText(status))
+	return true
+}
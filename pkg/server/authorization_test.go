@@ -0,0 +1,62 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	apiKeys, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "nobody", Hash: auth.HashAPIKey("nobody")},
+		{Name: "reader", Hash: auth.HashAPIKey("reader"), Scopes: []string{"gophers:read"}},
+		{Name: "writer", Hash: auth.HashAPIKey("writer"), Roles: []string{"writer"}},
+		{Name: "admin", Hash: auth.HashAPIKey("admin"), Scopes: []string{"gophers:admin"}},
+	})
+	if err != nil {
+		t.Fatalf("could not create authenticator: %v", err)
+	}
+
+	ID := gopherSample().ID
+	body := `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}`
+	routes := []struct {
+		method, uri, body string
+		success           int
+		allowed           map[string]bool
+	}{
+		{http.MethodGet, "/gophers", "", http.StatusOK, map[string]bool{"reader": true, "writer": true, "admin": true}},
+		{http.MethodGet, "/gophers/" + ID, "", http.StatusOK, map[string]bool{"reader": true, "writer": true, "admin": true}},
+		{http.MethodGet, "/gophers/" + ID + "/revisions", "", http.StatusOK, map[string]bool{"reader": true, "writer": true, "admin": true}},
+		{http.MethodPost, "/gophers", body, http.StatusCreated, map[string]bool{"writer": true, "admin": true}},
+		{http.MethodPut, "/gophers/" + ID, body, http.StatusNoContent, map[string]bool{"writer": true, "admin": true}},
+		{http.MethodDelete, "/gophers/" + ID, "", http.StatusNoContent, map[string]bool{"admin": true}},
+	}
+
+	for _, route := range routes {
+		for _, caller := range []string{"nobody", "reader", "writer", "admin"} {
+			t.Run(fmt.Sprintf("%s %s as %s", route.method, route.uri, caller), func(t *testing.T) {
+				req, err := http.NewRequest(route.method, route.uri, bytes.NewBufferString(route.body))
+				if err != nil {
+					t.Fatalf("could not created request: %v", err)
+				}
+				req.Header.Set("X-API-Key", caller)
+
+				s := buildServer(WithAuthenticators(apiKeys), WithPolicy(auth.DefaultPolicy()))
+				rec := httptest.NewRecorder()
+				s.Router().ServeHTTP(rec, req)
+
+				expected := http.StatusForbidden
+				if route.allowed[caller] {
+					expected = route.success
+				}
+				if rec.Code != expected {
+					t.Errorf("expected %d, got: %d", expected, rec.Code)
+				}
+			})
+		}
+	}
+}
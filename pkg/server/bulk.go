@@ -0,0 +1,265 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+	"github.com/friendsofgo/gopherapi/pkg/importing"
+)
+
+const (
+	// defaultMaxImportSize is the size of the largest body imported when the server isn't given one
+	defaultMaxImportSize = 100 << 20
+	// importReadTimeout bounds the upload of the bodies imported, the read timeout of the
+	// server suits the small bodies of the rest of the requests
+	importReadTimeout = 10 * time.Minute
+)
+
+// errReadingBody tells the failures reading the body of an import from those spooling it
+var errReadingBody = errors.New("reading the body")
+
+// exportCodecs are the representations the gophers are exported in, written
+// and read one gopher at a time
+var exportCodecs = encoding.NewRegistry(encoding.NDJSON, encoding.CSV)
+
+// importJob is the representation of an import job
+type importJob struct {
+	ID         string           `json:"id"`
+	Status     string           `json:"status"`
+	Policy     string           `json:"policy"`
+	Processed  int              `json:"processed"`
+	Created    int              `json:"created"`
+	Updated    int              `json:"updated"`
+	Skipped    int              `json:"skipped"`
+	Failed     int              `json:"failed"`
+	Errors     []importRowError `json:"errors"`
+	Error      string           `json:"error,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+type importRowError struct {
+	Row     int    `json:"row"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+func newImportJob(job importing.Job) importJob {
+	errs := make([]importRowError, 0, len(job.Errors))
+	for _, err := range job.Errors {
+		errs = append(errs, importRowError{Row: err.Row, ID: err.ID, Message: err.Message})
+	}
+
+	return importJob{
+		ID:         job.ID,
+		Status:     string(job.Status),
+		Policy:     string(job.Policy),
+		Processed:  job.Processed,
+		Created:    job.Created,
+		Updated:    job.Updated,
+		Skipped:    job.Skipped,
+		Failed:     job.Failed,
+		Errors:     errs,
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// ExportGophers streams all gophers as NDJSON or CSV, as they are read from the storage
+func (s *server) ExportGophers(w http.ResponseWriter, r *http.Request) {
+	codec, err := negotiateCodec(r, exportCodecs)
+	if err != nil {
+		s.logger.InvalidRequest(r.Context(), err)
+		writeProblem(w, r, http.StatusNotAcceptable, err.Error())
+		return
+	}
+
+	// the export takes as long as the storage takes to read the gophers, the write
+	// timeout of the server would cut it short
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		s.logger.UnexpectedError(r.Context(), fmt.Errorf("lifting the write deadline of the export: %w", err))
+	}
+
+	var (
+		e        encoding.ListEncoder
+		exported int
+	)
+	start := func() {
+		w.Header().Set("Content-Type", codec.MediaType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="gophers.%s"`, codec.Format()))
+		e = codec.NewListEncoder(w)
+	}
+	flusher, _ := w.(http.Flusher)
+
+	err = s.exporting.ExportGophers(r.Context(), func(g gopher.Gopher) error {
+		if e == nil {
+			start()
+		}
+		if err := e.Encode(g); err != nil {
+			return err
+		}
+		if exported++; flusher != nil && exported%flushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil && e == nil {
+		if writeAuthorizationProblem(w, r, err) {
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't export the gophers")
+		return
+	}
+	if err != nil {
+		// the response is already started, the callers only get it cut short
+		s.logger.UnexpectedError(r.Context(), fmt.Errorf("exporting the gophers: %w", err))
+		return
+	}
+
+	if e == nil {
+		start()
+	}
+	_ = e.Close()
+}
+
+// ImportGophers starts importing the gophers of the body, in the representation of its Content-Type,
+// answering with the job to poll its progress
+func (s *server) ImportGophers(w http.ResponseWriter, r *http.Request) {
+	policy := importing.PolicyFail
+	if p := r.URL.Query().Get("policy"); p != "" {
+		policy = importing.Policy(p)
+	}
+	if !policy.Valid() {
+		s.invalidRequestV2(w, r, importing.ErrInvalidPolicy)
+		return
+	}
+
+	codec, ok := s.codecs.ByContentType(r.Header.Get("Content-Type"))
+	if !ok {
+		s.logger.InvalidRequest(r.Context(), fmt.Errorf("unsupported Content-Type %q", r.Header.Get("Content-Type")))
+		writeProblem(w, r, http.StatusUnsupportedMediaType, "the Content-Type of the body is not supported")
+		return
+	}
+
+	// the upload of a large body outlasts the read timeout of the server
+	if err := http.NewResponseController(w).SetReadDeadline(time.Now().Add(importReadTimeout)); err != nil {
+		s.logger.UnexpectedError(r.Context(), fmt.Errorf("extending the read deadline of the import: %w", err))
+	}
+
+	source, err := spool(http.MaxBytesReader(w, r.Body, s.maxImportSize), codec)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		s.logger.InvalidRequest(r.Context(), err)
+		writeProblem(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("the body can't be larger than %d bytes", tooLarge.Limit))
+		return
+	}
+	if errors.Is(err, errReadingBody) {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't read the gophers to import")
+		return
+	}
+
+	job, err := s.importing.ImportGophers(r.Context(), source, policy)
+	if writeAuthorizationProblem(w, r, err) {
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't import the gophers")
+		return
+	}
+
+	w.Header().Set("Location", path.Join(r.URL.Path, job.ID))
+	writeJob(w, http.StatusAccepted, job)
+}
+
+// FetchImportJob return the progress of an import
+func (s *server) FetchImportJob(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["ID"]
+	job, err := s.importing.FetchJob(r.Context(), ID)
+	if writeAuthorizationProblem(w, r, err) {
+		return
+	}
+	if errors.Is(err, importing.ErrJobNotFound) {
+		writeProblem(w, r, http.StatusNotFound, fmt.Sprintf("import job %s not found", ID))
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't fetch the import job")
+		return
+	}
+
+	writeJob(w, http.StatusOK, job)
+}
+
+func writeJob(w http.ResponseWriter, status int, job importing.Job) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(newImportJob(job))
+}
+
+// spooledSource reads the gophers to import from a temporary file, removed once they are read
+type spooledSource struct {
+	encoding.ListDecoder
+	file *os.File
+}
+
+// spool copies the given body to a temporary file, so the gophers can be imported
+// after the response is sent without holding them in memory
+func spool(body io.Reader, codec encoding.Codec) (importing.Source, error) {
+	file, err := os.CreateTemp("", "gopherapi-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("spooling the import: %w", err)
+	}
+
+	source := spooledSource{ListDecoder: codec.NewListDecoder(file), file: file}
+	if _, err := io.Copy(file, bodyReader{body}); err != nil {
+		_ = source.Close()
+		return nil, fmt.Errorf("spooling the import: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		_ = source.Close()
+		return nil, fmt.Errorf("spooling the import: %w", err)
+	}
+	return source, nil
+}
+
+// bodyReader marks the errors of the body it reads with errReadingBody
+type bodyReader struct {
+	io.Reader
+}
+
+// Read implements io.Reader
+func (b bodyReader) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("%w: %w", errReadingBody, err)
+	}
+	return n, err
+}
+
+// Close removes the temporary file
+func (s spooledSource) Close() error {
+	err := s.file.Close()
+	if removeErr := os.Remove(s.file.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
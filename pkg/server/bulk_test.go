@@ -0,0 +1,313 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+	"github.com/friendsofgo/gopherapi/pkg/exporting"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBulk_ExportGophers(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	tests := map[string]struct {
+		uri, accept string
+		expected    encoding.Codec
+		filename    string
+	}{
+		"default":       {uri: "/gophers/export", expected: encoding.NDJSON, filename: "gophers.ndjson"},
+		"csv by Accept": {uri: "/gophers/export", accept: "text/csv", expected: encoding.CSV, filename: "gophers.csv"},
+		"csv by format": {uri: "/gophers/export?format=csv", expected: encoding.CSV, filename: "gophers.csv"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.uri, nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.expected.MediaType() {
+				t.Errorf("expected %q, got: %q", tt.expected.MediaType(), got)
+			}
+			if got := rec.Header().Get("Content-Disposition"); !strings.Contains(got, tt.filename) {
+				t.Errorf("expected the file to be named %s, got: %q", tt.filename, got)
+			}
+
+			got := 0
+			d := tt.expected.NewListDecoder(rec.Body)
+			for {
+				var g gopher.Gopher
+				err := d.Decode(&g)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				got++
+			}
+			if got != len(sample.Gophers) {
+				t.Errorf("expected %d gophers, got: %d", len(sample.Gophers), got)
+			}
+		})
+	}
+}
+
+func TestBulk_ExportGophersOutlivesTheWriteTimeout(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer collector.Close()
+
+	zipkinTracer, _, err := tracer.NewZipkinTracer("gopherapi", tracer.ZipkinLog(io.Discard))
+	if err != nil {
+		t.Fatalf("could not create the zipkin tracer: %v", err)
+	}
+	openTelemetryTracer, _, err := tracer.NewOpenTelemetryTracer("gopherapi", collector.URL)
+	if err != nil {
+		t.Fatalf("could not create the OpenTelemetry tracer: %v", err)
+	}
+
+	// the tracers wrap the writer the export lifts the deadline of
+	tracers := map[string]tracer.Tracer{
+		"noop":          tracer.NewNoopTracer(),
+		"zipkin":        zipkinTracer,
+		"opentelemetry": openTelemetryTracer,
+	}
+
+	for name, trc := range tracers {
+		t.Run(name, func(t *testing.T) {
+			s := buildServer(WithMetrics(prometheus.NewRegistry()))
+			srv := s.(*server)
+			srv.tracer = trc
+			srv.exporting = slowExporter{exporting.NewService(inmem.NewRepository(sample.Gophers)), 20 * time.Millisecond}
+			router(srv)
+
+			ts := httptest.NewUnstartedServer(s.Router())
+			ts.Config.WriteTimeout = 30 * time.Millisecond
+			ts.Start()
+			defer ts.Close()
+
+			res, err := ts.Client().Get(ts.URL + "/gophers/export")
+			if err != nil {
+				t.Fatalf("could not send request: %v", err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("expected %d, got: %d", http.StatusOK, res.StatusCode)
+			}
+
+			got := 0
+			d := encoding.NDJSON.NewListDecoder(res.Body)
+			for {
+				var g gopher.Gopher
+				err := d.Decode(&g)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("expected the export not to be cut short, got: %v", err)
+				}
+				got++
+			}
+			if got != len(sample.Gophers) {
+				t.Errorf("expected %d gophers, got: %d", len(sample.Gophers), got)
+			}
+		})
+	}
+}
+
+// slowExporter waits before each gopher exported, like a storage slower than the write timeout
+type slowExporter struct {
+	exporting.Service
+	wait time.Duration
+}
+
+func (e slowExporter) ExportGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	return e.Service.ExportGophers(ctx, func(g gopher.Gopher) error {
+		time.Sleep(e.wait)
+		return fn(g)
+	})
+}
+
+func TestBulk_ExportGophersNotAcceptable(t *testing.T) {
+	s := buildServer()
+
+	req, _ := http.NewRequest("GET", "/gophers/export", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected %d, got: %d %s", http.StatusNotAcceptable, rec.Code, rec.Body)
+	}
+}
+
+func TestBulk_ImportGophers(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	body := `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}
+{"ID": "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "name": "Jenny", "age": 19}
+{"ID": "01DCBP0R0MSNZY975ZQF1DCQCJ"}
+`
+	req, _ := http.NewRequest("POST", "/gophers/import?policy=skip", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected %d, got: %d %s", http.StatusAccepted, rec.Code, rec.Body)
+	}
+	location := rec.Header().Get("Location")
+	if !strings.HasPrefix(location, "/gophers/import/") {
+		t.Fatalf("expected the Location of the job, got: %q", location)
+	}
+
+	job := pollImportJob(t, s, location)
+	if job.Status != "succeeded" {
+		t.Fatalf("expected the import to succeed, got: %+v", job)
+	}
+	if job.Processed != 3 || job.Created != 1 || job.Skipped != 1 || job.Failed != 1 {
+		t.Errorf("expected 1 gopher created, 1 skipped and 1 failed, got: %+v", job)
+	}
+	if len(job.Errors) != 1 || job.Errors[0].Row != 3 {
+		t.Errorf("expected the third row to be reported, got: %+v", job.Errors)
+	}
+
+	req, _ = http.NewRequest("GET", "/gophers/01DCBP0R0MSNZY975ZQF1DCQCH", nil)
+	rec = httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected the gopher imported to be found, got: %d", rec.Code)
+	}
+}
+
+func TestBulk_ImportGophersOutlivesTheReadTimeout(t *testing.T) {
+	s := buildServer()
+
+	ts := httptest.NewUnstartedServer(s.Router())
+	ts.Config.ReadTimeout = 30 * time.Millisecond
+	ts.Start()
+	defer ts.Close()
+
+	// the body is uploaded slower than the read timeout of the server
+	body, upload := io.Pipe()
+	go func() {
+		for _, ID := range []string{"01DCBP0R0MSNZY975ZQF1DCQCH", "01DCBP0R0MSNZY975ZQF1DCQCJ", "01DCBP0R0MSNZY975ZQF1DCQCK"} {
+			time.Sleep(20 * time.Millisecond)
+			_, _ = fmt.Fprintf(upload, "{\"ID\": %q, \"name\": \"Eustaqio\"}\n", ID)
+		}
+		_ = upload.Close()
+	}()
+
+	res, err := ts.Client().Post(ts.URL+"/gophers/import", "application/x-ndjson", body)
+	if err != nil {
+		t.Fatalf("could not send request: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		b, _ := io.ReadAll(res.Body)
+		t.Fatalf("expected %d, got: %d %s", http.StatusAccepted, res.StatusCode, b)
+	}
+
+	job := pollImportJob(t, s, res.Header.Get("Location"))
+	if job.Status != "succeeded" || job.Created != 3 {
+		t.Errorf("expected the 3 gophers to be created, got: %+v", job)
+	}
+}
+
+func TestBulk_ImportGophersInvalid(t *testing.T) {
+	s := buildServer(WithMaxImportSize(16))
+
+	tests := map[string]struct {
+		uri, contentType string
+		body             io.Reader
+		expected         int
+	}{
+		"unknown policy":       {uri: "/gophers/import?policy=merge", contentType: "application/x-ndjson", expected: http.StatusBadRequest},
+		"unsupported encoding": {uri: "/gophers/import", contentType: "application/yaml", expected: http.StatusUnsupportedMediaType},
+		"body too large":       {uri: "/gophers/import", contentType: "application/x-ndjson", body: strings.NewReader(`{"ID":"01DCBP0R0MSNZY975ZQF1DCQCH"}`), expected: http.StatusRequestEntityTooLarge},
+		"body unreadable":      {uri: "/gophers/import", contentType: "application/x-ndjson", body: iotest.ErrReader(errors.New("connection reset")), expected: http.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			body := tt.body
+			if body == nil {
+				body = strings.NewReader("{}")
+			}
+			req, _ := http.NewRequest("POST", tt.uri, body)
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Fatalf("expected %d, got: %d %s", tt.expected, rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("expected a problem, got: %q", got)
+			}
+		})
+	}
+}
+
+func TestBulk_FetchImportJobNotFound(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	req, _ := http.NewRequest("GET", "/gophers/import/01J9Z3K4N5P6Q7R8S9T0V1W2X3", nil)
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got: %d %s", http.StatusNotFound, rec.Code, rec.Body)
+	}
+}
+
+// pollImportJob fetches the job at the given location until it's finished
+func pollImportJob(t *testing.T, s Server, location string) importJob {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		req, _ := http.NewRequest("GET", location, nil)
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+		}
+
+		var job importJob
+		if err := json.NewDecoder(rec.Body).Decode(&job); err != nil {
+			t.Fatalf("could not decode response: %v", err)
+		}
+		if job.Status != "running" {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the import to finish, got: %+v", job)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/log"
 )
 
 var (
@@ -10,6 +13,7 @@ var (
 	contextKeyXForwardedProto = contextKey("xForwardedProto")
 	contextKeyEndpoint        = contextKey("endpoint")
 	contextKeyClientIP        = contextKey("clientIP")
+	contextKeyRequestID       = contextKey("requestID")
 )
 
 type contextKey string
@@ -47,3 +51,38 @@ func ClientIP(ctx context.Context) (string, bool) {
 	clientIP, ok := ctx.Value(contextKeyClientIP).(string)
 	return clientIP, ok
 }
+
+// RequestID gets the identifier of the request from context
+func RequestID(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(contextKeyRequestID).(string)
+	return requestID, ok
+}
+
+// Principal gets the authenticated caller from context, stored by the auth package under its own key
+func Principal(ctx context.Context) (auth.Principal, bool) {
+	return auth.PrincipalFromContext(ctx)
+}
+
+// RequestFields returns the values of the request stored in the context, to be logged along with every message
+func RequestFields(ctx context.Context) []log.Field {
+	serverID, _ := ID(ctx)
+	endpoint, _ := Endpoint(ctx)
+	clientIP, _ := ClientIP(ctx)
+	fields := []log.Field{
+		{Key: "serverid", Value: serverID},
+		{Key: "endpoint", Value: endpoint},
+		{Key: "clientip", Value: clientIP},
+	}
+
+	if requestID, ok := RequestID(ctx); ok {
+		fields = append(fields, log.Field{Key: "requestid", Value: requestID})
+	}
+	if xForwardedFor, ok := XForwardedFor(ctx); ok {
+		fields = append(fields, log.Field{Key: "xforwardedfor", Value: xForwardedFor})
+	}
+	if xForwardedProto, ok := XForwardedProto(ctx); ok {
+		fields = append(fields, log.Field{Key: "xforwardedproto", Value: xForwardedProto})
+	}
+
+	return fields
+}
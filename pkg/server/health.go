@@ -0,0 +1,35 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/friendsofgo/gopherapi/pkg/health"
+)
+
+// Liveness reports the server is running
+func (s *server) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(health.Report{Status: health.StatusUp})
+}
+
+// Readiness reports whether the server and its dependencies can serve requests
+func (s *server) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := health.Report{Status: health.StatusUp}
+	if s.probe != nil {
+		report = s.probe.Check(r.Context())
+	}
+	// the errors of the dependencies are only logged, the body only tells which ones are down
+	for name, result := range report.Checks {
+		if result.Err != nil {
+			s.logger.UnexpectedError(r.Context(), fmt.Errorf("checking %s after %s: %w", name, result.Latency, result.Err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
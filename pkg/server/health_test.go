@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/friendsofgo/gopherapi/pkg/health"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+// errorLogger records the unexpected errors, the rest of messages are discarded
+type errorLogger struct {
+	log.Logger
+	errors []error
+}
+
+func (l *errorLogger) UnexpectedError(ctx context.Context, err error) {
+	l.errors = append(l.errors, err)
+}
+
+func TestReadiness(t *testing.T) {
+	probe := health.NewProbe(time.Second)
+	probe.Register("inmem", health.CheckerFunc(func(context.Context) error { return nil }))
+
+	testData := []struct {
+		name    string
+		prepare func()
+		status  int
+	}{
+		{name: "dependencies up", prepare: func() {}, status: http.StatusOK},
+		{name: "dependency down", prepare: func() {
+			probe.Register("redis", health.CheckerFunc(func(context.Context) error { return errors.New("connection refused") }))
+		}, status: http.StatusServiceUnavailable},
+		{name: "shutting down", prepare: func() {
+			probe.Register("redis", health.CheckerFunc(func(context.Context) error { return nil }))
+			probe.ShutDown()
+		}, status: http.StatusServiceUnavailable},
+	}
+
+	logger := &errorLogger{Logger: log.NewNoopLogger()}
+	s := buildServer(WithHealthProbe(probe), WithLogger(logger))
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+
+			req, err := http.NewRequest("GET", "/readyz", nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("expected %d, got: %d", tt.status, rec.Code)
+			}
+
+			if strings.Contains(rec.Body.String(), "connection refused") {
+				t.Errorf("expected the errors of the dependencies not to be answered, got: %s", rec.Body)
+			}
+
+			var report health.Report
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("could not unmarshall response %v", err)
+			}
+			if _, ok := report.Checks["inmem"]; !ok {
+				t.Errorf("expected inmem check in report %v", report)
+			}
+		})
+	}
+
+	if len(logger.errors) != 1 || !strings.Contains(logger.errors[0].Error(), "connection refused") {
+		t.Errorf("expected the error of the dependency down to be logged, got: %v", logger.errors)
+	}
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected liveness %d while shutting down, got: %d", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+// AccessLog determines which of the requests served are logged
+type AccessLog struct {
+	// SampleRate is the ratio of requests logged, between 0 and 1,
+	// the server errors are logged regardless of it
+	SampleRate float64
+	// Exclude are the path prefixes of the requests never logged, like the health checks
+	Exclude []string
+}
+
+// defaultAccessLog logs every request served
+var defaultAccessLog = AccessLog{SampleRate: 1}
+
+// excluded reports whether the requests of the given path aren't logged
+func (a AccessLog) excluded(path string) bool {
+	for _, prefix := range a.Exclude {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sampled decides whether a request is logged
+func (a AccessLog) sampled() bool {
+	return a.SampleRate >= 1 || rand.Float64() < a.SampleRate
+}
+
+// newLoggingMiddleware logs when each request starts and its access log entry once served
+func newLoggingMiddleware(logger log.Logger, config AccessLog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.excluded(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			path := routeTemplate(r)
+			sampled := config.sampled()
+			if sampled {
+				logger.RequestStarted(r.Context(), r.Method, path)
+			}
+
+			start := time.Now()
+			rec := newStatusRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			if !sampled && rec.status < http.StatusInternalServerError {
+				return
+			}
+			logger.RequestFinished(r.Context(), log.Access{
+				Method:   r.Method,
+				Path:     path,
+				Status:   rec.status,
+				Bytes:    rec.bytes,
+				Duration: time.Since(start),
+			})
+		})
+	}
+}
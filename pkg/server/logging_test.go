@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+// accessLogger records the access log entries, the rest of messages are discarded
+type accessLogger struct {
+	log.Logger
+	entries []log.Access
+}
+
+func (l *accessLogger) RequestFinished(ctx context.Context, access log.Access) {
+	l.entries = append(l.entries, access)
+}
+
+func TestAccessLog(t *testing.T) {
+	logger := &accessLogger{Logger: log.NewNoopLogger()}
+	s := buildServer(WithLogger(logger), WithAccessLog(AccessLog{SampleRate: 1, Exclude: []string{"/healthz"}}))
+
+	for _, path := range []string{"/healthz", "/gophers/01D3XZ3ZHCP3KG9VT4FGAD8KDR"} {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("could not created request: %v", err)
+		}
+		s.Router().ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(logger.entries) != 1 {
+		t.Fatalf("expected 1 access log entry, got: %d", len(logger.entries))
+	}
+
+	entry := logger.entries[0]
+	if entry.Method != http.MethodGet || entry.Path != "/gophers/{ID:[a-zA-Z0-9_]+}" {
+		t.Errorf("expected the route template to be logged, got: %s %s", entry.Method, entry.Path)
+	}
+	if entry.Status != http.StatusOK {
+		t.Errorf("expected %d, got: %d", http.StatusOK, entry.Status)
+	}
+	if entry.Bytes == 0 {
+		t.Errorf("expected the size of the response to be logged")
+	}
+}
+
+func TestAccessLog_Sampling(t *testing.T) {
+	logger := &accessLogger{Logger: log.NewNoopLogger()}
+	s := buildServer(WithLogger(logger), WithAccessLog(AccessLog{SampleRate: 0}))
+
+	req, err := http.NewRequest("GET", "/gophers", nil)
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	s.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(logger.entries) != 0 {
+		t.Errorf("expected the request not to be sampled, got: %v", logger.entries)
+	}
+}
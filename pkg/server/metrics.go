@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
+	"github.com/gorilla/mux"
+)
+
+// statusRecorder keeps the status and size of the response written by the handlers
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func newStatusRecorder(w http.ResponseWriter) *statusRecorder {
+	return &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.wroteHeader = true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher so streamed responses keep working
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController reaches the connection
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func newMetricsMiddleware(m *metrics.HTTP) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newStatusRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			m.Observe(routeTemplate(r), r.Method, rec.status, time.Since(start))
+		})
+	}
+}
+
+// routeTemplate returns the path template of the matched route, so the
+// metrics are not labelled with the gopher IDs
+func routeTemplate(r *http.Request) string {
+	if template, ok := pathTemplate(r); ok {
+		return template
+	}
+	return "unknown"
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetrics(t *testing.T) {
+	s := buildServer(WithMetrics(prometheus.NewRegistry()))
+
+	for _, path := range []string{"/gophers", "/gophers/01D3XZ3ZHCP3KG9VT4FGAD8KDR", "/gophers/unknown"} {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatalf("could not created request: %v", err)
+		}
+		s.Router().ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got: %d", http.StatusOK, rec.Code)
+	}
+
+	body, _ := ioutil.ReadAll(rec.Body)
+	expected := []string{
+		`gopherapi_http_requests_total{method="GET",route="/gophers",status="200"} 1`,
+		`gopherapi_http_requests_total{method="GET",route="/gophers/{ID:[a-zA-Z0-9_]+}",status="200"} 1`,
+		`gopherapi_http_requests_total{method="GET",route="/gophers/{ID:[a-zA-Z0-9_]+}",status="404"} 1`,
+		`gopherapi_http_request_duration_seconds_count{method="GET",route="/gophers",status="200"} 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line) {
+			t.Errorf("expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+}
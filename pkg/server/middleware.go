@@ -5,21 +5,25 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
-	"github.com/openzipkin/zipkin-go"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
 )
 
 type handler struct {
-	serverID string
-	next     http.Handler
+	serverID       string
+	trustedProxies []*net.IPNet
+	next           http.Handler
 }
 
-func newServerMiddleware(serverID string) func(http.Handler) http.Handler {
+func newServerMiddleware(serverID string, trustedProxies []*net.IPNet) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		h := &handler{
-			serverID: serverID,
-			next:     next,
+			serverID:       serverID,
+			trustedProxies: trustedProxies,
+			next:           next,
 		}
 		return h
 	}
@@ -47,22 +51,59 @@ func (h handler) createRequestContext(req *http.Request) context.Context {
 	}
 
 	ip, _, _ := net.SplitHostPort(req.RemoteAddr)
-	ctx = context.WithValue(ctx, contextKeyClientIP, ip)
+	ctx = context.WithValue(ctx, contextKeyClientIP, h.clientIP(ip, xForwardedFor))
 	ctx = context.WithValue(ctx, contextKeyEndpoint, req.URL.RequestURI())
 
 	ctx = context.WithValue(ctx, contextKeyServerID, h.serverID)
-	zipkinSpanHttpName(ctx, req)
+	spanHttpName(ctx, req)
 	return ctx
 }
 
-func zipkinSpanHttpName(ctx context.Context, req *http.Request) {
-	if span := zipkin.SpanFromContext(ctx); span != nil {
-		if currentRoute := mux.CurrentRoute(req); currentRoute != nil {
-			if routePath, err := currentRoute.GetPathTemplate(); err == nil {
-				zipkin.TagHTTPRoute.Set(span, routePath)
-				span.SetName(fmt.Sprintf("%s %s", req.Method, routePath))
+// clientIP returns the IP of the client, when the request comes through trusted proxies
+// it's the last address of X-Forwarded-For not added by one of them
+func (h handler) clientIP(remoteIP, xForwardedFor string) string {
+	if !h.trusted(remoteIP) || xForwardedFor == "" {
+		return remoteIP
+	}
+
+	forwarded := strings.Split(xForwardedFor, ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := strings.TrimSpace(forwarded[i])
+		if !h.trusted(ip) {
+			return ip
+		}
+	}
+	return strings.TrimSpace(forwarded[0])
+}
+
+func (h handler) trusted(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range h.trustedProxies {
+		if proxy.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func spanHttpName(ctx context.Context, req *http.Request) {
+	if span := tracer.SpanFromContext(ctx); span != nil {
+		if routePath, ok := pathTemplate(req); ok {
+			span.Tag("http.route", routePath)
+			span.SetName(fmt.Sprintf("%s %s", req.Method, routePath))
+		}
+	}
+}
 
-			}
+// pathTemplate returns the path template of the route matched by the request
+func pathTemplate(req *http.Request) (string, bool) {
+	if currentRoute := mux.CurrentRoute(req); currentRoute != nil {
+		if routePath, err := currentRoute.GetPathTemplate(); err == nil {
+			return routePath, true
 		}
 	}
+	return "", false
 }
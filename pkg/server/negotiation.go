@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+)
+
+// flushEvery is the number of gophers written between flushes, so the large lists
+// reach the callers while they are written
+const flushEvery = 100
+
+// negotiate returns the codec of the representation asked for with the format parameter,
+// or else with the Accept header, answering with a 406 when it isn't supported
+func (s *server) negotiate(w http.ResponseWriter, r *http.Request) (encoding.Codec, bool) {
+	codec, err := negotiateCodec(r, s.codecs)
+	if err == nil {
+		return codec, true
+	}
+
+	s.logger.InvalidRequest(r.Context(), err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotAcceptable)
+	_ = json.NewEncoder(w).Encode("Representation not supported")
+	return nil, false
+}
+
+// negotiateCodec returns the codec of the given registry asked for with the format parameter,
+// or else with the Accept header
+func negotiateCodec(r *http.Request, registry *encoding.Registry) (encoding.Codec, error) {
+	var (
+		codec encoding.Codec
+		ok    bool
+	)
+	if format := r.URL.Query().Get("format"); format != "" {
+		codec, ok = registry.ByFormat(format)
+	} else {
+		codec, ok = registry.Negotiate(r.Header.Get("Accept"))
+	}
+	if !ok {
+		return nil, fmt.Errorf("no representation for format %q or Accept %q",
+			r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	}
+	return codec, nil
+}
+
+// decodeGopher reads the gopher of the request body in the representation of its Content-Type,
+// JSON when it isn't given, answering with an error when it can't be read
+func (s *server) decodeGopher(w http.ResponseWriter, r *http.Request, g *gopher.Gopher) bool {
+	codec, ok := s.codecs.ByContentType(r.Header.Get("Content-Type"))
+	if !ok {
+		s.logger.InvalidRequest(r.Context(), fmt.Errorf("unsupported Content-Type %q", r.Header.Get("Content-Type")))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		_ = json.NewEncoder(w).Encode("Content-Type not supported")
+		return false
+	}
+
+	if err := codec.Decode(r.Body, g); err != nil {
+		s.logger.InvalidRequest(r.Context(), err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode("Error unmarshalling request body")
+		return false
+	}
+	return true
+}
+
+// writeGopher writes the given gopher in the representation of the given codec
+func writeGopher(w http.ResponseWriter, codec encoding.Codec, g gopher.Gopher) error {
+	w.Header().Set("Content-Type", codec.MediaType())
+	return codec.Encode(w, g)
+}
+
+// writeGophers streams the gophers given to fn by stream in the representation of the given codec,
+// the response is only started with the first gopher so the errors raised before can still be answered
+func writeGophers(w http.ResponseWriter, codec encoding.Codec, stream func(fn func(gopher.Gopher) error) error) (started bool, err error) {
+	var (
+		e       encoding.ListEncoder
+		written int
+	)
+	start := func() {
+		w.Header().Set("Content-Type", codec.MediaType())
+		e = codec.NewListEncoder(w)
+	}
+	flusher, _ := w.(http.Flusher)
+
+	err = stream(func(g gopher.Gopher) error {
+		if e == nil {
+			start()
+		}
+		if err := e.Encode(g); err != nil {
+			return err
+		}
+		if written++; flusher != nil && written%flushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return e != nil, err
+	}
+
+	if e == nil {
+		start()
+	}
+	return true, e.Close()
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+)
+
+func TestNegotiation_FetchGophers(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	tests := map[string]struct {
+		uri, accept string
+		expected    encoding.Codec
+	}{
+		"default":           {uri: "/gophers", expected: encoding.JSON},
+		"csv by Accept":     {uri: "/gophers", accept: "text/csv", expected: encoding.CSV},
+		"ndjson by Accept":  {uri: "/v1/gophers", accept: "application/json;q=0.5, application/x-ndjson", expected: encoding.NDJSON},
+		"xml by format":     {uri: "/gophers?format=xml", accept: "text/csv", expected: encoding.XML},
+		"msgpack by format": {uri: "/v1/gophers?format=msgpack", expected: encoding.MessagePack},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.uri, nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.expected.MediaType() {
+				t.Errorf("expected %q, got: %q", tt.expected.MediaType(), got)
+			}
+			if got := rec.Header().Values("Vary"); len(got) != 1 || got[0] != "Accept" {
+				t.Errorf("expected the response to vary by Accept, got: %v", got)
+			}
+
+			var got []gopher.Gopher
+			d := tt.expected.NewListDecoder(rec.Body)
+			for {
+				var g gopher.Gopher
+				err := d.Decode(&g)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("could not decode response: %v", err)
+				}
+				got = append(got, g)
+			}
+			if len(got) != len(sample.Gophers) {
+				t.Errorf("expected %d gophers, got: %d", len(sample.Gophers), len(got))
+			}
+		})
+	}
+}
+
+func TestNegotiation_FetchGopher(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+	expected := gopherSample()
+
+	req, _ := http.NewRequest("GET", "/gophers/"+expected.ID+"?format=csv", nil)
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+	}
+	var got gopher.Gopher
+	if err := encoding.CSV.Decode(rec.Body, &got); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if got != *expected {
+		t.Errorf("expected %+v, got: %+v", *expected, got)
+	}
+
+	// the errors are still described as JSON
+	req, _ = http.NewRequest("GET", "/gophers/unknown?format=csv", nil)
+	rec = httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected a JSON %d, got: %d %s", http.StatusNotFound, rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestNegotiation_Bodies(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	// run in order, the gopher modified is the one created before
+	tests := []struct {
+		name, method, uri, contentType, body string
+		expected                             int
+	}{
+		{"csv", "POST", "/gophers", "text/csv", "ID,name,age\n01DCBP0R0MSNZY975ZQF1DCQCH,Eustaqio,99\n", http.StatusCreated},
+		{"xml", "PUT", "/v1/gophers/01DCBP0R0MSNZY975ZQF1DCQCH", "application/xml", "<gopher><name>Eustaquio</name><age>100</age></gopher>", http.StatusNoContent},
+		{"ndjson", "POST", "/v1/gophers", "application/x-ndjson", `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCJ", "name": "Bruno"}`, http.StatusCreated},
+		{"checked by spec", "POST", "/gophers", "text/csv", "ID,age\n01DCBP0R0MSNZY975ZQF1DCQCK,1\n", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.uri, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("expected %d, got: %d %s", tt.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+
+	req, _ := http.NewRequest("GET", "/gophers/01DCBP0R0MSNZY975ZQF1DCQCH", nil)
+	req.Header.Set("Accept", "application/msgpack")
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	var got gopher.Gopher
+	if err := encoding.MessagePack.Decode(rec.Body, &got); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if expected := (gopher.Gopher{ID: "01DCBP0R0MSNZY975ZQF1DCQCH", Name: "Eustaquio", Age: 100}); got != expected {
+		t.Errorf("expected %+v, got: %+v", expected, got)
+	}
+}
+
+func TestNegotiation_Unsupported(t *testing.T) {
+	tests := map[string]struct {
+		s                          Server
+		method, uri, accept, ctype string
+		expected                   int
+	}{
+		"by Accept":     {s: buildServer(), method: "GET", uri: "/gophers", accept: "image/png", expected: http.StatusNotAcceptable},
+		"by format":     {s: buildServer(), method: "GET", uri: "/gophers?format=yaml", expected: http.StatusNotAcceptable},
+		"by the server": {s: buildServer(WithCodecs(encoding.NewRegistry(encoding.JSON))), method: "GET", uri: "/gophers/" + gopherSample().ID, accept: "text/csv", expected: http.StatusNotAcceptable},
+		"body":          {s: buildServer(), method: "POST", uri: "/gophers", ctype: "application/yaml", expected: http.StatusUnsupportedMediaType},
+		"v2 preferred":  {s: buildServer(), method: "GET", uri: "/gophers", accept: "application/json; version=2, image/png;q=0.5", expected: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.uri, bytes.NewBufferString("ID: 01DCBP0R0MSNZY975ZQF1DCQCK\n"))
+			req.Header.Set("Accept", tt.accept)
+			req.Header.Set("Content-Type", tt.ctype)
+			rec := httptest.NewRecorder()
+			tt.s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("expected %d, got: %d %s", tt.expected, rec.Code, rec.Body)
+			}
+		})
+	}
+}
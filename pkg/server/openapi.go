@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/friendsofgo/gopherapi/api"
+)
+
+// swaggerUIVersion is the exact version of Swagger UI the docs page loads, a floating
+// version would let the CDN serve the page any script published under it
+const swaggerUIVersion = "5.17.14"
+
+// docsPage renders the OpenAPI document with Swagger UI
+var docsPage = strings.ReplaceAll(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Gopher API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{version}/swagger-ui.css" crossorigin="anonymous">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{version}/swagger-ui-bundle.js" crossorigin="anonymous"></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>
+`, "{version}", swaggerUIVersion)
+
+// OpenAPI serves the OpenAPI document describing the api
+func (s *server) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(api.OpenAPI)
+}
+
+// Docs serves a page to browse the OpenAPI document
+func (s *server) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(docsPage))
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pathVariable matches the variables of a mux path template, and their pattern if any
+var pathVariable = regexp.MustCompile(`\{([^}:]+)(:[^}]+)?\}`)
+
+func TestOpenAPI_DescribesEveryRoute(t *testing.T) {
+	s := buildServer(WithMetrics(prometheus.NewRegistry()))
+
+	req, err := http.NewRequest("GET", "/openapi.json", nil)
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got: %d", http.StatusOK, rec.Code)
+	}
+
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&spec); err != nil {
+		t.Fatalf("could not decode the OpenAPI document: %v", err)
+	}
+
+	router := s.(*server).router.(*mux.Router)
+	err = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// subrouters have no methods, their routes are walked on their own
+			return nil
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		path := pathVariable.ReplaceAllString(template, "{$1}")
+		for _, method := range methods {
+			if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+				t.Errorf("expected the OpenAPI document to describe %s %s", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("could not walk the routes: %v", err)
+	}
+}
+
+func TestDocs_PinsSwaggerUI(t *testing.T) {
+	s := buildServer()
+
+	req, err := http.NewRequest("GET", "/docs", nil)
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got: %d", http.StatusOK, rec.Code)
+	}
+
+	assets := regexp.MustCompile(`(?:href|src)="(https://[^"]+)"`).FindAllStringSubmatch(rec.Body.String(), -1)
+	if len(assets) == 0 {
+		t.Fatalf("expected the docs page to load Swagger UI")
+	}
+	exactVersion := regexp.MustCompile(`@\d+\.\d+\.\d+/`)
+	for _, asset := range assets {
+		if !exactVersion.MatchString(asset[1]) {
+			t.Errorf("expected %s to be pinned to an exact version", asset[1])
+		}
+	}
+}
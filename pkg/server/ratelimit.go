@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+)
+
+// RateLimits defines the limit of requests of each client, by default and per route name
+type RateLimits struct {
+	Default ratelimit.Limit
+	Routes  map[string]ratelimit.Limit
+	// IP limits all the requests of each client IP, before they are authenticated, so the
+	// requests with wrong credentials are limited too, it's disabled when zero
+	IP ratelimit.Limit
+}
+
+func (l RateLimits) forRoute(name string) ratelimit.Limit {
+	if limit, ok := l.Routes[name]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// newIPRateLimitMiddleware limits the requests of each client IP to the whole api,
+// it goes before the authentication so it can't be bypassed with invalid credentials
+func newIPRateLimitMiddleware(store ratelimit.Store, limit ratelimit.Limit, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			clientIP, _ := ClientIP(r.Context())
+			if takeToken(w, r, store, ratelimit.IPKey(clientIP), limit, logger) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// newRateLimitMiddleware limits the requests of each client to each route, clients are
+// identified by their authenticated principal or, for anonymous requests, by their IP
+func newRateLimitMiddleware(store ratelimit.Store, limits RateLimits, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var routeName string
+			if route := mux.CurrentRoute(r); route != nil {
+				routeName = route.GetName()
+			}
+			if takeToken(w, r, store, rateLimitKey(r, routeName), limits.forRoute(routeName), logger) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// takeToken takes a token from the bucket of the given key, answering with a 429 and
+// returning false when there's none left
+func takeToken(w http.ResponseWriter, r *http.Request, store ratelimit.Store, key string, limit ratelimit.Limit, logger log.Logger) bool {
+	result, err := store.Take(r.Context(), key, limit)
+	if err != nil {
+		// the rate limiter must not take the api down with it
+		logger.UnexpectedError(r.Context(), err)
+		return true
+	}
+
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_ = json.NewEncoder(w).Encode("Too many requests")
+		return false
+	}
+	return true
+}
+
+func rateLimitKey(r *http.Request, routeName string) string {
+	if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+		return fmt.Sprintf("%s:%s:%s", routeName, p.Method, p.Subject)
+	}
+	clientIP, _ := ClientIP(r.Context())
+	return fmt.Sprintf("%s:ip:%s", routeName, clientIP)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	_, proxies, _ := net.ParseCIDR("10.0.0.0/8")
+	limits := RateLimits{
+		Default: ratelimit.Limit{Rate: 0.001, Burst: 1},
+		Routes:  map[string]ratelimit.Limit{routeFetchGophers: {Rate: 0.001, Burst: 2}},
+	}
+	s := buildServer(WithTrustedProxies(proxies), WithRateLimit(ratelimit.NewMemoryStore(), limits))
+
+	testData := []struct {
+		name          string
+		uri           string
+		remoteAddr    string
+		xForwardedFor string
+		status        int
+		remaining     string
+	}{
+		{name: "first request", uri: "/gophers", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "1"},
+		{name: "second request within burst", uri: "/gophers", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "0"},
+		{name: "request over the limit", uri: "/gophers", remoteAddr: "192.0.2.1:1234", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "other route has its own limit", uri: "/gophers/" + gopherSample().ID, remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "0"},
+		{name: "other client through trusted proxy", uri: "/gophers", remoteAddr: "10.0.0.1:1234", xForwardedFor: "192.0.2.2, 10.0.0.2", status: http.StatusOK, remaining: "1"},
+		{name: "spoofed forwarded header from untrusted client", uri: "/gophers", remoteAddr: "192.0.2.1:1234", xForwardedFor: "192.0.2.3", status: http.StatusTooManyRequests, remaining: "0"},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.uri, nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.xForwardedFor)
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+			if tt.status != res.StatusCode {
+				t.Errorf("expected %d, got: %d", tt.status, res.StatusCode)
+			}
+			if remaining := res.Header.Get("RateLimit-Remaining"); remaining != tt.remaining {
+				t.Errorf("expected %s remaining requests, got: %s", tt.remaining, remaining)
+			}
+			if tt.status == http.StatusTooManyRequests && res.Header.Get("Retry-After") == "" {
+				t.Errorf("expected Retry-After header")
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_BeforeAuthentication(t *testing.T) {
+	authenticator, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "ci", Hash: auth.HashAPIKey("s3cr3t"), Scopes: []string{"gophers:read"}},
+	})
+	if err != nil {
+		t.Fatalf("could not create authenticator: %v", err)
+	}
+	limits := RateLimits{
+		Default: ratelimit.Limit{Rate: 0.001, Burst: 10},
+		IP:      ratelimit.Limit{Rate: 0.001, Burst: 2},
+	}
+	s := buildServer(WithAuthenticators(authenticator), WithRateLimit(ratelimit.NewMemoryStore(), limits))
+
+	testData := []struct {
+		name   string
+		uri    string
+		apiKey string
+		status int
+	}{
+		{name: "wrong credentials", uri: "/gophers", apiKey: "wrong", status: http.StatusUnauthorized},
+		{name: "wrong credentials on other route", uri: "/v2/gophers", apiKey: "wrong", status: http.StatusUnauthorized},
+		{name: "right credentials over the limit of the IP", uri: "/gophers", apiKey: "s3cr3t", status: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.uri, nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			req.RemoteAddr = "192.0.2.1:1234"
+			req.Header.Set("X-API-Key", tt.apiKey)
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if tt.status != rec.Code {
+				t.Errorf("expected %d, got: %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
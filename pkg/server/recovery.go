@@ -0,0 +1,48 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+// newRecoveryMiddleware recovers from the panics of the handlers, answering with a 500 problem
+// instead of dropping the connection, and reports them to the logs, the trace and the metrics if any
+func newRecoveryMiddleware(logger log.Logger, m *metrics.HTTP) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := newStatusRecorder(w)
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					// the handler aborted the response on purpose, let net/http drop the connection
+					panic(recovered)
+				}
+
+				ctx := r.Context()
+				logger.PanicRecovered(ctx, recovered, debug.Stack())
+				if span := tracer.SpanFromContext(ctx); span != nil {
+					span.SetError(fmt.Errorf("panic: %v", recovered))
+				}
+				if m != nil {
+					m.Panicked(routeTemplate(r))
+				}
+
+				if rec.wroteHeader {
+					// part of the response is already sent, it can only be aborted
+					panic(http.ErrAbortHandler)
+				}
+				writeProblem(rec, r, http.StatusInternalServerError, "")
+			}()
+
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
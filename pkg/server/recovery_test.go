@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
+)
+
+// panicLogger records the panics recovered, the rest of messages are discarded
+type panicLogger struct {
+	log.Logger
+	recovered []interface{}
+}
+
+func (l *panicLogger) PanicRecovered(ctx context.Context, recovered interface{}, stack []byte) {
+	l.recovered = append(l.recovered, recovered)
+}
+
+func TestRecovery(t *testing.T) {
+	logger := &panicLogger{Logger: log.NewNoopLogger()}
+	registry := prometheus.NewRegistry()
+	handler := newRecoveryMiddleware(logger, metrics.NewHTTP(registry))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gophers", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected %d, got: %d", http.StatusInternalServerError, rec.Code)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("expected a problem response, got: %s", contentType)
+	}
+	var body problem
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("could not decode the problem: %v", err)
+	}
+	if body.Status != http.StatusInternalServerError {
+		t.Errorf("expected status %d in the problem, got: %d", http.StatusInternalServerError, body.Status)
+	}
+
+	if len(logger.recovered) != 1 || logger.recovered[0] != "boom" {
+		t.Errorf("expected the panic to be logged, got: %v", logger.recovered)
+	}
+	if count := testutil.CollectAndCount(registry, "gopherapi_http_panics_total"); count != 1 {
+		t.Errorf("expected the panic to be counted, got %d series", count)
+	}
+}
+
+func TestRecovery_ResponseStarted(t *testing.T) {
+	handler := newRecoveryMiddleware(log.NewNoopLogger(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("boom")
+	}))
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("expected the response to be aborted, got: %v", recovered)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/gophers", nil))
+}
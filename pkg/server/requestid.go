@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oklog/ulid/v2"
+
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+// requestIDHeader carries the identifier of the request, given by the caller or generated by the server
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the identifiers accepted from the callers, as they end up in every log entry
+const maxRequestIDLength = 128
+
+// newRequestIDMiddleware identifies each request with the X-Request-ID given by the caller,
+// or a new ULID otherwise, and echoes it in the response so it can be quoted later
+func newRequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = ulid.Make().String()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		if span := tracer.SpanFromContext(r.Context()); span != nil {
+			span.Tag("request.id", requestID)
+		}
+
+		ctx := context.WithValue(r.Context(), contextKeyRequestID, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether the given identifier is short and printable,
+// so the callers can't forge log entries with it
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
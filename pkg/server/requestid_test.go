@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := map[string]struct {
+		header    string
+		generated bool
+	}{
+		"given by the caller": {header: "f3a9c2e1-support-ticket"},
+		"missing":             {generated: true},
+		"too long":            {header: strings.Repeat("a", maxRequestIDLength+1), generated: true},
+		"not printable":       {header: "abc\ninjected", generated: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var fromContext string
+			handler := newRequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromContext, _ = RequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/gophers", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			echoed := rec.Header().Get(requestIDHeader)
+			if echoed != fromContext {
+				t.Errorf("expected the response to echo %q, got: %q", fromContext, echoed)
+			}
+			if tt.generated && (echoed == tt.header || len(echoed) != 26) {
+				t.Errorf("expected a generated ULID, got: %q", echoed)
+			}
+			if !tt.generated && echoed != tt.header {
+				t.Errorf("expected %q, got: %q", tt.header, echoed)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/friendsofgo/gopherapi/pkg/searching"
+)
+
+// searchResult is the representation of a gopher found, with the words matched highlighted
+type searchResult struct {
+	Gopher     gopherV2          `json:"gopher"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchGophers return the gophers matching the q parameter, best first
+func (s *server) SearchGophers(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultPageLimit, 1, maxPageLimit)
+	if err != nil {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+
+	results, err := s.searching.SearchGophers(r.Context(), r.URL.Query().Get("q"), limit)
+	if writeAuthorizationProblem(w, r, err) {
+		return
+	}
+	if errors.Is(err, searching.ErrEmptyQuery) || errors.Is(err, searching.ErrQueryTooLong) {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't search the gophers")
+		return
+	}
+
+	data := make([]searchResult, 0, len(results))
+	for _, result := range results {
+		data = append(data, searchResult{
+			Gopher:     newGopherV2(result.Gopher),
+			Score:      result.Score,
+			Highlights: result.Highlights,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(page{Data: data})
+}
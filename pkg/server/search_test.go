@@ -0,0 +1,79 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSearchGophers(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+	expected := gopherSample()
+
+	req, _ := http.NewRequest("GET", "/gophers/search?q=JENY", nil)
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+	}
+
+	var got struct {
+		Data []searchResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(got.Data) == 0 || got.Data[0].Gopher.ID != expected.ID {
+		t.Fatalf("expected %s to be found first, got: %+v", expected.ID, got.Data)
+	}
+	if highlight := got.Data[0].Highlights["name"]; highlight != "<em>Jenny</em>" {
+		t.Errorf("expected the name to be highlighted, got: %q", highlight)
+	}
+}
+
+func TestSearchGophers_FindsTheGophersAdded(t *testing.T) {
+	s := buildServer()
+
+	body, _ := json.Marshal(addGopherRequest{ID: "01DCBP0R0MSNZY975ZQF1DCQCH", Name: "Eustáquio", Age: 99})
+	req, _ := http.NewRequest("POST", "/gophers", bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got: %d %s", http.StatusCreated, rec.Code, rec.Body)
+	}
+
+	req, _ = http.NewRequest("GET", "/gophers/search?q="+url.QueryEscape("eustaquio"), nil)
+	rec = httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	var got struct {
+		Data []searchResult `json:"data"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("could not decode response: %v", err)
+	}
+	if len(got.Data) != 1 || got.Data[0].Gopher.ID != "01DCBP0R0MSNZY975ZQF1DCQCH" {
+		t.Errorf("expected the gopher added to be found, got: %+v", got.Data)
+	}
+}
+
+func TestSearchGophers_InvalidQuery(t *testing.T) {
+	s := buildServer()
+
+	for _, uri := range []string{"/gophers/search", "/gophers/search?q=%2A%2A", "/gophers/search?q=jenny&limit=0"} {
+		req, _ := http.NewRequest("GET", uri, nil)
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected %d, got: %d %s", uri, http.StatusBadRequest, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+			t.Errorf("%s: expected a problem, got: %q", uri, got)
+		}
+	}
+}
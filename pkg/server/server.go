@@ -2,32 +2,68 @@ package server
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"time"
 
-	"github.com/openzipkin/zipkin-go"
-	zipkinhttp "github.com/openzipkin/zipkin-go/middleware/http"
-
+	gopher "github.com/friendsofgo/gopherapi/pkg"
 	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+	"github.com/friendsofgo/gopherapi/pkg/exporting"
 	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/graphql"
+	"github.com/friendsofgo/gopherapi/pkg/health"
+	"github.com/friendsofgo/gopherapi/pkg/importing"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/metrics"
 	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
 	"github.com/friendsofgo/gopherapi/pkg/removing"
+	"github.com/friendsofgo/gopherapi/pkg/searching"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // server all server necessary dependencies
 type server struct {
 	serverID string
 
-	tracer *zipkin.Tracer
+	tracer tracer.Tracer
 
 	router http.Handler
 
+	authenticators []auth.Authenticator
+	policy         *auth.Policy
+	tenants        tenant.Resolver
+	trustedProxies []*net.IPNet
+	rateLimiter    ratelimit.Store
+	rateLimits     RateLimits
+	probe          *health.Probe
+	registry       *prometheus.Registry
+	logger         log.Logger
+	accessLog      AccessLog
+
+	validateRequests  bool
+	validateResponses bool
+	deprecation       Deprecation
+	graphQLLimits     graphql.Limits
+	codecs            *encoding.Registry
+	maxImportSize     int64
+
 	fetching  fetching.Service
 	adding    adding.Service
 	modifying modifying.Service
 	removing  removing.Service
+	exporting exporting.Service
+	importing importing.Service
+	searching searching.Service
 }
 
 // Server representation of gopher server
@@ -35,27 +71,168 @@ type Server interface {
 	Router() http.Handler
 	FetchGophers(w http.ResponseWriter, r *http.Request)
 	FetchGopher(w http.ResponseWriter, r *http.Request)
+	FetchGopherRevisions(w http.ResponseWriter, r *http.Request)
 	AddGopher(w http.ResponseWriter, r *http.Request)
 	ModifyGopher(w http.ResponseWriter, r *http.Request)
 	RemoveGopher(w http.ResponseWriter, r *http.Request)
+	ExportGophers(w http.ResponseWriter, r *http.Request)
+	ImportGophers(w http.ResponseWriter, r *http.Request)
+	FetchImportJob(w http.ResponseWriter, r *http.Request)
+	SearchGophers(w http.ResponseWriter, r *http.Request)
+	Liveness(w http.ResponseWriter, r *http.Request)
+	Readiness(w http.ResponseWriter, r *http.Request)
+	OpenAPI(w http.ResponseWriter, r *http.Request)
+	Docs(w http.ResponseWriter, r *http.Request)
+}
+
+// Option configures optional features of the server
+type Option func(*server)
+
+// WithAuthenticators requires every gopher request to be authenticated by
+// one of the given authenticators, tried in order
+func WithAuthenticators(authenticators ...auth.Authenticator) Option {
+	return func(s *server) {
+		s.authenticators = append(s.authenticators, authenticators...)
+	}
+}
+
+// WithPolicy restricts the gopher requests to the callers allowed by the given policy
+func WithPolicy(policy *auth.Policy) Option {
+	return func(s *server) {
+		s.policy = policy
+	}
+}
+
+// WithTenantResolver resolves the tenant of the gopher requests with the given resolver,
+// requests without tenant belong to the default one
+func WithTenantResolver(resolver tenant.Resolver) Option {
+	return func(s *server) {
+		s.tenants = resolver
+	}
+}
+
+// WithTrustedProxies takes the client IP from X-Forwarded-For when the requests come through the given proxies
+func WithTrustedProxies(proxies ...*net.IPNet) Option {
+	return func(s *server) {
+		s.trustedProxies = append(s.trustedProxies, proxies...)
+	}
+}
+
+// WithRateLimit limits the gopher requests of each client using the given store to keep count
+func WithRateLimit(store ratelimit.Store, limits RateLimits) Option {
+	return func(s *server) {
+		s.rateLimiter = store
+		s.rateLimits = limits
+	}
+}
+
+// WithHealthProbe reports the readiness of the server with the given probe
+func WithHealthProbe(probe *health.Probe) Option {
+	return func(s *server) {
+		s.probe = probe
+	}
+}
+
+// WithMetrics records the HTTP metrics in the given registry and exposes it on /metrics
+func WithMetrics(registry *prometheus.Registry) Option {
+	return func(s *server) {
+		s.registry = registry
+	}
+}
+
+// WithLogger logs the requests served and their failures with the given logger
+func WithLogger(logger log.Logger) Option {
+	return func(s *server) {
+		s.logger = logger
+	}
+}
+
+// WithAccessLog logs only the requests selected by the given config
+func WithAccessLog(config AccessLog) Option {
+	return func(s *server) {
+		s.accessLog = config
+	}
+}
+
+// WithRequestValidation rejects the requests not matching the OpenAPI document of the api
+func WithRequestValidation() Option {
+	return func(s *server) {
+		s.validateRequests = true
+	}
+}
+
+// WithResponseValidation fails the responses not matching the OpenAPI document of the api,
+// along with the requests, to catch the contract drifts in the tests
+func WithResponseValidation() Option {
+	return func(s *server) {
+		s.validateRequests = true
+		s.validateResponses = true
+	}
+}
+
+// WithDeprecation announces when the v1 api was deprecated and when it will be removed
+func WithDeprecation(deprecation Deprecation) Option {
+	return func(s *server) {
+		s.deprecation = deprecation
+	}
+}
+
+// WithGraphQLLimits bounds the depth and complexity of the GraphQL queries
+func WithGraphQLLimits(limits graphql.Limits) Option {
+	return func(s *server) {
+		s.graphQLLimits = limits
+	}
+}
+
+// WithCodecs serves the gophers in the representations of the given registry, instead
+// of the default ones
+func WithCodecs(registry *encoding.Registry) Option {
+	return func(s *server) {
+		s.codecs = registry
+	}
+}
+
+// WithMaxImportSize rejects the imports with a body larger than the given bytes,
+// instead of the default 100MB
+func WithMaxImportSize(bytes int64) Option {
+	return func(s *server) {
+		if bytes > 0 {
+			s.maxImportSize = bytes
+		}
+	}
 }
 
 // New initialize the server
 func New(
 	serverID string,
-	tracer *zipkin.Tracer,
+	tracer tracer.Tracer,
 	fS fetching.Service,
 	aS adding.Service,
 	mS modifying.Service,
 	rS removing.Service,
+	eS exporting.Service,
+	iS importing.Service,
+	sS searching.Service,
+	opts ...Option,
 ) Server {
 	a := &server{
-		serverID:  serverID,
-		tracer:    tracer,
-		fetching:  fS,
-		adding:    aS,
-		modifying: mS,
-		removing:  rS}
+		serverID:      serverID,
+		tracer:        tracer,
+		fetching:      fS,
+		adding:        aS,
+		modifying:     mS,
+		removing:      rS,
+		exporting:     eS,
+		importing:     iS,
+		searching:     sS,
+		logger:        log.NewNoopLogger(),
+		accessLog:     defaultAccessLog,
+		graphQLLimits: graphql.DefaultLimits,
+		codecs:        encoding.DefaultRegistry(),
+		maxImportSize: defaultMaxImportSize}
+	for _, opt := range opts {
+		opt(a)
+	}
 	router(a)
 
 	return a
@@ -65,45 +242,259 @@ func router(s *server) {
 	r := mux.NewRouter()
 
 	r.Use(
-		zipkinhttp.NewServerMiddleware(s.tracer),
-		newServerMiddleware(s.serverID),
+		s.tracer.Middleware,
+		newRequestIDMiddleware,
+		newServerMiddleware(s.serverID, s.trustedProxies),
 	)
 
-	r.HandleFunc("/gophers", s.FetchGophers).Methods(http.MethodGet)
-	r.HandleFunc("/gophers/{ID:[a-zA-Z0-9_]+}", s.FetchGopher).Methods(http.MethodGet)
-	r.HandleFunc("/gophers", s.AddGopher).Methods(http.MethodPost)
-	r.HandleFunc("/gophers/{ID:[a-zA-Z0-9_]+}", s.ModifyGopher).Methods(http.MethodPut)
-	r.HandleFunc("/gophers/{ID:[a-zA-Z0-9_]+}", s.RemoveGopher).Methods(http.MethodDelete)
+	r.Use(newLoggingMiddleware(s.logger, s.accessLog))
+	var httpMetrics *metrics.HTTP
+	if s.registry != nil {
+		httpMetrics = metrics.NewHTTP(s.registry)
+		r.Use(newMetricsMiddleware(httpMetrics))
+		r.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{})).Methods(http.MethodGet)
+	}
+	r.Use(newRecoveryMiddleware(s.logger, httpMetrics))
+	if s.validateRequests {
+		openAPIRouter, err := newOpenAPIRouter()
+		if err != nil {
+			// the document is embedded in the binary, and checked by the tests
+			panic(err)
+		}
+		r.Use(newValidationMiddleware(openAPIRouter, s.logger, s.validateResponses))
+	}
+
+	r.HandleFunc("/healthz", s.Liveness).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", s.Readiness).Methods(http.MethodGet)
+	r.HandleFunc("/openapi.json", s.OpenAPI).Methods(http.MethodGet)
+	r.HandleFunc("/docs", s.Docs).Methods(http.MethodGet)
+
+	// the unversioned routes serve the v1 api, unless the v2 is negotiated with the Accept header
+	g := s.gopherRouter(r, "/gophers")
+	g.Use(varyAccept)
+	// the bulk and search routes go first, so their paths aren't taken for gopher IDs,
+	// the adding service doesn't give those IDs to the gophers
+	s.handleBulkAndSearch(g, func(next http.HandlerFunc) http.HandlerFunc { return next })
+	handleGophers(g, s.v2Handlers(), acceptsVersion("2"))
+	handleGophers(g, s.v1Handlers())
+
+	v1 := s.gopherRouter(r, "/v1/gophers")
+	v1.Use(varyAccept)
+	s.handleBulkAndSearch(v1, s.deprecation.deprecated)
+	handleGophers(v1, s.v1Handlers())
+
+	v2 := s.gopherRouter(r, "/v2/gophers")
+	s.handleBulkAndSearch(v2, func(next http.HandlerFunc) http.HandlerFunc { return next })
+	handleGophers(v2, s.v2Handlers())
+
+	graphQL, err := graphql.NewHandler(s.fetching, s.adding, s.modifying, s.removing, s.logger, s.graphQLLimits)
+	if err != nil {
+		// the schema is built from code, and checked by the tests
+		panic(err)
+	}
+	s.gopherRouter(r, "/graphql").Handle("", graphQL).Methods(http.MethodGet, http.MethodPost).Name(routeGraphQL)
 
 	s.router = r
 }
 
+// gopherRouter mounts the gopher routes on the given prefix, behind the auth, tenant and rate limit middlewares
+func (s *server) gopherRouter(r *mux.Router, prefix string) *mux.Router {
+	g := r.PathPrefix(prefix).Subrouter()
+	if s.rateLimiter != nil && s.rateLimits.IP.Burst > 0 {
+		g.Use(newIPRateLimitMiddleware(s.rateLimiter, s.rateLimits.IP, s.logger))
+	}
+	if len(s.authenticators) > 0 {
+		g.Use(newAuthMiddleware(s.authenticators))
+	}
+	if s.policy != nil {
+		g.Use(newAuthorizationMiddleware(s.policy))
+	}
+	g.Use(newTenantMiddleware(s.tenants, s.logger))
+	if s.rateLimiter != nil {
+		g.Use(newRateLimitMiddleware(s.rateLimiter, s.rateLimits, s.logger))
+	}
+	return g
+}
+
+// handleBulkAndSearch routes the export, import and search requests to their handlers, decorated
+func (s *server) handleBulkAndSearch(g *mux.Router, decorate func(http.HandlerFunc) http.HandlerFunc) {
+	g.HandleFunc("/export", decorate(s.ExportGophers)).Methods(http.MethodGet).Name(routeExportGophers)
+	g.HandleFunc("/import", decorate(s.ImportGophers)).Methods(http.MethodPost).Name(routeImportGophers)
+	g.HandleFunc("/import/{ID:[a-zA-Z0-9_]+}", decorate(s.FetchImportJob)).Methods(http.MethodGet).Name(routeFetchImportJob)
+	g.HandleFunc("/search", decorate(s.SearchGophers)).Methods(http.MethodGet).Name(routeSearchGophers)
+}
+
+// gopherHandlers are the handlers of a version of the gopher api
+type gopherHandlers struct {
+	fetchGophers, fetchGopher, fetchGopherRevisions, addGopher, modifyGopher, removeGopher http.HandlerFunc
+}
+
+// v1Handlers returns the handlers of the deprecated v1 api
+func (s *server) v1Handlers() gopherHandlers {
+	return gopherHandlers{
+		fetchGophers:         s.deprecation.deprecated(s.FetchGophers),
+		fetchGopher:          s.deprecation.deprecated(s.FetchGopher),
+		fetchGopherRevisions: s.deprecation.deprecated(s.FetchGopherRevisions),
+		addGopher:            s.deprecation.deprecated(s.AddGopher),
+		modifyGopher:         s.deprecation.deprecated(s.ModifyGopher),
+		removeGopher:         s.deprecation.deprecated(s.RemoveGopher),
+	}
+}
+
+// v2Handlers returns the handlers of the v2 api
+func (s *server) v2Handlers() gopherHandlers {
+	return gopherHandlers{
+		fetchGophers:         s.fetchGophersV2,
+		fetchGopher:          s.fetchGopherV2,
+		fetchGopherRevisions: s.fetchGopherRevisionsV2,
+		addGopher:            s.addGopherV2,
+		modifyGopher:         s.modifyGopherV2,
+		removeGopher:         s.removeGopherV2,
+	}
+}
+
+// handleGophers routes the gopher requests matching the given matchers to the given handlers,
+// the routes keep the same names in every version so they share permissions and rate limits
+func handleGophers(g *mux.Router, h gopherHandlers, matchers ...mux.MatcherFunc) {
+	handle := func(path, method, name string, handler http.HandlerFunc) {
+		route := g.HandleFunc(path, handler).Methods(method).Name(name)
+		for _, matcher := range matchers {
+			route.MatcherFunc(matcher)
+		}
+	}
+
+	handle("", http.MethodGet, routeFetchGophers, h.fetchGophers)
+	handle("/{ID:[a-zA-Z0-9_]+}", http.MethodGet, routeFetchGopher, h.fetchGopher)
+	handle("/{ID:[a-zA-Z0-9_]+}/revisions", http.MethodGet, routeFetchGopherRevisions, h.fetchGopherRevisions)
+	handle("", http.MethodPost, routeAddGopher, h.addGopher)
+	handle("/{ID:[a-zA-Z0-9_]+}", http.MethodPut, routeModifyGopher, h.modifyGopher)
+	handle("/{ID:[a-zA-Z0-9_]+}", http.MethodDelete, routeRemoveGopher, h.removeGopher)
+}
+
 func (s *server) Router() http.Handler {
 	return s.router
 }
 
-// FetchGophers return a list of all gophers
+// FetchGophers return a list of all gophers, in the representation negotiated
 func (s *server) FetchGophers(w http.ResponseWriter, r *http.Request) {
-	gophers, _ := s.fetching.FetchGophers(r.Context())
-
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(gophers)
+	codec, ok := s.negotiate(w, r)
+	if !ok {
+		return
+	}
 
+	started, err := writeGophers(w, codec, func(fn func(gopher.Gopher) error) error {
+		return s.fetching.StreamGophers(r.Context(), fn)
+	})
+	if err != nil && !started {
+		if writeAuthorizationError(w, err) {
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode("Error fetching the gophers")
+		return
+	}
+	if err != nil {
+		// the response is already started, the callers only get it cut short
+		s.logger.UnexpectedError(r.Context(), fmt.Errorf("fetching the gophers: %w", err))
+	}
 }
 
-// FetchGopher return a gopher by ID
+// FetchGopher return a gopher by ID, or as it was at the moment given by the as_of parameter,
+// in the representation negotiated
 func (s *server) FetchGopher(w http.ResponseWriter, r *http.Request) {
+	codec, ok := s.negotiate(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
-	gopher := s.fetching.FetchGopherByID(r.Context(), vars["ID"])
 	w.Header().Set("Content-Type", "application/json")
-	if gopher == nil {
+
+	if asOf := r.URL.Query().Get("as_of"); asOf != "" {
+		s.fetchGopherAsOf(w, r, codec, vars["ID"], asOf)
+		return
+	}
+
+	g, err := s.fetching.FetchGopherByID(r.Context(), vars["ID"])
+	if writeAuthorizationError(w, err) {
+		return
+	}
+	if errors.Is(err, gopher.ErrNotFound) {
 		w.WriteHeader(http.StatusNotFound)
 		_ = json.NewEncoder(w).Encode("Gopher Not found")
 		return
 	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode("Can't fetch the gopher")
+		return
+	}
 
-	_ = json.NewEncoder(w).Encode(gopher)
+	_ = writeGopher(w, codec, *g)
+}
 
+func (s *server) fetchGopherAsOf(w http.ResponseWriter, r *http.Request, codec encoding.Codec, ID, asOf string) {
+	at, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		s.logger.InvalidRequest(r.Context(), fmt.Errorf("invalid as_of: %w", err))
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode("as_of must be a RFC3339 date")
+		return
+	}
+
+	g, err := s.fetching.FetchGopherAsOf(r.Context(), ID, at)
+	if writeAuthorizationError(w, err) {
+		return
+	}
+	if errors.Is(err, gopher.ErrRevisionsNotSupported) {
+		w.WriteHeader(http.StatusNotImplemented)
+		_ = json.NewEncoder(w).Encode("Revisions not supported")
+		return
+	}
+	if errors.Is(err, gopher.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode("Gopher Not found")
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode("Error fetching the gopher")
+		return
+	}
+
+	_ = writeGopher(w, codec, *g)
+}
+
+// FetchGopherRevisions return the revision history of a gopher
+func (s *server) FetchGopherRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	revisions, err := s.fetching.FetchGopherRevisions(r.Context(), vars["ID"])
+	if writeAuthorizationError(w, err) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if errors.Is(err, gopher.ErrRevisionsNotSupported) {
+		w.WriteHeader(http.StatusNotImplemented)
+		_ = json.NewEncoder(w).Encode("Revisions not supported")
+		return
+	}
+	if errors.Is(err, gopher.ErrNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode("Gopher Not found")
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode("Error fetching the revisions of the gopher")
+		return
+	}
+
+	_ = json.NewEncoder(w).Encode(revisions)
 }
 
 type addGopherRequest struct {
@@ -113,21 +504,30 @@ type addGopherRequest struct {
 	Age   int    `json:"age"`
 }
 
-// AddGopher save a gopher
+// AddGopher save a gopher, read in the representation of the Content-Type
 func (s *server) AddGopher(w http.ResponseWriter, r *http.Request) {
-	decoder := json.NewDecoder(r.Body)
-
-	var g addGopherRequest
-	err := decoder.Decode(&g)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode("Error unmarshalling request body")
+	var g gopher.Gopher
+	if !s.decodeGopher(w, r, &g) {
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
 	if err := s.adding.AddGopher(r.Context(), g.ID, g.Name, g.Image, g.Age); err != nil {
-		log.Println(err)
+		if writeAuthorizationError(w, err) {
+			return
+		}
+		if errors.Is(err, gopher.ErrAlreadyExists) {
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode("Gopher already exists")
+			return
+		}
+		if errors.Is(err, gopher.ErrReservedID) {
+			s.logger.InvalidRequest(r.Context(), err)
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode("The gopher ID is reserved")
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode("Can't create a gopher")
 		return
@@ -142,21 +542,25 @@ type modifyGopherRequest struct {
 	Age   int    `json:"age"`
 }
 
-// ModifyGopher modify gopher data
+// ModifyGopher modify gopher data, read in the representation of the Content-Type
 func (s *server) ModifyGopher(w http.ResponseWriter, r *http.Request) {
-	decoder := json.NewDecoder(r.Body)
-
-	var g addGopherRequest
-	err := decoder.Decode(&g)
-
-	w.Header().Set("Content-Type", "application/json")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode("Error unmarshalling request body")
+	var g gopher.Gopher
+	if !s.decodeGopher(w, r, &g) {
 		return
 	}
+
+	w.Header().Set("Content-Type", "application/json")
 	vars := mux.Vars(r)
 	if err := s.modifying.ModifyGopher(r.Context(), vars["ID"], g.Name, g.Image, g.Age); err != nil {
+		if writeAuthorizationError(w, err) {
+			return
+		}
+		if errors.Is(err, gopher.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode("Gopher Not found")
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
 		w.WriteHeader(http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode("Can't modify a gopher")
 		return
@@ -169,7 +573,10 @@ func (s *server) ModifyGopher(w http.ResponseWriter, r *http.Request) {
 func (s *server) RemoveGopher(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
-	_ = s.removing.RemoveGopher(r.Context(), vars["ID"])
+	err := s.removing.RemoveGopher(r.Context(), vars["ID"])
+	if writeAuthorizationError(w, err) {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 
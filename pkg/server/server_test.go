@@ -7,15 +7,20 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/friendsofgo/gopherapi/pkg/log"
 	"github.com/friendsofgo/gopherapi/pkg/removing"
 	"github.com/friendsofgo/gopherapi/pkg/tracer"
 
 	"github.com/friendsofgo/gopherapi/pkg/adding"
+	"github.com/friendsofgo/gopherapi/pkg/exporting"
 	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/importing"
 	"github.com/friendsofgo/gopherapi/pkg/modifying"
+	"github.com/friendsofgo/gopherapi/pkg/searching"
 
 	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
 	gopher "github.com/friendsofgo/gopherapi/pkg"
@@ -132,6 +137,60 @@ func TestAddGopher(t *testing.T) {
 	}
 }
 
+func TestAddGopher_AlreadyExists(t *testing.T) {
+	bodyJSON := []byte(`{"ID": "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "name": "Jenny", "age": 18}`)
+	req, err := http.NewRequest("POST", "/gophers", bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	s := buildServer()
+	rec := httptest.NewRecorder()
+
+	s.AddGopher(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("expected %d, got: %d", http.StatusConflict, res.StatusCode)
+	}
+}
+
+func TestAddGopher_ReservedID(t *testing.T) {
+	bodyJSON := []byte(`{"ID": "search", "name": "Jenny", "age": 18}`)
+	req, err := http.NewRequest("POST", "/gophers", bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	s := buildServer()
+	rec := httptest.NewRecorder()
+
+	s.AddGopher(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected %d, got: %d", http.StatusBadRequest, res.StatusCode)
+	}
+}
+
+func TestAddGopher_MalformedBody(t *testing.T) {
+	bodyJSON := []byte(`{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": `)
+	req, err := http.NewRequest("POST", "/gophers", bytes.NewBuffer(bodyJSON))
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	s := buildServer()
+	rec := httptest.NewRecorder()
+
+	s.AddGopher(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected %d, got: %d", http.StatusBadRequest, res.StatusCode)
+	}
+}
+
 func TestModifyGopher(t *testing.T) {
 	bodyJSON := []byte(`{
         "name": "Eustaqio",
@@ -145,7 +204,7 @@ func TestModifyGopher(t *testing.T) {
 	s := buildServer()
 	rec := httptest.NewRecorder()
 
-	s.ModifyGopher(rec, req)
+	s.Router().ServeHTTP(rec, req)
 	res := rec.Result()
 	defer res.Body.Close()
 
@@ -176,6 +235,78 @@ func TestRemoveGopher(t *testing.T) {
 
 }
 
+func TestFetchGopherRevisions(t *testing.T) {
+	s := buildServer()
+	g := gopherSample()
+
+	body := []byte(`{"name": "Jenny", "image": "https://via.placeholder.com/150.png", "age": 19}`)
+	req, err := http.NewRequest("PUT", fmt.Sprintf("/gophers/%s", g.ID), bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+	s.Router().ServeHTTP(httptest.NewRecorder(), req)
+
+	req, err = http.NewRequest("GET", fmt.Sprintf("/gophers/%s/revisions", g.ID), nil)
+	if err != nil {
+		t.Fatalf("could not created request: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	res := rec.Result()
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected %d, got: %d", http.StatusOK, res.StatusCode)
+	}
+
+	var got []gopher.Revision
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatalf("could not unmarshall response %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 revisions, got: %d", len(got))
+	}
+	if got[0].Gopher != *g {
+		t.Errorf("expected first revision %v, got: %v", g, got[0].Gopher)
+	}
+	if got[1].Number != 2 || got[1].Gopher.Age != 19 {
+		t.Errorf("expected second revision with age 19, got: %v", got[1])
+	}
+}
+
+func TestFetchGopherAsOf(t *testing.T) {
+	testData := []struct {
+		name   string
+		asOf   string
+		status int
+	}{
+		{name: "gopher found", asOf: time.Now().Add(time.Hour).Format(time.RFC3339), status: http.StatusOK},
+		{name: "gopher not yet created", asOf: time.Now().Add(-time.Hour).Format(time.RFC3339), status: http.StatusNotFound},
+		{name: "invalid date", asOf: "yesterday", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			uri := fmt.Sprintf("/gophers/%s?as_of=%s", gopherSample().ID, url.QueryEscape(tt.asOf))
+			req, err := http.NewRequest("GET", uri, nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			buildServer().Router().ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+			if tt.status != res.StatusCode {
+				t.Errorf("expected %d, got: %d", tt.status, res.StatusCode)
+			}
+		})
+	}
+}
+
 func gopherSample() *gopher.Gopher {
 	return &gopher.Gopher{
 		ID:    "01D3XZ3ZHCP3KG9VT4FGAD8KDR",
@@ -185,14 +316,24 @@ func gopherSample() *gopher.Gopher {
 	}
 }
 
-func buildServer() Server {
+func buildServer(opts ...Option) Server {
 
 	noopTracer := tracer.NewNoopTracer()
-	repo := inmem.NewRepository(sample.Gophers, tracer.NewNoopTracer())
+	gophers := make(map[string]gopher.Gopher, len(sample.Gophers))
+	for ID, g := range sample.Gophers {
+		gophers[ID] = g
+	}
+
+	repo := inmem.NewRepository(gophers)
+	index := searching.NewMemoryIndex(repo)
+	repo = searching.NewRepository(repo, index)
 	fS := fetching.NewService(repo, log.NewNoopLogger())
 	aS := adding.NewService(repo)
 	mS := modifying.NewService(repo)
 	rS := removing.NewService(repo)
+	eS := exporting.NewService(repo)
+	iS := importing.NewService(repo)
+	sS := searching.NewService(index)
 
-	return New("test", noopTracer, fS, aS, mS, rS)
+	return New("test", noopTracer, fS, aS, mS, rS, eS, iS, sS, opts...)
 }
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+// newTenantMiddleware scopes the request to its tenant, the authenticated callers are bound to
+// the tenant they claim, or the default one, and can't ask for a different one, only the
+// anonymous requests choose their tenant
+func newTenantMiddleware(resolver tenant.Resolver, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ID, _ := resolver.Resolve(r)
+
+			if p, ok := auth.PrincipalFromContext(r.Context()); ok {
+				if ID != "" && ID != p.TenantID() {
+					writeTenantError(w, http.StatusForbidden, "Tenant not allowed")
+					return
+				}
+				ID = p.TenantID()
+			}
+
+			if ID == "" {
+				ID = tenant.Default
+			}
+			if !tenant.Valid(ID) {
+				logger.InvalidRequest(r.Context(), fmt.Errorf("invalid tenant %q", ID))
+				writeTenantError(w, http.StatusBadRequest, "Invalid tenant")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), ID)))
+		})
+	}
+}
+
+func writeTenantError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(message)
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	apiKeys, err := auth.NewAPIKeyAuthenticator([]auth.APIKey{
+		{Name: "acme", Hash: auth.HashAPIKey("acme"), Tenant: "acme"},
+		{Name: "anyone", Hash: auth.HashAPIKey("anyone")},
+	})
+	if err != nil {
+		t.Fatalf("could not create authenticator: %v", err)
+	}
+	resolver := tenant.Resolver{Header: "X-Tenant-ID", Domain: "gophers.test"}
+	s := buildServer(WithAuthenticators(apiKeys), WithTenantResolver(resolver))
+	anonymous := buildServer(WithTenantResolver(resolver))
+
+	body := []byte(`{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}`)
+	req, _ := http.NewRequest("POST", "/gophers", bytes.NewBuffer(body))
+	req.Header.Set("X-API-Key", "acme")
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got: %d", http.StatusCreated, rec.Code)
+	}
+
+	req, _ = http.NewRequest("POST", "/gophers", bytes.NewBuffer(body))
+	req.Header.Set("X-Tenant-ID", "acme")
+	rec = httptest.NewRecorder()
+	anonymous.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got: %d", http.StatusCreated, rec.Code)
+	}
+
+	testData := []struct {
+		name     string
+		apiKey   string
+		header   string
+		host     string
+		status   int
+		expected int
+	}{
+		{name: "tenant from api key", apiKey: "acme", status: http.StatusOK, expected: 1},
+		{name: "api key without tenant", apiKey: "anyone", status: http.StatusOK, expected: len(sample.Gophers)},
+		{name: "header not matching api key", apiKey: "acme", header: "globex", status: http.StatusForbidden},
+		{name: "header chosen by api key without tenant", apiKey: "anyone", header: "acme", status: http.StatusForbidden},
+		{name: "subdomain chosen by api key without tenant", apiKey: "anyone", host: "acme.gophers.test", status: http.StatusForbidden},
+		{name: "default tenant header of api key without tenant", apiKey: "anyone", header: tenant.Default, status: http.StatusOK, expected: len(sample.Gophers)},
+		{name: "anonymous tenant from header", header: "acme", status: http.StatusOK, expected: 1},
+		{name: "anonymous tenant from subdomain", host: "acme.gophers.test", status: http.StatusOK, expected: 1},
+		{name: "anonymous default tenant", status: http.StatusOK, expected: len(sample.Gophers)},
+		{name: "anonymous other tenant", header: "globex", status: http.StatusOK, expected: 0},
+		{name: "anonymous invalid tenant", header: "acme:*", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/gophers", nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			server := anonymous
+			if tt.apiKey != "" {
+				server = s
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+
+			rec := httptest.NewRecorder()
+			server.Router().ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got: %d", tt.status, rec.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var got []gopher.Gopher
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("could not unmarshall response %v", err)
+			}
+			if len(got) != tt.expected {
+				t.Errorf("expected %d gophers, got: %d", tt.expected, len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertificateReloader serves a certificate loaded from disk, reloading it
+// when its files change so renewed certificates are served without restarts
+type CertificateReloader struct {
+	certFile, keyFile string
+
+	mtx      sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+// NewCertificateReloader loads the given certificate and key files
+func NewCertificateReloader(certFile, keyFile string) (*CertificateReloader, error) {
+	r := &CertificateReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate satisfies the tls.Config GetCertificate callback
+func (r *CertificateReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if r.changed() {
+		// keep serving the previous certificate while the new one is only half written
+		_ = r.reload()
+	}
+
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.cert, nil
+}
+
+func (r *CertificateReloader) changed() bool {
+	modTimes, err := r.stat()
+	if err != nil {
+		return false
+	}
+
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return modTimes != r.modTimes
+}
+
+func (r *CertificateReloader) reload() error {
+	modTimes, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading certificate %s: %w", r.certFile, err)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.cert = &cert
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *CertificateReloader) stat() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// NewTLSConfig creates the TLS configuration of the server, when a client CA bundle is given
+// the client certificates are verified against it, and required if requireClientCert is set
+func NewTLSConfig(reloader *CertificateReloader, clientCAFile string, requireClientCert bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if clientCAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+	}
+
+	cfg.ClientCAs = pool
+	cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	if requireClientCert {
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
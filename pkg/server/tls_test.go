@@ -0,0 +1,162 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCertificate(t, "gopherapi test CA", nil, nil)
+	serverCert := newTestCertificate(t, "localhost", nil, &ca)
+	clientCert := newTestCertificate(t, "jenny", []string{"writer"}, &ca)
+
+	certFile, keyFile, caFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.crt")
+	serverCert.write(t, certFile, keyFile)
+	ca.write(t, caFile, filepath.Join(dir, "ca.key"))
+
+	reloader, err := NewCertificateReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("could not load certificate: %v", err)
+	}
+	tlsConfig, err := NewTLSConfig(reloader, caFile, false)
+	if err != nil {
+		t.Fatalf("could not create tls config: %v", err)
+	}
+
+	var principal auth.Principal
+	handler := newAuthMiddleware([]auth.Authenticator{auth.NewClientCertAuthenticator()})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, _ = Principal(r.Context())
+			_ = json.NewEncoder(w).Encode(principal.Subject)
+		}),
+	)
+	// httptest would serve its own certificate, so the server is started by hand
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", tlsConfig)
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	srv := &http.Server{Handler: handler, ErrorLog: log.New(io.Discard, "", 0)}
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Close()
+	url := "https://" + listener.Addr().String()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	// WHEN the client presents a certificate signed by the CA THEN its subject is the principal
+	res, err := newTLSClient(roots, &clientCert).Get(url)
+	if err != nil {
+		t.Fatalf("could not send request: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected %d, got: %d", http.StatusOK, res.StatusCode)
+	}
+	if principal.Subject != "jenny" || principal.Method != "mtls" || len(principal.Roles) != 1 || principal.Roles[0] != "writer" {
+		t.Errorf("unexpected principal %v", principal)
+	}
+
+	// WHEN the client presents no certificate THEN the request is unauthorized
+	res, err = newTLSClient(roots, nil).Get(url)
+	if err != nil {
+		t.Fatalf("could not send request: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected %d, got: %d", http.StatusUnauthorized, res.StatusCode)
+	}
+
+	// WHEN the certificate files are renewed THEN the new certificate is served
+	renewed := newTestCertificate(t, "localhost", nil, &ca)
+	renewed.write(t, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+	_ = os.Chtimes(keyFile, future, future)
+
+	res, err = newTLSClient(roots, &clientCert).Get(url)
+	if err != nil {
+		t.Fatalf("could not send request: %v", err)
+	}
+	res.Body.Close()
+	if served := res.TLS.PeerCertificates[0]; !served.Equal(renewed.cert) {
+		t.Errorf("expected renewed certificate with serial %v, got: %v", renewed.cert.SerialNumber, served.SerialNumber)
+	}
+}
+
+type testCertificate struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCertificate(t *testing.T, commonName string, organizationalUnits []string, issuer *testCertificate) testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	serial, _ := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName, OrganizationalUnit: organizationalUnits},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	parent, signer := template, key
+	if issuer == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		parent, signer = issuer.cert, issuer.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatalf("could not create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return testCertificate{cert: cert, key: key}
+}
+
+func (c testCertificate) write(t *testing.T, certFile, keyFile string) {
+	key, err := x509.MarshalECPrivateKey(c.key)
+	if err != nil {
+		t.Fatalf("could not marshal key: %v", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600); err != nil {
+		t.Fatalf("could not write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: key}), 0600); err != nil {
+		t.Fatalf("could not write key: %v", err)
+	}
+}
+
+func newTLSClient(roots *x509.CertPool, cert *testCertificate) *http.Client {
+	cfg := &tls.Config{RootCAs: roots}
+	if cert != nil {
+		cfg.Certificates = []tls.Certificate{{Certificate: [][]byte{cert.cert.Raw}, PrivateKey: cert.key}}
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, DisableKeepAlives: true}}
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"container/heap"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+// Declare the page sizes of the v2 gopher list
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// gopherV2 is the v2 representation of a gopher, with its timestamps
+type gopherV2 struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Image     string     `json:"image"`
+	Age       int        `json:"age"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func newGopherV2(g gopher.Gopher) gopherV2 {
+	return gopherV2{
+		ID:        g.ID,
+		Name:      g.Name,
+		Image:     g.Image,
+		Age:       g.Age,
+		CreatedAt: g.CreatedAt,
+		UpdatedAt: g.UpdatedAt,
+	}
+}
+
+type revisionV2 struct {
+	Number    int       `json:"revision"`
+	Gopher    gopherV2  `json:"gopher"`
+	Deleted   bool      `json:"deleted,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type pagination struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Total  int `json:"total"`
+}
+
+// page is the envelope of the v2 lists
+type page struct {
+	Data       interface{} `json:"data"`
+	Pagination *pagination `json:"pagination,omitempty"`
+}
+
+// fetchGophersV2 return a page of the gophers, sorted by ID
+func (s *server) fetchGophersV2(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit", defaultPageLimit, 1, maxPageLimit)
+	if err != nil {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+	offset, err := queryInt(r, "offset", 0, 0, -1)
+	if err != nil {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+
+	// only the gophers up to the end of the page are kept while they are read
+	var (
+		first firstGophers
+		total int
+	)
+	err = s.fetching.StreamGophers(r.Context(), func(g gopher.Gopher) error {
+		total++
+		first.keep(g, offset+limit)
+		return nil
+	})
+	if writeAuthorizationProblem(w, r, err) {
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't fetch the gophers")
+		return
+	}
+	sort.Slice(first, func(i, j int) bool { return first[i].ID < first[j].ID })
+
+	data := make([]gopherV2, 0, limit)
+	for i := offset; i < len(first); i++ {
+		data = append(data, newGopherV2(first[i]))
+	}
+
+	writeV2(w, http.StatusOK, page{
+		Data:       data,
+		Pagination: &pagination{Limit: limit, Offset: offset, Total: total},
+	})
+}
+
+// firstGophers keeps the gophers with the lowest IDs, in a heap with the highest ID on top
+type firstGophers []gopher.Gopher
+
+// keep adds the given gopher while there are less than n, or replaces the top one when its ID is lower
+func (f *firstGophers) keep(g gopher.Gopher, n int) {
+	if len(*f) < n {
+		heap.Push(f, g)
+		return
+	}
+	if n > 0 && g.ID < (*f)[0].ID {
+		(*f)[0] = g
+		heap.Fix(f, 0)
+	}
+}
+
+func (f firstGophers) Len() int            { return len(f) }
+func (f firstGophers) Less(i, j int) bool  { return f[i].ID > f[j].ID }
+func (f firstGophers) Swap(i, j int)       { f[i], f[j] = f[j], f[i] }
+func (f *firstGophers) Push(x interface{}) { *f = append(*f, x.(gopher.Gopher)) }
+func (f *firstGophers) Pop() interface{} {
+	old := *f
+	g := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return g
+}
+
+// fetchGopherV2 return a gopher by ID, or as it was at the moment given by the as_of parameter
+func (s *server) fetchGopherV2(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["ID"]
+
+	var (
+		g   *gopher.Gopher
+		err error
+	)
+	asOf := r.URL.Query().Get("as_of")
+	if asOf != "" {
+		at, parseErr := time.Parse(time.RFC3339, asOf)
+		if parseErr != nil {
+			s.invalidRequestV2(w, r, fmt.Errorf("as_of must be a RFC3339 date: %w", parseErr))
+			return
+		}
+		g, err = s.fetching.FetchGopherAsOf(r.Context(), ID, at)
+	} else {
+		g, err = s.fetching.FetchGopherByID(r.Context(), ID)
+	}
+
+	if writeAuthorizationProblem(w, r, err) || writeRevisionsProblem(w, r, err) || writeNotFoundProblem(w, r, err, ID) {
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't fetch the gopher")
+		return
+	}
+
+	writeV2(w, http.StatusOK, newGopherV2(*g))
+}
+
+// fetchGopherRevisionsV2 return the revision history of a gopher
+func (s *server) fetchGopherRevisionsV2(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["ID"]
+	revisions, err := s.fetching.FetchGopherRevisions(r.Context(), ID)
+	if writeAuthorizationProblem(w, r, err) || writeRevisionsProblem(w, r, err) || writeNotFoundProblem(w, r, err, ID) {
+		return
+	}
+	if err != nil {
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't fetch the revisions of the gopher")
+		return
+	}
+
+	data := make([]revisionV2, 0, len(revisions))
+	for _, revision := range revisions {
+		data = append(data, revisionV2{
+			Number:    revision.Number,
+			Gopher:    newGopherV2(revision.Gopher),
+			Deleted:   revision.Deleted,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+	writeV2(w, http.StatusOK, page{Data: data})
+}
+
+// addGopherV2 save a gopher, answering with its location
+func (s *server) addGopherV2(w http.ResponseWriter, r *http.Request) {
+	var g addGopherRequest
+	if err := decodeV2(r, &g); err != nil {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+
+	if err := s.adding.AddGopher(r.Context(), g.ID, g.Name, g.Image, g.Age); err != nil {
+		if writeAuthorizationProblem(w, r, err) {
+			return
+		}
+		if errors.Is(err, gopher.ErrAlreadyExists) {
+			writeProblem(w, r, http.StatusConflict, fmt.Sprintf("gopher %s already exists", g.ID))
+			return
+		}
+		if errors.Is(err, gopher.ErrReservedID) {
+			s.invalidRequestV2(w, r, err)
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't create the gopher")
+		return
+	}
+
+	w.Header().Set("Location", "/v2/gophers/"+g.ID)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// modifyGopherV2 modify gopher data
+func (s *server) modifyGopherV2(w http.ResponseWriter, r *http.Request) {
+	var g modifyGopherRequest
+	if err := decodeV2(r, &g); err != nil {
+		s.invalidRequestV2(w, r, err)
+		return
+	}
+
+	ID := mux.Vars(r)["ID"]
+	if err := s.modifying.ModifyGopher(r.Context(), ID, g.Name, g.Image, g.Age); err != nil {
+		if writeAuthorizationProblem(w, r, err) || writeNotFoundProblem(w, r, err, ID) {
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't modify the gopher")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeGopherV2 remove a gopher
+func (s *server) removeGopherV2(w http.ResponseWriter, r *http.Request) {
+	ID := mux.Vars(r)["ID"]
+	if err := s.removing.RemoveGopher(r.Context(), ID); err != nil {
+		if writeAuthorizationProblem(w, r, err) || writeNotFoundProblem(w, r, err, ID) {
+			return
+		}
+		s.logger.UnexpectedError(r.Context(), err)
+		writeProblem(w, r, http.StatusInternalServerError, "can't remove the gopher")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) invalidRequestV2(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.InvalidRequest(r.Context(), err)
+	writeProblem(w, r, http.StatusBadRequest, err.Error())
+}
+
+// decodeV2 reads the given v2 representation, rejecting the fields it doesn't know
+func decodeV2(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+// writeV2 writes the given v2 representation
+func writeV2(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", contentTypeV2)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeAuthorizationProblem writes the problem for the errors of the auth package, and
+// reports whether the given error was one of them
+func writeAuthorizationProblem(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		writeProblem(w, r, http.StatusUnauthorized, "")
+	case errors.Is(err, auth.ErrForbidden):
+		writeProblem(w, r, http.StatusForbidden, "")
+	default:
+		return false
+	}
+	return true
+}
+
+// writeNotFoundProblem writes the problem for the missing gophers, and reports
+// whether the given error was that one
+func writeNotFoundProblem(w http.ResponseWriter, r *http.Request, err error, ID string) bool {
+	if !errors.Is(err, gopher.ErrNotFound) {
+		return false
+	}
+	writeProblem(w, r, http.StatusNotFound, fmt.Sprintf("gopher %s not found", ID))
+	return true
+}
+
+// writeRevisionsProblem writes the problem for the storages without revisions, and
+// reports whether the given error was that one
+func writeRevisionsProblem(w http.ResponseWriter, r *http.Request, err error) bool {
+	if !errors.Is(err, gopher.ErrRevisionsNotSupported) {
+		return false
+	}
+	writeProblem(w, r, http.StatusNotImplemented, err.Error())
+	return true
+}
+
+// queryInt parses the given integer query parameter, between min and max if max isn't negative
+func queryInt(r *http.Request, name string, defaultValue, min, max int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min || (max >= 0 && n > max) {
+		if max >= 0 {
+			return 0, fmt.Errorf("%s must be an integer between %d and %d", name, min, max)
+		}
+		return 0, fmt.Errorf("%s must be an integer from %d", name, min)
+	}
+	return n, nil
+}
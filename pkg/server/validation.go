@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+
+	"github.com/friendsofgo/gopherapi/api"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/encoding"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+func init() {
+	// the callers are told which value is wrong, without dumping the whole schema
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	for _, codec := range encoding.DefaultRegistry().Codecs() {
+		if codec != encoding.JSON {
+			openapi3filter.RegisterBodyDecoder(codec.MediaType(), codecBodyDecoder(codec))
+		}
+	}
+}
+
+// codecBodyDecoder reads the gophers in the representation of the given codec as the JSON
+// values they stand for, so every representation is checked against the same schemas
+func codecBodyDecoder(codec encoding.Codec) openapi3filter.BodyDecoder {
+	return func(body io.Reader, _ http.Header, schema *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (interface{}, error) {
+		if schema == nil || schema.Value == nil || !schema.Value.Type.Is(openapi3.TypeArray) {
+			var g gopher.Gopher
+			if err := codec.Decode(body, &g); err != nil {
+				return nil, err
+			}
+			return gopherValue(g), nil
+		}
+
+		values := []interface{}{}
+		d := codec.NewListDecoder(body)
+		for {
+			var g gopher.Gopher
+			err := d.Decode(&g)
+			if err == io.EOF {
+				return values, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, gopherValue(g))
+		}
+	}
+}
+
+// gopherValue returns the JSON value of the given gopher, without the fields left empty
+// as the representations without types can't tell them from the missing ones
+func gopherValue(g gopher.Gopher) map[string]interface{} {
+	value := make(map[string]interface{})
+	for name, field := range map[string]string{"ID": g.ID, "name": g.Name, "image": g.Image} {
+		if field != "" {
+			value[name] = field
+		}
+	}
+	if g.Age != 0 {
+		value["age"] = g.Age
+	}
+	return value
+}
+
+// streamedOperations are the operations whose request bodies aren't read by the validation
+var streamedOperations = map[string]bool{"importGophers": true}
+
+// newOpenAPIRouter finds the operations of the OpenAPI document matched by the requests
+func newOpenAPIRouter() (routers.Router, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(api.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("loading the OpenAPI document: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("validating the OpenAPI document: %w", err)
+	}
+	// the requests are matched whatever the host the server is reached by
+	doc.Servers = nil
+
+	return gorillamux.NewRouter(doc)
+}
+
+// newValidationMiddleware rejects the requests not matching the OpenAPI document with a 400,
+// when validateResponses is set the responses are checked too, to catch the contract drifts in tests
+func newValidationMiddleware(router routers.Router, logger log.Logger, validateResponses bool) func(http.Handler) http.Handler {
+	options := &openapi3filter.Options{
+		// the callers are authenticated by the auth middleware, with the scopes of the policy
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+	// the streamed bodies can be too large to be read in memory, their rows are validated
+	// while they are imported instead
+	streamedOptions := *options
+	streamedOptions.ExcludeRequestBody = true
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				// not described by the document, like the routes not found
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if streamedOperations[route.Operation.OperationID] {
+				input.Options = &streamedOptions
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				logger.InvalidRequest(r.Context(), err)
+				writeProblem(w, r, http.StatusBadRequest, err.Error())
+				return
+			}
+
+			if !validateResponses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := newResponseBuffer()
+			next.ServeHTTP(buf, r)
+
+			output := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 buf.status,
+				Header:                 buf.header,
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true},
+			}
+			if err := openapi3filter.ValidateResponse(r.Context(), output.SetBodyBytes(buf.body.Bytes())); err != nil {
+				err = fmt.Errorf("response of %s %s doesn't match the OpenAPI document: %w", r.Method, route.Path, err)
+				logger.UnexpectedError(r.Context(), err)
+				writeProblem(w, r, http.StatusInternalServerError, err.Error())
+				return
+			}
+			buf.writeTo(w)
+		})
+	}
+}
+
+// responseBuffer holds the response of the handlers until it's validated
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header), status: http.StatusOK}
+}
+
+// Header implements http.ResponseWriter
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (b *responseBuffer) WriteHeader(status int) {
+	b.status = status
+}
+
+// Write implements http.ResponseWriter
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// writeTo sends the response held to the given writer
+func (b *responseBuffer) writeTo(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	_, _ = b.body.WriteTo(w)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestValidation_Requests(t *testing.T) {
+	s := buildServer(WithRequestValidation())
+	ID := gopherSample().ID
+
+	tests := map[string]struct {
+		method, uri, body string
+		expected          int
+	}{
+		"valid gopher":          {http.MethodPost, "/gophers", `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}`, http.StatusCreated},
+		"unknown field":         {http.MethodPost, "/gophers", `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "color": "blue"}`, http.StatusBadRequest},
+		"missing name":          {http.MethodPost, "/gophers", `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH"}`, http.StatusBadRequest},
+		"negative age":          {http.MethodPut, "/gophers/" + ID, `{"name": "Jenny", "age": -1}`, http.StatusBadRequest},
+		"malformed as_of":       {http.MethodGet, "/gophers/" + ID + "?as_of=yesterday", "", http.StatusBadRequest},
+		"not described by spec": {http.MethodGet, "/unknown", "", http.StatusNotFound},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.uri, bytes.NewBufferString(tt.body))
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			if rec.Code != tt.expected {
+				t.Errorf("expected %d, got: %d %s", tt.expected, rec.Code, rec.Body)
+			}
+			if tt.expected == http.StatusBadRequest && rec.Header().Get("Content-Type") != "application/problem+json" {
+				t.Errorf("expected a problem response, got: %s", rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// TestValidation_Responses fails when a response of the server drifts from the OpenAPI document
+func TestValidation_Responses(t *testing.T) {
+	s := buildServer(WithResponseValidation(), WithMetrics(prometheus.NewRegistry()))
+	ID := gopherSample().ID
+
+	requests := []struct {
+		method, uri, body string
+	}{
+		{http.MethodGet, "/gophers", ""},
+		{http.MethodGet, "/gophers/" + ID, ""},
+		{http.MethodGet, "/gophers/unknown", ""},
+		{http.MethodPost, "/gophers", `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}`},
+		{http.MethodPut, "/gophers/" + ID, `{"name": "Jenny", "image": "https://via.placeholder.com/150.png", "age": 19}`},
+		{http.MethodGet, "/gophers/" + ID + "/revisions", ""},
+		{http.MethodGet, "/gophers/" + ID + "?as_of=2000-01-01T00:00:00Z", ""},
+		{http.MethodDelete, "/gophers/" + ID, ""},
+		{http.MethodGet, "/v1/gophers/" + ID, ""},
+		{http.MethodGet, "/v2/gophers?limit=2&offset=1", ""},
+		{http.MethodGet, "/v2/gophers/" + ID, ""},
+		{http.MethodGet, "/v2/gophers/unknown", ""},
+		{http.MethodGet, "/v2/gophers/" + ID + "/revisions", ""},
+		{http.MethodPost, "/v2/gophers", `{"ID": "01DCBP0R0MSNZY975ZQF1DCQCJ", "name": "Eustaqio", "age": 99}`},
+		{http.MethodPut, "/v2/gophers/" + ID, `{"name": "Jenny", "age": 20}`},
+		{http.MethodDelete, "/v2/gophers/" + ID, ""},
+		{http.MethodGet, "/healthz", ""},
+		{http.MethodGet, "/readyz", ""},
+		{http.MethodGet, "/openapi.json", ""},
+		{http.MethodGet, "/docs", ""},
+		{http.MethodGet, "/metrics", ""},
+		{http.MethodGet, "/graphql?query=%7B%20gophers%20%7B%20totalCount%20%7D%20%7D", ""},
+		{http.MethodGet, "/graphql?query=mutation%20%7B%20deleteGopher(id%3A%20%22123%22)%20%7D", ""},
+		{http.MethodPost, "/graphql", `{"query": "{ gopher(id: \"` + ID + `\") { id name createdAt } }"}`},
+		{http.MethodPost, "/graphql", `{"query": "mutation ($id: ID!) { deleteGopher(id: $id) }", "variables": {"id": "` + ID + `"}}`},
+	}
+
+	for _, r := range requests {
+		t.Run(fmt.Sprintf("%s %s", r.method, r.uri), func(t *testing.T) {
+			req, err := http.NewRequest(r.method, r.uri, bytes.NewBufferString(r.body))
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			if r.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+			if rec.Code == http.StatusBadRequest || rec.Code == http.StatusInternalServerError {
+				t.Errorf("expected the request and its response to match the OpenAPI document, got: %d %s", rec.Code, rec.Body)
+			}
+		})
+	}
+}
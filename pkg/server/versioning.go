@@ -0,0 +1,66 @@
+package server
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// contentTypeV2 is the media type of the v2 representations, also accepted
+// by the unversioned routes to negotiate the v2 api
+const contentTypeV2 = "application/json; version=2"
+
+// Deprecation announces the v1 api is deprecated, and when it will be removed
+type Deprecation struct {
+	// At is the moment the v1 api was deprecated, it's announced as deprecated without date if zero
+	At time.Time
+	// Sunset is the moment the v1 api will be removed, not announced if zero
+	Sunset time.Time
+}
+
+// acceptsVersion matches the requests asking for the given version of the api
+// with the version parameter of the Accept header, like application/json; version=2
+func acceptsVersion(version string) mux.MatcherFunc {
+	return func(r *http.Request, _ *mux.RouteMatch) bool {
+		for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+			if err != nil {
+				continue
+			}
+			if (mediaType == "application/json" || mediaType == "*/*") && params["version"] == version {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// varyAccept tells the caches the responses depend on the version and the representation negotiated
+func varyAccept(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deprecated announces the deprecation of the v1 api in the responses of the given handler,
+// pointing to its successor
+func (d Deprecation) deprecated(next http.HandlerFunc) http.HandlerFunc {
+	deprecation := "true"
+	if !d.At.IsZero() {
+		deprecation = fmt.Sprintf("@%d", d.At.Unix())
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", deprecation)
+		if !d.Sunset.IsZero() {
+			w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		w.Header().Set("Link", `</v2/gophers>; rel="successor-version"`)
+		next(w, r)
+	}
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	sample "github.com/friendsofgo/gopherapi/cmd/sample-data"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/fetching"
+	"github.com/friendsofgo/gopherapi/pkg/log"
+)
+
+func TestVersioning_V1IsDeprecated(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s := buildServer(WithDeprecation(Deprecation{Sunset: sunset}))
+
+	for _, uri := range []string{"/gophers", "/v1/gophers"} {
+		req, err := http.NewRequest("GET", uri, nil)
+		if err != nil {
+			t.Fatalf("could not created request: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got: %d", http.StatusOK, rec.Code)
+		}
+		if rec.Header().Get("Deprecation") == "" {
+			t.Errorf("expected %s to be announced as deprecated", uri)
+		}
+		if got := rec.Header().Get("Sunset"); got != "Tue, 01 Jan 2030 00:00:00 GMT" {
+			t.Errorf("expected the sunset of %s, got: %q", uri, got)
+		}
+
+		var gophers []map[string]interface{}
+		if err := json.NewDecoder(rec.Body).Decode(&gophers); err != nil {
+			t.Fatalf("expected the v1 list of gophers: %v", err)
+		}
+	}
+}
+
+func TestVersioning_V1BulkAndSearch(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	for _, uri := range []string{"/v1/gophers/export", "/v1/gophers/search?q=jenny"} {
+		req, _ := http.NewRequest("GET", uri, nil)
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		if rec.Header().Get("Deprecation") == "" {
+			t.Errorf("expected %s to be announced as deprecated", uri)
+		}
+	}
+}
+
+func TestVersioning_V2BulkAndSearch(t *testing.T) {
+	s := buildServer(WithResponseValidation())
+
+	for _, uri := range []string{"/v2/gophers/export", "/v2/gophers/search?q=jenny"} {
+		req, _ := http.NewRequest("GET", uri, nil)
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		// the paths aren't taken for gopher IDs
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected %d, got: %d %s", http.StatusOK, rec.Code, rec.Body)
+		}
+		if rec.Header().Get("Deprecation") != "" {
+			t.Errorf("expected %s not to be deprecated", uri)
+		}
+	}
+}
+
+func TestVersioning_V2(t *testing.T) {
+	s := buildServer()
+
+	tests := map[string]struct {
+		uri, accept string
+	}{
+		"versioned route":        {uri: "/v2/gophers?limit=2"},
+		"negotiated with Accept": {uri: "/gophers?limit=2", accept: "application/json; version=2"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.uri, nil)
+			if err != nil {
+				t.Fatalf("could not created request: %v", err)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected %d, got: %d", http.StatusOK, rec.Code)
+			}
+			if rec.Header().Get("Deprecation") != "" {
+				t.Errorf("expected the v2 api not to be deprecated")
+			}
+			if got := rec.Header().Get("Content-Type"); got != contentTypeV2 {
+				t.Errorf("expected %q, got: %q", contentTypeV2, got)
+			}
+
+			var got struct {
+				Data       []gopherV2 `json:"data"`
+				Pagination pagination `json:"pagination"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("could not unmarshall response %v", err)
+			}
+			if len(got.Data) != 2 || got.Pagination.Limit != 2 || got.Pagination.Total <= 2 {
+				t.Errorf("expected the first page of 2 gophers, got: %+v", got)
+			}
+			if got.Data[0].ID > got.Data[1].ID {
+				t.Errorf("expected the gophers sorted by ID, got: %s, %s", got.Data[0].ID, got.Data[1].ID)
+			}
+		})
+	}
+}
+
+func TestVersioning_V2Pages(t *testing.T) {
+	s := buildServer()
+
+	var IDs []string
+	for offset := 0; ; offset += 2 {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v2/gophers?limit=2&offset=%d", offset), nil)
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		var got struct {
+			Data       []gopherV2 `json:"data"`
+			Pagination pagination `json:"pagination"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("could not unmarshall response %v", err)
+		}
+		if got.Pagination.Total != len(sample.Gophers) {
+			t.Fatalf("expected a total of %d gophers, got: %d", len(sample.Gophers), got.Pagination.Total)
+		}
+		if len(got.Data) == 0 {
+			break
+		}
+		for _, g := range got.Data {
+			IDs = append(IDs, g.ID)
+		}
+	}
+
+	expected := make([]string, 0, len(sample.Gophers))
+	for ID := range sample.Gophers {
+		expected = append(expected, ID)
+	}
+	sort.Strings(expected)
+	if !reflect.DeepEqual(IDs, expected) {
+		t.Errorf("expected every gopher once sorted by ID, got: %v", IDs)
+	}
+}
+
+func TestVersioning_V2RepositoryErrors(t *testing.T) {
+	s := buildServer()
+	srv := s.(*server)
+	srv.fetching = fetching.NewService(failingRepository{}, log.NewNoopLogger())
+	router(srv)
+
+	for _, uri := range []string{"/v2/gophers", "/v2/gophers/01D3XZ3ZHCP3KG9VT4FGAD8KDR"} {
+		req, _ := http.NewRequest("GET", uri, nil)
+		rec := httptest.NewRecorder()
+		s.Router().ServeHTTP(rec, req)
+
+		// a storage outage is not an empty list
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("expected %d fetching %s, got: %d %s", http.StatusInternalServerError, uri, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+			t.Errorf("expected a problem, got: %q", got)
+		}
+	}
+}
+
+// failingRepository fails to read the gophers, like an unreachable storage
+type failingRepository struct {
+	gopher.Repository
+}
+
+func (failingRepository) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (failingRepository) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestVersioning_V2Timestamps(t *testing.T) {
+	s := buildServer()
+
+	body := []byte(`{"ID": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "age": 99}`)
+	req, _ := http.NewRequest("POST", "/v2/gophers", bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected %d, got: %d", http.StatusCreated, rec.Code)
+	}
+
+	location := rec.Header().Get("Location")
+	req, _ = http.NewRequest("GET", location, nil)
+	rec = httptest.NewRecorder()
+	s.Router().ServeHTTP(rec, req)
+
+	var got gopherV2
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("could not unmarshall response %v", err)
+	}
+	if got.ID != "01DCBP0R0MSNZY975ZQF1DCQCH" || got.CreatedAt == nil || got.UpdatedAt == nil {
+		t.Errorf("expected the gopher created with its timestamps, got: %+v", got)
+	}
+}
+
+func TestVersioning_V2Problems(t *testing.T) {
+	testData := map[string]struct {
+		method string
+		uri    string
+		body   string
+		status int
+	}{
+		"fetching a missing gopher":  {method: "GET", uri: "/v2/gophers/unknown", status: http.StatusNotFound},
+		"removing a missing gopher":  {method: "DELETE", uri: "/v2/gophers/unknown", status: http.StatusNotFound},
+		"modifying a missing gopher": {method: "PUT", uri: "/v2/gophers/unknown", body: `{"name": "Jenny"}`, status: http.StatusNotFound},
+		"creating an existing gopher": {
+			method: "POST",
+			uri:    "/v2/gophers",
+			body:   `{"id": "01D3XZ3ZHCP3KG9VT4FGAD8KDR", "name": "Jenny"}`,
+			status: http.StatusConflict,
+		},
+		"creating with an unknown field": {
+			method: "POST",
+			uri:    "/v2/gophers",
+			body:   `{"id": "01DCBP0R0MSNZY975ZQF1DCQCH", "name": "Eustaqio", "colour": "blue"}`,
+			status: http.StatusBadRequest,
+		},
+		"modifying with an unknown field": {
+			method: "PUT",
+			uri:    "/v2/gophers/01D3XZ3ZHCP3KG9VT4FGAD8KDR",
+			body:   `{"name": "Jenny", "colour": "blue"}`,
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range testData {
+		t.Run(name, func(t *testing.T) {
+			s := buildServer()
+
+			req, _ := http.NewRequest(tt.method, tt.uri, bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			s.Router().ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got: %d", tt.status, rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("expected a problem, got: %q", got)
+			}
+		})
+	}
+}
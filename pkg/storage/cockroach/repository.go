@@ -4,62 +4,260 @@ import (
 	"context"
 	"database/sql"
 	"errors"
-	"log"
+	"fmt"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	gopher "github.com/friendsofgo/gopherapi/pkg"
-	"github.com/openzipkin/zipkin-go"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
 )
 
+const (
+	// uniqueViolation is the code of the errors raised when a unique constraint is violated
+	uniqueViolation = "23505"
+	// serializationFailure is the code of the errors raised when a transaction must be retried
+	serializationFailure = "40001"
+)
+
+// maxRevisionAttempts is the number of times a write is run while its revision number is taken
+const maxRevisionAttempts = 3
+
 type gopherRepository struct {
-	db     *sql.DB
-	tracer *zipkin.Tracer
+	db *sql.DB
 }
 
 // NewRepository creates a crockoach repository with the necessary dependencies
-func NewRepository(db *sql.DB, tracer *zipkin.Tracer) gopher.Repository {
-	return gopherRepository{db: db, tracer: tracer}
+func NewRepository(db *sql.DB) gopher.Repository {
+	return gopherRepository{db: db}
 }
 
-func (r gopherRepository) CreateGopher(_ context.Context, g *gopher.Gopher) error {
-	sqlStm := `INSERT INTO gophers (id, name, age, image, created_at) 
-	VALUES ($1, $2, $3, $4, NOW())`
-	_, err := r.db.Exec(sqlStm, g.ID, g.Name, g.Age, g.Image)
+func (r gopherRepository) CreateGopher(ctx context.Context, g *gopher.Gopher) error {
+	sqlStm := `INSERT INTO gophers (id, name, age, image, tenant_id, created_at) 
+	VALUES ($1, $2, $3, $4, $5, NOW())`
+
+	return r.withRevision(ctx, gopher.Revision{Gopher: *g}, func(tx *sql.Tx) error {
+		tracer.TagStatement(ctx, sqlStm)
+		if _, err := tx.ExecContext(ctx, sqlStm, g.ID, g.Name, g.Age, g.Image, tenant.ID(ctx)); err != nil {
+			if hasCode(err, uniqueViolation) {
+				return fmt.Errorf("%w: %s", gopher.ErrAlreadyExists, g.ID)
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
+	var gophers []gopher.Gopher
+	err := r.StreamGophers(ctx, func(g gopher.Gopher) error {
+		gophers = append(gophers, g)
+		return nil
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return gophers, nil
 }
 
-func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
-	sqlStm := `SELECT id, name, age, image, created_at, updated_at FROM gophers`
-	rows, err := r.db.Query(sqlStm)
+// StreamGophers reads the rows as fn consumes them
+func (r gopherRepository) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) error {
+	sqlStm := `SELECT id, name, age, image, created_at, updated_at FROM gophers WHERE tenant_id = $1`
+	return r.queryGophers(ctx, sqlStm, []interface{}{tenant.ID(ctx)}, fn)
+}
+
+// FetchGophersByIDs reads the gophers with the given IDs with one query
+func (r gopherRepository) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	gophers := []gopher.Gopher{}
+	if len(IDs) == 0 {
+		return gophers, nil
+	}
+
+	sqlStm := `SELECT id, name, age, image, created_at, updated_at FROM gophers WHERE tenant_id = $1 AND id = ANY($2)`
+	err := r.queryGophers(ctx, sqlStm, []interface{}{tenant.ID(ctx), pq.Array(IDs)}, func(g gopher.Gopher) error {
+		gophers = append(gophers, g)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return gophers, nil
+}
+
+// queryGophers runs a select of whole gophers, calling fn with each row as it is read
+func (r gopherRepository) queryGophers(ctx context.Context, sqlStm string, args []interface{}, fn func(gopher.Gopher) error) error {
+	tracer.TagStatement(ctx, sqlStm)
+	rows, err := r.db.QueryContext(ctx, sqlStm, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
-	var gophers []gopher.Gopher
 
 	for rows.Next() {
 		var g gopher.Gopher
 		if err := rows.Scan(&g.ID, &g.Name, &g.Age, &g.Image, &g.CreatedAt, &g.UpdatedAt); err != nil {
-			log.Println(err)
-			continue
+			return err
+		}
+		if err := fn(g); err != nil {
+			return err
 		}
-		gophers = append(gophers, g)
 	}
-	return gophers, nil
+	return rows.Err()
 }
 
 func (r gopherRepository) DeleteGopher(ctx context.Context, ID string) error {
-	return errors.New("method not implemented")
+	sqlStm := `DELETE FROM gophers WHERE tenant_id = $1 AND id = $2`
+
+	// the deletion is stored as a revision, so the gopher isn't found as of afterwards
+	deletion := gopher.Revision{Gopher: gopher.Gopher{ID: ID}, Deleted: true}
+	return r.withRevision(ctx, deletion, func(tx *sql.Tx) error {
+		tracer.TagStatement(ctx, sqlStm)
+		result, err := tx.ExecContext(ctx, sqlStm, tenant.ID(ctx), ID)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return gopher.ErrNotFound
+		}
+		return nil
+	})
 }
 
 func (r gopherRepository) UpdateGopher(ctx context.Context, ID string, g gopher.Gopher) error {
-	return errors.New("method not implemented")
+	sqlStm := `UPDATE gophers SET name = $1, age = $2, image = $3, updated_at = NOW() 
+	WHERE tenant_id = $4 AND id = $5`
+
+	g.ID = ID
+	return r.withRevision(ctx, gopher.Revision{Gopher: g}, func(tx *sql.Tx) error {
+		tracer.TagStatement(ctx, sqlStm)
+		result, err := tx.ExecContext(ctx, sqlStm, g.Name, g.Age, g.Image, tenant.ID(ctx), ID)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return gopher.ErrNotFound
+		}
+		return nil
+	})
 }
 
 func (r gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
-	return nil, errors.New("method not implemented")
+	sqlStm := `SELECT id, name, age, image, created_at, updated_at FROM gophers WHERE tenant_id = $1 AND id = $2`
+	tracer.TagStatement(ctx, sqlStm)
+
+	var g gopher.Gopher
+	err := r.db.QueryRowContext(ctx, sqlStm, tenant.ID(ctx), ID).Scan(&g.ID, &g.Name, &g.Age, &g.Image, &g.CreatedAt, &g.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, gopher.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r gopherRepository) HealthCheck(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+func (r gopherRepository) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	sqlStm := `SELECT revision, gopher_id, name, age, image, deleted, created_at FROM gopher_revisions 
+	WHERE tenant_id = $1 AND gopher_id = $2 ORDER BY revision`
+	tracer.TagStatement(ctx, sqlStm)
+	rows, err := r.db.QueryContext(ctx, sqlStm, tenant.ID(ctx), ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revisions []gopher.Revision
+
+	for rows.Next() {
+		var rev gopher.Revision
+		if err := rows.Scan(&rev.Number, &rev.Gopher.ID, &rev.Gopher.Name, &rev.Gopher.Age, &rev.Gopher.Image, &rev.Deleted, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+	}
+	return revisions, nil
+}
+
+func (r gopherRepository) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	sqlStm := `SELECT gopher_id, name, age, image, deleted FROM gopher_revisions 
+	WHERE tenant_id = $1 AND gopher_id = $2 AND created_at <= $3 ORDER BY revision DESC LIMIT 1`
+
+	tracer.TagStatement(ctx, sqlStm)
+	var (
+		g       gopher.Gopher
+		deleted bool
+	)
+	err := r.db.QueryRowContext(ctx, sqlStm, tenant.ID(ctx), ID, at).Scan(&g.ID, &g.Name, &g.Age, &g.Image, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, fmt.Errorf("%w: %s deleted as of %s", gopher.ErrNotFound, ID, at.Format(time.RFC3339))
+	}
+	return &g, nil
+}
+
+// withRevision runs write in a transaction storing the given revision as the next one of its gopher, the
+// concurrent writers of the same gopher may number their revisions the same, the unique key of
+// the revisions, or the serializable isolation, rejects all of them but the first, and the
+// others are run again to number theirs after
+func (r gopherRepository) withRevision(ctx context.Context, revision gopher.Revision, write func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := write(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		if err = createRevision(ctx, tx, revision); err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+		if !revisionTaken(err) || attempt == maxRevisionAttempts {
+			return err
+		}
+	}
+}
+
+// revisionTaken reports whether the given error rejected a revision numbered like a concurrent one
+func revisionTaken(err error) bool {
+	return hasCode(err, uniqueViolation) || hasCode(err, serializationFailure)
+}
+
+// hasCode reports whether the given error was raised by the database with the given code
+func hasCode(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+// createRevision stores the given revision, numbered after the last one of its gopher it
+// reads, a concurrent writer may read the same one, see withRevision
+func createRevision(ctx context.Context, tx *sql.Tx, revision gopher.Revision) error {
+	sqlStm := `INSERT INTO gopher_revisions (gopher_id, revision, name, age, image, deleted, tenant_id, created_at) 
+	SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, NOW() FROM gopher_revisions 
+	WHERE tenant_id = $6 AND gopher_id = $1`
+	g := revision.Gopher
+	_, err := tx.ExecContext(ctx, sqlStm, g.ID, g.Name, g.Age, g.Image, revision.Deleted, tenant.ID(ctx))
+	return err
 }
@@ -0,0 +1,288 @@
+package cockroach
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+func Test_GopherRepository_ScopedToTenant(t *testing.T) {
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	columns := []string{"id", "name", "age", "image", "created_at", "updated_at"}
+
+	testData := []struct {
+		name   string
+		expect func(sqlMock sqlmock.Sqlmock)
+		run    func(repo gopher.Repository) error
+	}{
+		{
+			name: "FetchGophers",
+			expect: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT id, name, age, image, created_at, updated_at FROM gophers WHERE tenant_id = $1`).
+					WithArgs("acme").
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+			run: func(repo gopher.Repository) error {
+				_, err := repo.FetchGophers(ctx)
+				return err
+			},
+		},
+		{
+			name: "FetchGopherByID",
+			expect: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT id, name, age, image, created_at, updated_at FROM gophers WHERE tenant_id = $1 AND id = $2`).
+					WithArgs("acme", "123ABC").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("123ABC", "Jenny", 18, "", nil, nil))
+			},
+			run: func(repo gopher.Repository) error {
+				_, err := repo.FetchGopherByID(ctx, "123ABC")
+				return err
+			},
+		},
+		{
+			name: "FetchGophersByIDs",
+			expect: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectQuery(`SELECT id, name, age, image, created_at, updated_at FROM gophers WHERE tenant_id = $1 AND id = ANY($2)`).
+					WithArgs("acme", pq.Array([]string{"123ABC", "456DEF"})).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow("123ABC", "Jenny", 18, "", nil, nil))
+			},
+			run: func(repo gopher.Repository) error {
+				_, err := repo.(gopher.BatchRepository).FetchGophersByIDs(ctx, []string{"123ABC", "456DEF"})
+				return err
+			},
+		},
+		{
+			name: "UpdateGopher",
+			expect: func(sqlMock sqlmock.Sqlmock) {
+				sqlMock.ExpectBegin()
+				sqlMock.ExpectExec(`UPDATE gophers SET name = $1, age = $2, image = $3, updated_at = NOW() WHERE tenant_id = $4 AND id = $5`).
+					WithArgs("Jenny", 18, "", "acme", "123ABC").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectRevision(sqlMock, "acme", gopher.Revision{Gopher: gopher.Gopher{ID: "123ABC", Name: "Jenny", Age: 18}})
+				sqlMock.ExpectCommit()
+			},
+			run: func(repo gopher.Repository) error {
+				return repo.UpdateGopher(ctx, "123ABC", gopher.Gopher{Name: "Jenny", Age: 18})
+			},
+		},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a repository whose statements are only expected for the tenant of the context
+			db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.NoError(t, err)
+			tt.expect(sqlMock)
+
+			// WHEN it's used within the tenant "acme"
+			err = tt.run(NewRepository(db))
+
+			// THEN every statement is filtered by the tenant
+			assert.NoError(t, err)
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_GopherRepository_CreateGopher_AlreadyExists(t *testing.T) {
+	// GIVEN a repository whose gophers table already has the ID
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`INSERT INTO gophers (id, name, age, image, tenant_id, created_at) VALUES ($1, $2, $3, $4, $5, NOW())`).
+		WithArgs("123ABC", "Jenny", 18, "", tenant.Default).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+	sqlMock.ExpectRollback()
+
+	// WHEN a gopher is created with it
+	err = NewRepository(db).CreateGopher(context.Background(), gopher.New("123ABC", "Jenny", "", 18))
+
+	// THEN the repository tells it already exists
+	assert.ErrorIs(t, err, gopher.ErrAlreadyExists)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_DeleteGopher(t *testing.T) {
+	testData := []struct {
+		name         string
+		rowsAffected int64
+		err          error
+	}{
+		{name: "existing gopher", rowsAffected: 1},
+		{name: "unknown gopher", rowsAffected: 0, err: gopher.ErrNotFound},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a repository whose gophers table deletes the rows given
+			db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.NoError(t, err)
+
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectExec(`DELETE FROM gophers WHERE tenant_id = $1 AND id = $2`).
+				WithArgs(tenant.Default, "123ABC").
+				WillReturnResult(sqlmock.NewResult(0, tt.rowsAffected))
+			if tt.err != nil {
+				sqlMock.ExpectRollback()
+			} else {
+				expectRevision(sqlMock, tenant.Default, gopher.Revision{Gopher: gopher.Gopher{ID: "123ABC"}, Deleted: true})
+				sqlMock.ExpectCommit()
+			}
+
+			// WHEN the gopher is deleted
+			err = NewRepository(db).DeleteGopher(context.Background(), "123ABC")
+
+			// THEN its deletion is stored as its next revision, and an unknown gopher isn't found
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_GopherRepository_FetchGopherRevisions(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"revision", "gopher_id", "name", "age", "image", "deleted", "created_at"}
+
+	testData := []struct {
+		name     string
+		rows     *sqlmock.Rows
+		queryErr error
+		expected []gopher.Revision
+		err      error
+	}{
+		{
+			name: "with revisions",
+			rows: sqlmock.NewRows(columns).
+				AddRow(1, "123ABC", "Jenny", 17, "", false, createdAt).
+				AddRow(2, "123ABC", "Jenny", 18, "", false, createdAt.Add(time.Hour)).
+				AddRow(3, "123ABC", "", 0, "", true, createdAt.Add(2*time.Hour)),
+			expected: []gopher.Revision{
+				{Number: 1, Gopher: gopher.Gopher{ID: "123ABC", Name: "Jenny", Age: 17}, CreatedAt: createdAt},
+				{Number: 2, Gopher: gopher.Gopher{ID: "123ABC", Name: "Jenny", Age: 18}, CreatedAt: createdAt.Add(time.Hour)},
+				{Number: 3, Gopher: gopher.Gopher{ID: "123ABC"}, Deleted: true, CreatedAt: createdAt.Add(2 * time.Hour)},
+			},
+		},
+		{name: "without revisions", rows: sqlmock.NewRows(columns), err: gopher.ErrNotFound},
+		{name: "failing", queryErr: errors.New("connection refused")},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN a repository whose revisions table returns the rows given
+			db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.NoError(t, err)
+			query := sqlMock.ExpectQuery(`SELECT revision, gopher_id, name, age, image, deleted, created_at FROM gopher_revisions WHERE tenant_id = $1 AND gopher_id = $2 ORDER BY revision`).
+				WithArgs(tenant.Default, "123ABC")
+			if tt.queryErr != nil {
+				query.WillReturnError(tt.queryErr)
+			} else {
+				query.WillReturnRows(tt.rows)
+			}
+
+			// WHEN the revisions of the gopher are fetched
+			revisions, err := NewRepository(db).(gopher.RevisionRepository).FetchGopherRevisions(context.Background(), "123ABC")
+
+			// THEN they are returned oldest first, a gopher without revisions isn't found, and the
+			// errors of the database are told apart from it
+			switch {
+			case tt.queryErr != nil:
+				assert.ErrorIs(t, err, tt.queryErr)
+				assert.NotErrorIs(t, err, gopher.ErrNotFound)
+			case tt.err != nil:
+				assert.ErrorIs(t, err, tt.err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, revisions)
+			}
+			assert.NoError(t, sqlMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func Test_GopherRepository_FetchGopherAsOf(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	query := `SELECT gopher_id, name, age, image, deleted FROM gopher_revisions WHERE tenant_id = $1 AND gopher_id = $2 AND created_at <= $3 ORDER BY revision DESC LIMIT 1`
+	columns := []string{"gopher_id", "name", "age", "image", "deleted"}
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	repo := NewRepository(db).(gopher.RevisionRepository)
+
+	// GIVEN a gopher with a revision before the moment asked for
+	sqlMock.ExpectQuery(query).
+		WithArgs(tenant.Default, "123ABC", at).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("123ABC", "Jenny", 17, "", false))
+
+	// THEN it is returned as it was then
+	g, err := repo.FetchGopherAsOf(context.Background(), "123ABC", at)
+	assert.NoError(t, err)
+	assert.Equal(t, &gopher.Gopher{ID: "123ABC", Name: "Jenny", Age: 17}, g)
+
+	// AND a gopher created afterwards isn't found
+	sqlMock.ExpectQuery(query).
+		WithArgs(tenant.Default, "456DEF", at).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	_, err = repo.FetchGopherAsOf(context.Background(), "456DEF", at)
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+
+	// AND a gopher deleted before isn't found either
+	sqlMock.ExpectQuery(query).
+		WithArgs(tenant.Default, "789GHI", at).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("789GHI", "", 0, "", true))
+
+	_, err = repo.FetchGopherAsOf(context.Background(), "789GHI", at)
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_UpdateGopher_RevisionTaken(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	g := gopher.Gopher{ID: "123ABC", Name: "Jenny", Age: 18}
+
+	// GIVEN a concurrent writer takes the revision number of the first attempt
+	sqlMock.ExpectBegin()
+	expectUpdate(sqlMock, g)
+	sqlMock.ExpectExec(`INSERT INTO gopher_revisions (gopher_id, revision, name, age, image, deleted, tenant_id, created_at) SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, NOW() FROM gopher_revisions WHERE tenant_id = $6 AND gopher_id = $1`).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+	sqlMock.ExpectRollback()
+
+	sqlMock.ExpectBegin()
+	expectUpdate(sqlMock, g)
+	expectRevision(sqlMock, tenant.Default, gopher.Revision{Gopher: g})
+	sqlMock.ExpectCommit()
+
+	// WHEN the gopher is updated
+	err = NewRepository(db).UpdateGopher(context.Background(), g.ID, g)
+
+	// THEN the update is run again to number its revision after the other one
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func expectUpdate(sqlMock sqlmock.Sqlmock, g gopher.Gopher) {
+	sqlMock.ExpectExec(`UPDATE gophers SET name = $1, age = $2, image = $3, updated_at = NOW() WHERE tenant_id = $4 AND id = $5`).
+		WithArgs(g.Name, g.Age, g.Image, tenant.Default, g.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func expectRevision(sqlMock sqlmock.Sqlmock, tenantID string, revision gopher.Revision) {
+	g := revision.Gopher
+	sqlMock.ExpectExec(`INSERT INTO gopher_revisions (gopher_id, revision, name, age, image, deleted, tenant_id, created_at) SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, NOW() FROM gopher_revisions WHERE tenant_id = $6 AND gopher_id = $1`).
+		WithArgs(g.ID, g.Name, g.Age, g.Image, revision.Deleted, tenantID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
@@ -0,0 +1,31 @@
+-- Tables of the cockroach repository
+
+-- the gophers of every tenant, their IDs are only unique within their tenant
+CREATE TABLE IF NOT EXISTS gophers (
+    tenant_id  STRING      NOT NULL,
+    id         STRING      NOT NULL,
+    name       STRING      NOT NULL DEFAULT '',
+    age        INT         NOT NULL DEFAULT 0,
+    image      STRING      NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NULL,
+    updated_at TIMESTAMPTZ NULL,
+    PRIMARY KEY (tenant_id, id)
+);
+
+-- speeds up the search of the gophers by name
+CREATE INDEX IF NOT EXISTS gophers_name_trigrams ON gophers USING GIN (lower(name) gin_trgm_ops);
+
+-- a snapshot of the gophers every time they are created, updated or deleted, the primary key keeps two
+-- concurrent writes of a gopher from taking the same revision number, the repository retries
+-- the one rejected
+CREATE TABLE IF NOT EXISTS gopher_revisions (
+    tenant_id  STRING      NOT NULL,
+    gopher_id  STRING      NOT NULL,
+    revision   INT         NOT NULL,
+    name       STRING      NOT NULL DEFAULT '',
+    age        INT         NOT NULL DEFAULT 0,
+    image      STRING      NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    deleted    BOOL        NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (tenant_id, gopher_id, revision)
+);
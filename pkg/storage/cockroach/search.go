@@ -0,0 +1,68 @@
+package cockroach
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/searching"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+)
+
+// maxSearchCandidates is the number of rows ranked by a search
+const maxSearchCandidates = 1000
+
+type searchIndex struct {
+	db *sql.DB
+}
+
+// NewSearchIndex creates a cockroach implementation of the searching.Index, looking up the gophers
+// table with ILIKE and the trigram similarity, which a trigram index on lower(name) speeds up
+func NewSearchIndex(db *sql.DB) searching.Index {
+	return searchIndex{db: db}
+}
+
+// Index satisfies the searching.Index interface, the table is kept by the repository
+func (i searchIndex) Index(context.Context, gopher.Gopher) {}
+
+// Remove satisfies the searching.Index interface, the table is kept by the repository
+func (i searchIndex) Remove(context.Context, string) {}
+
+// Search reads the gophers containing the terms of the query, or similar to it, most similar first,
+// to rank them. The accents aren't folded by cockroach, the similarity finds the names which only differ by them
+func (i searchIndex) Search(ctx context.Context, query string, limit int) ([]searching.Result, error) {
+	terms := searching.Terms(query)
+	patterns := make([]string, 0, len(terms))
+	for _, term := range terms {
+		// the terms are made of letters and digits only, so they have no wildcards to escape
+		patterns = append(patterns, "%"+term+"%")
+	}
+
+	sqlStm := `SELECT id, name, age, image, created_at, updated_at FROM gophers
+	WHERE tenant_id = $1 AND (lower(name) LIKE ANY ($2) OR lower(name) % $3)
+	ORDER BY similarity(lower(name), $3) DESC LIMIT $4`
+	tracer.TagStatement(ctx, sqlStm)
+	rows, err := i.db.QueryContext(ctx, sqlStm, tenant.ID(ctx), pq.Array(patterns), strings.Join(terms, " "), maxSearchCandidates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var candidates []gopher.Gopher
+	for rows.Next() {
+		var g gopher.Gopher
+		if err := rows.Scan(&g.ID, &g.Name, &g.Age, &g.Image, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return searching.Rank(query, candidates, limit), nil
+}
@@ -6,45 +6,54 @@ import (
 	"sync"
 	"time"
 
-	"github.com/openzipkin/zipkin-go"
-
 	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
 )
 
 type gopherRepository struct {
-	mtx    sync.RWMutex
-	tracer *zipkin.Tracer
+	mtx sync.RWMutex
 
-	gophers map[string]gopher.Gopher
+	// gophers and revisions are partitioned by tenant
+	gophers   map[string]map[string]gopher.Gopher
+	revisions map[string]map[string][]gopher.Revision
 }
 
-// NewRepository creates a inmem repository with the necessary dependencies
-func NewRepository(gophers map[string]gopher.Gopher, tracer *zipkin.Tracer) gopher.Repository {
+// NewRepository creates a inmem repository with the necessary dependencies,
+// the given gophers belong to the default tenant
+func NewRepository(gophers map[string]gopher.Gopher) gopher.Repository {
 	if gophers == nil {
 		gophers = make(map[string]gopher.Gopher)
 	}
 
-	return &gopherRepository{
-		gophers: gophers,
-		tracer:  tracer,
+	r := &gopherRepository{
+		gophers:   map[string]map[string]gopher.Gopher{tenant.Default: gophers},
+		revisions: make(map[string]map[string][]gopher.Revision),
+	}
+	for ID, g := range gophers {
+		r.addRevision(tenant.Default, ID, gopher.Revision{Gopher: g})
 	}
+
+	return r
 }
 
 func (r *gopherRepository) CreateGopher(ctx context.Context, g *gopher.Gopher) error {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
+	tenantID := tenant.ID(ctx)
 	if err := r.checkIfExists(ctx, g.ID); err != nil {
 		return err
 	}
-	r.gophers[g.ID] = *g
+	r.partition(tenantID)[g.ID] = *g
+	r.addRevision(tenantID, g.ID, gopher.Revision{Gopher: *g})
 	return nil
 }
 
 func (r *gopherRepository) FetchGophers(ctx context.Context) ([]gopher.Gopher, error) {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
-	values := make([]gopher.Gopher, 0, len(r.gophers))
-	for _, value := range r.gophers {
+	gophers := r.gophers[tenant.ID(ctx)]
+	values := make([]gopher.Gopher, 0, len(gophers))
+	for _, value := range gophers {
 		values = append(values, value)
 	}
 	return values, nil
@@ -53,7 +62,13 @@ func (r *gopherRepository) FetchGophers(ctx context.Context) ([]gopher.Gopher, e
 func (r *gopherRepository) DeleteGopher(ctx context.Context, ID string) error {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
-	delete(r.gophers, ID)
+	tenantID := tenant.ID(ctx)
+	gophers := r.gophers[tenantID]
+	if _, ok := gophers[ID]; !ok {
+		return fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+	}
+	delete(gophers, ID)
+	r.addRevision(tenantID, ID, gopher.Revision{Gopher: gopher.Gopher{ID: ID}, Deleted: true})
 
 	return nil
 }
@@ -61,36 +76,111 @@ func (r *gopherRepository) DeleteGopher(ctx context.Context, ID string) error {
 func (r *gopherRepository) UpdateGopher(ctx context.Context, ID string, g gopher.Gopher) error {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
-	r.gophers[ID] = g
+	tenantID := tenant.ID(ctx)
+	current, ok := r.partition(tenantID)[ID]
+	if !ok {
+		return fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+	}
+	if g.CreatedAt == nil {
+		// the gopher keeps the moment it was created at
+		g.CreatedAt = current.CreatedAt
+	}
+	r.partition(tenantID)[ID] = g
+	r.addRevision(tenantID, ID, gopher.Revision{Gopher: g})
 	return nil
 }
 
 func (r *gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
-	span, _ := r.tracer.StartSpanFromContext(ctx, "FetchGopherByID")
-	span.Tag("Repository", "in memory")
-	span.Annotate(time.Now(), "Transaction Start")
-
-	defer func() {
-		span.Annotate(time.Now(), "Transaction End")
-		span.Finish()
-	}()
-
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
 
-	for _, v := range r.gophers {
+	for _, v := range r.gophers[tenant.ID(ctx)] {
 		if v.ID == ID {
 			return &v, nil
 		}
 	}
 
-	return nil, fmt.Errorf("Error has ocurred while finding gopher %s", ID)
+	return nil, fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+}
+
+func (r *gopherRepository) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopher.Gopher, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	partition := r.gophers[tenant.ID(ctx)]
+	gophers := make([]gopher.Gopher, 0, len(IDs))
+	for _, ID := range IDs {
+		if g, ok := partition[ID]; ok {
+			gophers = append(gophers, g)
+		}
+	}
+	return gophers, nil
+}
+
+func (r *gopherRepository) FetchGopherRevisions(ctx context.Context, ID string) ([]gopher.Revision, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	revisions, ok := r.revisions[tenant.ID(ctx)][ID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", gopher.ErrNotFound, ID)
+	}
+
+	values := make([]gopher.Revision, len(revisions))
+	copy(values, revisions)
+	return values, nil
+}
+
+func (r *gopherRepository) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopher.Gopher, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	revisions := r.revisions[tenant.ID(ctx)][ID]
+	for i := len(revisions) - 1; i >= 0; i-- {
+		if revisions[i].CreatedAt.After(at) {
+			continue
+		}
+		if revisions[i].Deleted {
+			break
+		}
+		g := revisions[i].Gopher
+		return &g, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s as of %s", gopher.ErrNotFound, ID, at.Format(time.RFC3339))
+}
+
+func (r *gopherRepository) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+// partition returns the gophers of the given tenant, creating its partition if needed
+func (r *gopherRepository) partition(tenantID string) map[string]gopher.Gopher {
+	gophers, ok := r.gophers[tenantID]
+	if !ok {
+		gophers = make(map[string]gopher.Gopher)
+		r.gophers[tenantID] = gophers
+	}
+	return gophers
+}
+
+// addRevision stores the given revision of the gopher with the given ID, numbered after the last one
+func (r *gopherRepository) addRevision(tenantID, ID string, revision gopher.Revision) {
+	revisions, ok := r.revisions[tenantID]
+	if !ok {
+		revisions = make(map[string][]gopher.Revision)
+		r.revisions[tenantID] = revisions
+	}
+
+	revision.Number = len(revisions[ID]) + 1
+	revision.CreatedAt = time.Now()
+	revisions[ID] = append(revisions[ID], revision)
 }
 
 func (r *gopherRepository) checkIfExists(ctx context.Context, ID string) error {
-	for _, v := range r.gophers {
+	for _, v := range r.gophers[tenant.ID(ctx)] {
 		if v.ID == ID {
-			return fmt.Errorf("The gopher %s is already exist", ID)
+			return fmt.Errorf("%w: %s", gopher.ErrAlreadyExists, ID)
 		}
 	}
 
@@ -0,0 +1,70 @@
+package inmem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+func Test_GopherRepository_TenantIsolation(t *testing.T) {
+	repo := NewRepository(nil)
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	// GIVEN a gopher created by each tenant with the same ID
+	assert.NoError(t, repo.CreateGopher(acme, gopher.New("123ABC", "Acme gopher", "", 1)))
+	assert.NoError(t, repo.CreateGopher(globex, gopher.New("123ABC", "Globex gopher", "", 2)))
+
+	// THEN each tenant only sees its own gopher
+	g, err := repo.FetchGopherByID(acme, "123ABC")
+	assert.NoError(t, err)
+	assert.Equal(t, "Acme gopher", g.Name)
+
+	gophers, err := repo.FetchGophers(globex)
+	assert.NoError(t, err)
+	assert.Equal(t, []gopher.Gopher{*gopher.New("123ABC", "Globex gopher", "", 2)}, gophers)
+
+	gophers, err = repo.(gopher.BatchRepository).FetchGophersByIDs(globex, []string{"123ABC", "456DEF"})
+	assert.NoError(t, err)
+	assert.Equal(t, []gopher.Gopher{*gopher.New("123ABC", "Globex gopher", "", 2)}, gophers)
+
+	// AND the default tenant sees none of them
+	gophers, err = repo.FetchGophers(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, gophers)
+
+	// AND removing the gopher of a tenant doesn't touch the other one
+	assert.NoError(t, repo.DeleteGopher(acme, "123ABC"))
+	_, err = repo.FetchGopherByID(acme, "123ABC")
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+	_, err = repo.FetchGopherByID(globex, "123ABC")
+	assert.NoError(t, err)
+}
+
+func Test_GopherRepository_DeleteGopher_Revision(t *testing.T) {
+	ctx := context.Background()
+	repo := NewRepository(map[string]gopher.Gopher{"123ABC": *gopher.New("123ABC", "Jenny", "", 18)})
+	revisions := repo.(gopher.RevisionRepository)
+
+	// GIVEN a gopher removed after it was created
+	assert.NoError(t, repo.DeleteGopher(ctx, "123ABC"))
+
+	// THEN its removal is stored as its last revision
+	history, err := revisions.FetchGopherRevisions(ctx, "123ABC")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.True(t, history[1].Deleted)
+	assert.Equal(t, "123ABC", history[1].Gopher.ID)
+
+	// AND it isn't found as of afterwards, but still is as of before
+	_, err = revisions.FetchGopherAsOf(ctx, "123ABC", time.Now())
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+	g, err := revisions.FetchGopherAsOf(ctx, "123ABC", history[0].CreatedAt)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jenny", g.Name)
+}
@@ -4,12 +4,19 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	gopherapi "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
 	"github.com/huandu/go-sqlbuilder"
 	_ "github.com/lib/pq"
+	"strings"
 	"time"
 )
 
+// maxRevisionAttempts is the number of times a write is run while its revision number is taken
+const maxRevisionAttempts = 3
+
 type gopherRepository struct {
 	table string
 	db    *sql.DB
@@ -31,37 +38,99 @@ func (r gopherRepository) CreateGopher(ctx context.Context, g *gopherapi.Gopher)
 			Age:       g.Age,
 			CreatedAt: g.CreatedAt,
 			UpdatedAt: g.UpdatedAt,
+			TenantID:  tenant.ID(ctx),
 		},
 	)
 
 	query, args := insertBuilder.Build()
-	_, err := r.db.ExecContext(ctx, query, args...)
-	return err
+
+	return r.withRevision(ctx, gopherapi.Revision{Gopher: *g}, func(tx *sql.Tx) error {
+		tracer.TagStatement(ctx, query)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			if isDuplicateEntry(err) {
+				return fmt.Errorf("%w: %s", gopherapi.ErrAlreadyExists, g.ID)
+			}
+			return err
+		}
+		return nil
+	})
 }
 
 func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopherapi.Gopher, error) {
+	var gophers []gopherapi.Gopher
+	err := r.StreamGophers(ctx, func(g gopherapi.Gopher) error {
+		gophers = append(gophers, g)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return gophers, nil
+}
+
+// StreamGophers satisfies the gopherapi.StreamRepository interface, reading the rows as fn consumes them
+func (r gopherRepository) StreamGophers(ctx context.Context, fn func(gopherapi.Gopher) error) error {
 	sqlGopherStruct := sqlbuilder.NewStruct(new(sqlGopher))
 
 	selectBuilder := sqlGopherStruct.SelectFrom(r.table)
-	query, args := selectBuilder.Build()
+	query, args := selectBuilder.Where(
+		selectBuilder.Equal("tenant_id", tenant.ID(ctx)),
+	).Build()
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	return r.queryGophers(ctx, query, args, fn)
+}
+
+// FetchGophersByIDs satisfies the gopherapi.BatchRepository interface, reading the gophers with one query
+func (r gopherRepository) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopherapi.Gopher, error) {
+	gophers := []gopherapi.Gopher{}
+	if len(IDs) == 0 {
+		return gophers, nil
+	}
+
+	values := make([]interface{}, 0, len(IDs))
+	for _, ID := range IDs {
+		values = append(values, ID)
+	}
+
+	selectBuilder := sqlbuilder.NewStruct(new(sqlGopher)).SelectFrom(r.table)
+	query, args := selectBuilder.Where(
+		selectBuilder.Equal("tenant_id", tenant.ID(ctx)),
+		selectBuilder.In("id", values...),
+	).Build()
+
+	err := r.queryGophers(ctx, query, args, func(g gopherapi.Gopher) error {
+		gophers = append(gophers, g)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	return gophers, nil
+}
+
+// queryGophers runs a select of whole gophers, calling fn with each row as it is read
+func (r gopherRepository) queryGophers(ctx context.Context, query string, args []interface{}, fn func(gopherapi.Gopher) error) error {
+	sqlGopherStruct := sqlbuilder.NewStruct(new(sqlGopher))
+
+	tracer.TagStatement(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
 	defer func() { _ = rows.Close() }()
 
-	var gophers []gopherapi.Gopher
 	for rows.Next() {
 		sqlGopher := sqlGopher{}
 
 		err := rows.Scan(sqlGopherStruct.Addr(&sqlGopher)...)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		gophers = append(gophers, gopherapi.Gopher{
+		err = fn(gopherapi.Gopher{
 			ID:        sqlGopher.ID,
 			Name:      sqlGopher.Name,
 			Image:     sqlGopher.Image,
@@ -69,49 +138,65 @@ func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopherapi.Gopher,
 			CreatedAt: sqlGopher.CreatedAt,
 			UpdatedAt: sqlGopher.UpdatedAt,
 		})
+		if err != nil {
+			return err
+		}
 	}
 
-	return gophers, nil
+	return rows.Err()
 }
 
 func (r gopherRepository) DeleteGopher(ctx context.Context, ID string) error {
 	deleteBuilder := sqlbuilder.NewStruct(new(sqlGopher)).DeleteFrom(r.table)
 	query, args := deleteBuilder.Where(
+		deleteBuilder.Equal("tenant_id", tenant.ID(ctx)),
 		deleteBuilder.Equal("id", ID),
 	).Build()
 
-	_, err := r.db.ExecContext(ctx, query, args...)
-	return err
+	// the deletion is stored as a revision, so the gopher isn't found as of afterwards
+	deletion := gopherapi.Revision{Gopher: gopherapi.Gopher{ID: ID}, Deleted: true}
+	return r.withRevision(ctx, deletion, func(tx *sql.Tx) error {
+		tracer.TagStatement(ctx, query)
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return gopherapi.ErrNotFound
+		}
+		return nil
+	})
 }
 
 func (r gopherRepository) UpdateGopher(ctx context.Context, ID string, g gopherapi.Gopher) error {
-	updateBuilder := sqlbuilder.NewStruct(new(sqlGopher)).Update(
-		r.table,
-		sqlGopher{
-			ID:        g.ID,
-			Name:      g.Name,
-			Image:     g.Image,
-			Age:       g.Age,
-			CreatedAt: g.CreatedAt,
-			UpdatedAt: g.UpdatedAt,
-		},
+	// the gopher keeps the moment it was created at
+	updateBuilder := sqlbuilder.NewUpdateBuilder().Update(r.table)
+	updateBuilder.Set(
+		updateBuilder.Assign("name", g.Name),
+		updateBuilder.Assign("image", g.Image),
+		updateBuilder.Assign("age", g.Age),
+		updateBuilder.Assign("updated_at", g.UpdatedAt),
 	)
 
 	query, args := updateBuilder.Where(
+		updateBuilder.Equal("tenant_id", tenant.ID(ctx)),
 		updateBuilder.Equal("id", ID),
 	).Build()
 
-	result, err := r.db.ExecContext(ctx, query, args...)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return errors.New("not found")
-	}
+	g.ID = ID
+	return r.withRevision(ctx, gopherapi.Revision{Gopher: g}, func(tx *sql.Tx) error {
+		tracer.TagStatement(ctx, query)
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return gopherapi.ErrNotFound
+		}
+		return nil
+	})
 }
 
 func (r gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*gopherapi.Gopher, error) {
@@ -120,14 +205,19 @@ func (r gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*goph
 	selectBuilder := sqlGopherStruct.SelectFrom(r.table)
 
 	query, args := selectBuilder.Where(
+		selectBuilder.Equal("tenant_id", tenant.ID(ctx)),
 		selectBuilder.Equal("id", ID),
 	).Build()
 
+	tracer.TagStatement(ctx, query)
 	row := r.db.QueryRowContext(ctx, query, args...)
 
 	sqlGopher := sqlGopher{}
 
 	err := row.Scan(sqlGopherStruct.Addr(&sqlGopher)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, gopherapi.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -142,6 +232,158 @@ func (r gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*goph
 	}, nil
 }
 
+// HealthCheck satisfies the health.Checker interface
+func (r gopherRepository) HealthCheck(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// FetchGopherRevisions satisfies the gopherapi.RevisionRepository interface
+func (r gopherRepository) FetchGopherRevisions(ctx context.Context, ID string) ([]gopherapi.Revision, error) {
+	sqlRevisionStruct := sqlbuilder.NewStruct(new(sqlRevision))
+
+	selectBuilder := sqlRevisionStruct.SelectFrom(r.revisionsTable())
+	query, args := selectBuilder.Where(
+		selectBuilder.Equal("tenant_id", tenant.ID(ctx)),
+		selectBuilder.Equal("gopher_id", ID),
+	).OrderBy("revision").Build()
+
+	tracer.TagStatement(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	var revisions []gopherapi.Revision
+	for rows.Next() {
+		sqlRevision := sqlRevision{}
+
+		err := rows.Scan(sqlRevisionStruct.Addr(&sqlRevision)...)
+		if err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, sqlRevision.toRevision())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %s", gopherapi.ErrNotFound, ID)
+	}
+
+	return revisions, nil
+}
+
+// FetchGopherAsOf satisfies the gopherapi.RevisionRepository interface
+func (r gopherRepository) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (*gopherapi.Gopher, error) {
+	sqlRevisionStruct := sqlbuilder.NewStruct(new(sqlRevision))
+
+	selectBuilder := sqlRevisionStruct.SelectFrom(r.revisionsTable())
+	query, args := selectBuilder.Where(
+		selectBuilder.Equal("tenant_id", tenant.ID(ctx)),
+		selectBuilder.Equal("gopher_id", ID),
+		selectBuilder.LessEqualThan("created_at", at),
+	).OrderBy("revision").Desc().Limit(1).Build()
+
+	tracer.TagStatement(ctx, query)
+	row := r.db.QueryRowContext(ctx, query, args...)
+
+	sqlRevision := sqlRevision{}
+
+	err := row.Scan(sqlRevisionStruct.Addr(&sqlRevision)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", gopherapi.ErrNotFound, ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if sqlRevision.Deleted {
+		return nil, fmt.Errorf("%w: %s deleted as of %s", gopherapi.ErrNotFound, ID, at.Format(time.RFC3339))
+	}
+
+	g := sqlRevision.toRevision().Gopher
+	return &g, nil
+}
+
+// withRevision runs write in a transaction storing the given revision as the next one of its gopher,
+// the concurrent writers of the same gopher may number their revisions the same, the unique key of
+// the revisions rejects all of them but the first, and the others are run again to number theirs after
+func (r gopherRepository) withRevision(ctx context.Context, revision gopherapi.Revision, write func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		if err := write(tx); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+
+		err = r.createRevision(ctx, tx, revision)
+		if err == nil {
+			return tx.Commit()
+		}
+		_ = tx.Rollback()
+		if !isDuplicateEntry(err) || attempt == maxRevisionAttempts {
+			return err
+		}
+	}
+}
+
+// createRevision stores the given revision, numbered after the last one of its gopher it
+// reads, a concurrent writer may read the same one, see withRevision
+func (r gopherRepository) createRevision(ctx context.Context, tx *sql.Tx, revision gopherapi.Revision) error {
+	query := fmt.Sprintf(
+		"INSERT INTO %[1]s (gopher_id, revision, name, image, age, created_at, deleted, tenant_id) "+
+			"SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?, ?, ? FROM %[1]s WHERE tenant_id = ? AND gopher_id = ?",
+		r.revisionsTable(),
+	)
+
+	g := revision.Gopher
+	tenantID := tenant.ID(ctx)
+	_, err := tx.ExecContext(ctx, query, g.ID, g.Name, g.Image, g.Age, time.Now(), revision.Deleted, tenantID, tenantID, g.ID)
+	return err
+}
+
+// isDuplicateEntry reports whether the given error is the violation of a unique key, the driver
+// errors can't be told apart here, so it's found by the message MySQL gives it
+func isDuplicateEntry(err error) bool {
+	return strings.Contains(err.Error(), "Duplicate entry")
+}
+
+func (r gopherRepository) revisionsTable() string {
+	return r.table + "_revisions"
+}
+
+type sqlRevision struct {
+	GopherID  string    `db:"gopher_id"`
+	Revision  int       `db:"revision"`
+	Name      string    `db:"name"`
+	Image     string    `db:"image"`
+	Age       int       `db:"age"`
+	CreatedAt time.Time `db:"created_at"`
+	Deleted   bool      `db:"deleted"`
+	TenantID  string    `db:"tenant_id"`
+}
+
+func (r sqlRevision) toRevision() gopherapi.Revision {
+	return gopherapi.Revision{
+		Number: r.Revision,
+		Gopher: gopherapi.Gopher{
+			ID:    r.GopherID,
+			Name:  r.Name,
+			Image: r.Image,
+			Age:   r.Age,
+		},
+		Deleted:   r.Deleted,
+		CreatedAt: r.CreatedAt,
+	}
+}
+
 type sqlGopher struct {
 	ID        string     `db:"id"`
 	Name      string     `db:"name"`
@@ -149,4 +391,5 @@ type sqlGopher struct {
 	Age       int        `db:"age"`
 	CreatedAt *time.Time `db:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at"`
+	TenantID  string     `db:"tenant_id"`
 }
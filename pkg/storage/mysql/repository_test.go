@@ -5,6 +5,8 @@ import (
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	gopherapi "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/health"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -19,15 +21,40 @@ func Test_GopherRepository_CreateGopher_RepositoryError(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"INSERT INTO gophers (id, name, image, age, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)").
-		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt).
+		"INSERT INTO gophers (id, name, image, age, created_at, updated_at, tenant_id) VALUES (?, ?, ?, ?, ?, ?, ?)").
+		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt, tenant.Default).
 		WillReturnError(errors.New("database failed"))
+	sqlMock.ExpectRollback()
 
 	repo := NewRepository("gophers", db)
 	err = repo.CreateGopher(context.Background(), &gopher)
 
 	assert.Error(t, err)
+	assert.NotErrorIs(t, err, gopherapi.ErrAlreadyExists)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_CreateGopher_AlreadyExists(t *testing.T) {
+	gopher := buildGopher()
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(
+		"INSERT INTO gophers (id, name, image, age, created_at, updated_at, tenant_id) VALUES (?, ?, ?, ?, ?, ?, ?)").
+		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt, tenant.Default).
+		WillReturnError(errors.New("Error 1062 (23000): Duplicate entry '" + gopher.ID + "' for key 'PRIMARY'"))
+	sqlMock.ExpectRollback()
+
+	repo := NewRepository("gophers", db)
+	err = repo.CreateGopher(context.Background(), &gopher)
+
+	assert.ErrorIs(t, err, gopherapi.ErrAlreadyExists)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
@@ -39,10 +66,13 @@ func Test_GopherRepository_CreateGopher_Success(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"INSERT INTO gophers (id, name, image, age, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)").
-		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt).
+		"INSERT INTO gophers (id, name, image, age, created_at, updated_at, tenant_id) VALUES (?, ?, ?, ?, ?, ?, ?)").
+		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt, tenant.Default).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRevision(sqlMock, gopherapi.Revision{Gopher: gopher})
+	sqlMock.ExpectCommit()
 
 	repo := NewRepository("gophers", db)
 	err = repo.CreateGopher(context.Background(), &gopher)
@@ -58,7 +88,8 @@ func Test_GopherRepository_FetchGophers_RepositoryError(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers").
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ?").
+		WithArgs(tenant.Default).
 		WillReturnError(errors.New("something-failed"))
 
 	repo := NewRepository("gophers", db)
@@ -75,9 +106,10 @@ func Test_GopherRepository_FetchGophers_NoRows(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers").
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ?").
+		WithArgs(tenant.Default).
 		WillReturnRows(sqlmock.NewRows(
-			[]string{"id", "name", "image", "age", "created_at", "updated_at"}),
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}),
 		)
 
 	repo := NewRepository("gophers", db)
@@ -96,10 +128,11 @@ func Test_GopherRepository_FetchGophers_RowWithInvalidData(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers").
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ?").
+		WithArgs(tenant.Default).
 		WillReturnRows(sqlmock.NewRows(
-			[]string{"id", "name", "image", "age", "created_at", "updated_at"}).
-			AddRow(nil, nil, nil, nil, nil, nil), // This is a row failure as the data type is wrong
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}).
+			AddRow(nil, nil, nil, nil, nil, nil, nil), // This is a row failure as the data type is wrong
 		)
 
 	repo := NewRepository("gophers", db)
@@ -121,11 +154,12 @@ func Test_GopherRepository_FetchGophers_Succeeded(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers").
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ?").
+		WithArgs(tenant.Default).
 		WillReturnRows(sqlmock.NewRows(
-			[]string{"id", "name", "image", "age", "created_at", "updated_at"}).
-			AddRow(expectedGophers[0].ID, expectedGophers[0].Name, expectedGophers[0].Image, expectedGophers[0].Age, expectedGophers[0].CreatedAt, expectedGophers[0].UpdatedAt).
-			AddRow(expectedGophers[1].ID, expectedGophers[1].Name, expectedGophers[1].Image, expectedGophers[1].Age, expectedGophers[1].CreatedAt, expectedGophers[1].UpdatedAt),
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}).
+			AddRow(expectedGophers[0].ID, expectedGophers[0].Name, expectedGophers[0].Image, expectedGophers[0].Age, expectedGophers[0].CreatedAt, expectedGophers[0].UpdatedAt, tenant.Default).
+			AddRow(expectedGophers[1].ID, expectedGophers[1].Name, expectedGophers[1].Image, expectedGophers[1].Age, expectedGophers[1].CreatedAt, expectedGophers[1].UpdatedAt, tenant.Default),
 		)
 
 	repo := NewRepository("gophers", db)
@@ -136,6 +170,50 @@ func Test_GopherRepository_FetchGophers_Succeeded(t *testing.T) {
 	assert.Equal(t, expectedGophers, gophers)
 }
 
+func Test_GopherRepository_FetchGophers_ScopedToTenant(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ?").
+		WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}),
+		)
+
+	repo := NewRepository("gophers", db)
+	_, err = repo.FetchGophers(tenant.WithTenant(context.Background(), "acme"))
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_FetchGophersByIDs_Succeeded(t *testing.T) {
+	expectedGopher := buildGopher()
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ? AND id IN (?, ?)").
+		WithArgs(tenant.Default, expectedGopher.ID, "missing").
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}).
+			AddRow(expectedGopher.ID, expectedGopher.Name, expectedGopher.Image, expectedGopher.Age, expectedGopher.CreatedAt, expectedGopher.UpdatedAt, tenant.Default),
+		)
+
+	repo := NewRepository("gophers", db).(gopherapi.BatchRepository)
+	gophers, err := repo.FetchGophersByIDs(context.Background(), []string{expectedGopher.ID, "missing"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	assert.Equal(t, []gopherapi.Gopher{expectedGopher}, gophers)
+}
+
 func Test_GopherRepository_DeleteGopher_RepositoryError(t *testing.T) {
 	gopherID := "123ABC"
 
@@ -144,10 +222,12 @@ func Test_GopherRepository_DeleteGopher_RepositoryError(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"DELETE FROM gophers WHERE id = ?").
-		WithArgs(gopherID).
+		"DELETE FROM gophers WHERE tenant_id = ? AND id = ?").
+		WithArgs(tenant.Default, gopherID).
 		WillReturnError(errors.New("database failed"))
+	sqlMock.ExpectRollback()
 
 	repo := NewRepository("gophers", db)
 	err = repo.DeleteGopher(context.Background(), gopherID)
@@ -164,10 +244,14 @@ func Test_GopherRepository_DeleteGopher_Success(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"DELETE FROM gophers WHERE id = ?").
-		WithArgs(gopherID).
+		"DELETE FROM gophers WHERE tenant_id = ? AND id = ?").
+		WithArgs(tenant.Default, gopherID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	// the deletion is stored as a revision
+	expectRevision(sqlMock, gopherapi.Revision{Gopher: gopherapi.Gopher{ID: gopherID}, Deleted: true})
+	sqlMock.ExpectCommit()
 
 	repo := NewRepository("gophers", db)
 	err = repo.DeleteGopher(context.Background(), gopherID)
@@ -176,6 +260,28 @@ func Test_GopherRepository_DeleteGopher_Success(t *testing.T) {
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
+func Test_GopherRepository_DeleteGopher_NotFound(t *testing.T) {
+	gopherID := "123ABC"
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(
+		"DELETE FROM gophers WHERE tenant_id = ? AND id = ?").
+		WithArgs(tenant.Default, gopherID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
+
+	repo := NewRepository("gophers", db)
+	err = repo.DeleteGopher(context.Background(), gopherID)
+
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
 func Test_GopherRepository_UpdateGopher_RepositoryError(t *testing.T) {
 	gopher := buildGopher()
 
@@ -184,10 +290,12 @@ func Test_GopherRepository_UpdateGopher_RepositoryError(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"UPDATE gophers SET id = ?, name = ?, image = ?, age = ?, created_at = ?, updated_at = ? WHERE id = ?").
-		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt, gopher.ID).
+		"UPDATE gophers SET name = ?, image = ?, age = ?, updated_at = ? WHERE tenant_id = ? AND id = ?").
+		WithArgs(gopher.Name, gopher.Image, gopher.Age, gopher.UpdatedAt, tenant.Default, gopher.ID).
 		WillReturnError(errors.New("database failed"))
+	sqlMock.ExpectRollback()
 
 	repo := NewRepository("gophers", db)
 	err = repo.UpdateGopher(context.Background(), gopher.ID, gopher)
@@ -204,15 +312,17 @@ func Test_GopherRepository_UpdateGopher_NotFound(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"UPDATE gophers SET id = ?, name = ?, image = ?, age = ?, created_at = ?, updated_at = ? WHERE id = ?").
-		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt, gopher.ID).
+		"UPDATE gophers SET name = ?, image = ?, age = ?, updated_at = ? WHERE tenant_id = ? AND id = ?").
+		WithArgs(gopher.Name, gopher.Image, gopher.Age, gopher.UpdatedAt, tenant.Default, gopher.ID).
 		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectRollback()
 
 	repo := NewRepository("gophers", db)
 	err = repo.UpdateGopher(context.Background(), gopher.ID, gopher)
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
@@ -224,10 +334,13 @@ func Test_GopherRepository_UpdateGopher_Success(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
+	sqlMock.ExpectBegin()
 	sqlMock.ExpectExec(
-		"UPDATE gophers SET id = ?, name = ?, image = ?, age = ?, created_at = ?, updated_at = ? WHERE id = ?").
-		WithArgs(gopher.ID, gopher.Name, gopher.Image, gopher.Age, gopher.CreatedAt, gopher.UpdatedAt, gopher.ID).
+		"UPDATE gophers SET name = ?, image = ?, age = ?, updated_at = ? WHERE tenant_id = ? AND id = ?").
+		WithArgs(gopher.Name, gopher.Image, gopher.Age, gopher.UpdatedAt, tenant.Default, gopher.ID).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRevision(sqlMock, gopherapi.Revision{Gopher: gopher})
+	sqlMock.ExpectCommit()
 
 	repo := NewRepository("gophers", db)
 	err = repo.UpdateGopher(context.Background(), gopher.ID, gopher)
@@ -245,8 +358,8 @@ func Test_GopherRepository_FetchGopherByID_RepositoryError(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers WHERE id = ?").
-		WithArgs(gopherID).
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ? AND id = ?").
+		WithArgs(tenant.Default, gopherID).
 		WillReturnError(errors.New("something-failed"))
 
 	repo := NewRepository("gophers", db)
@@ -265,16 +378,16 @@ func Test_GopherRepository_FetchGopherByID_NoRows(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers WHERE id = ?").
-		WithArgs(gopherID).
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ? AND id = ?").
+		WithArgs(tenant.Default, gopherID).
 		WillReturnRows(sqlmock.NewRows(
-			[]string{"id", "name", "image", "age", "created_at", "updated_at"}),
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}),
 		)
 
 	repo := NewRepository("gophers", db)
 	_, err = repo.FetchGopherByID(context.Background(), gopherID)
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
 	assert.NoError(t, sqlMock.ExpectationsWereMet())
 }
 
@@ -287,10 +400,10 @@ func Test_GopherRepository_FetchGopherByID_RowWithInvalidData(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers WHERE id = ?").
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ? AND id = ?").
 		WillReturnRows(sqlmock.NewRows(
-			[]string{"id", "name", "image", "age", "created_at", "updated_at"}).
-			AddRow(nil, nil, nil, nil, nil, nil), // This is a row failure as the data type is wrong
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}).
+			AddRow(nil, nil, nil, nil, nil, nil, nil), // This is a row failure as the data type is wrong
 		)
 
 	repo := NewRepository("gophers", db)
@@ -309,12 +422,12 @@ func Test_GopherRepository_FetchGopherByID_Succeeded(t *testing.T) {
 	}
 
 	sqlMock.ExpectQuery(
-		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at FROM gophers WHERE id = ?",
+		"SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id FROM gophers WHERE tenant_id = ? AND id = ?",
 	).
-		WithArgs(expectedGopher.ID).
+		WithArgs(tenant.Default, expectedGopher.ID).
 		WillReturnRows(sqlmock.NewRows(
-			[]string{"id", "name", "image", "age", "created_at", "updated_at"}).
-			AddRow(expectedGopher.ID, expectedGopher.Name, expectedGopher.Image, expectedGopher.Age, expectedGopher.CreatedAt, expectedGopher.UpdatedAt),
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}).
+			AddRow(expectedGopher.ID, expectedGopher.Name, expectedGopher.Image, expectedGopher.Age, expectedGopher.CreatedAt, expectedGopher.UpdatedAt, tenant.Default),
 		)
 
 	repo := NewRepository("gophers", db)
@@ -325,6 +438,198 @@ func Test_GopherRepository_FetchGopherByID_Succeeded(t *testing.T) {
 	assert.Equal(t, &expectedGopher, gopher)
 }
 
+func Test_GopherRepository_FetchGopherRevisions_NoRows(t *testing.T) {
+	gopherID := "123ABC"
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers_revisions.gopher_id, gophers_revisions.revision, gophers_revisions.name, gophers_revisions.image, gophers_revisions.age, gophers_revisions.created_at, gophers_revisions.deleted, gophers_revisions.tenant_id FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ? ORDER BY revision").
+		WithArgs(tenant.Default, gopherID).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"gopher_id", "revision", "name", "image", "age", "created_at", "deleted", "tenant_id"}),
+		)
+
+	repo := NewRepository("gophers", db).(gopherapi.RevisionRepository)
+	_, err = repo.FetchGopherRevisions(context.Background(), gopherID)
+
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_FetchGopherRevisions_RepositoryError(t *testing.T) {
+	gopherID := "123ABC"
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers_revisions.gopher_id, gophers_revisions.revision, gophers_revisions.name, gophers_revisions.image, gophers_revisions.age, gophers_revisions.created_at, gophers_revisions.deleted, gophers_revisions.tenant_id FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ? ORDER BY revision").
+		WithArgs(tenant.Default, gopherID).
+		WillReturnError(errors.New("connection refused"))
+
+	repo := NewRepository("gophers", db).(gopherapi.RevisionRepository)
+	_, err = repo.FetchGopherRevisions(context.Background(), gopherID)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, gopherapi.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_FetchGopherRevisions_Succeeded(t *testing.T) {
+	gopher := buildGopher()
+	expectedRevisions := []gopherapi.Revision{
+		{Number: 1, Gopher: gopherapi.Gopher{ID: gopher.ID, Name: "The Saviour", Image: gopher.Image, Age: 7}, CreatedAt: gopher.CreatedAt.Add(-time.Hour)},
+		{Number: 2, Gopher: gopherapi.Gopher{ID: gopher.ID, Name: gopher.Name, Image: gopher.Image, Age: gopher.Age}, CreatedAt: *gopher.CreatedAt},
+		{Number: 3, Gopher: gopherapi.Gopher{ID: gopher.ID}, Deleted: true, CreatedAt: gopher.CreatedAt.Add(time.Hour)},
+	}
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	rows := sqlmock.NewRows([]string{"gopher_id", "revision", "name", "image", "age", "created_at", "deleted", "tenant_id"})
+	for _, r := range expectedRevisions {
+		rows.AddRow(r.Gopher.ID, r.Number, r.Gopher.Name, r.Gopher.Image, r.Gopher.Age, r.CreatedAt, r.Deleted, tenant.Default)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers_revisions.gopher_id, gophers_revisions.revision, gophers_revisions.name, gophers_revisions.image, gophers_revisions.age, gophers_revisions.created_at, gophers_revisions.deleted, gophers_revisions.tenant_id FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ? ORDER BY revision").
+		WithArgs(tenant.Default, gopher.ID).
+		WillReturnRows(rows)
+
+	repo := NewRepository("gophers", db).(gopherapi.RevisionRepository)
+	revisions, err := repo.FetchGopherRevisions(context.Background(), gopher.ID)
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	assert.Equal(t, expectedRevisions, revisions)
+}
+
+func Test_GopherRepository_FetchGopherAsOf_Succeeded(t *testing.T) {
+	gopher := buildGopher()
+	at := gopher.CreatedAt.Add(time.Minute)
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers_revisions.gopher_id, gophers_revisions.revision, gophers_revisions.name, gophers_revisions.image, gophers_revisions.age, gophers_revisions.created_at, gophers_revisions.deleted, gophers_revisions.tenant_id FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ? AND created_at <= ? ORDER BY revision DESC LIMIT 1").
+		WithArgs(tenant.Default, gopher.ID, at).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"gopher_id", "revision", "name", "image", "age", "created_at", "deleted", "tenant_id"}).
+			AddRow(gopher.ID, 1, gopher.Name, gopher.Image, gopher.Age, *gopher.CreatedAt, false, tenant.Default),
+		)
+
+	repo := NewRepository("gophers", db).(gopherapi.RevisionRepository)
+	result, err := repo.FetchGopherAsOf(context.Background(), gopher.ID, at)
+
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+	assert.Equal(t, &gopherapi.Gopher{ID: gopher.ID, Name: gopher.Name, Image: gopher.Image, Age: gopher.Age}, result)
+}
+
+func Test_GopherRepository_FetchGopherAsOf_NoRows(t *testing.T) {
+	gopher := buildGopher()
+	at := gopher.CreatedAt.Add(-time.Minute)
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectQuery(
+		"SELECT gophers_revisions.gopher_id, gophers_revisions.revision, gophers_revisions.name, gophers_revisions.image, gophers_revisions.age, gophers_revisions.created_at, gophers_revisions.deleted, gophers_revisions.tenant_id FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ? AND created_at <= ? ORDER BY revision DESC LIMIT 1").
+		WithArgs(tenant.Default, gopher.ID, at).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"gopher_id", "revision", "name", "image", "age", "created_at", "deleted", "tenant_id"}),
+		)
+
+	repo := NewRepository("gophers", db).(gopherapi.RevisionRepository)
+	_, err = repo.FetchGopherAsOf(context.Background(), gopher.ID, at)
+
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_FetchGopherAsOf_Deleted(t *testing.T) {
+	gopher := buildGopher()
+	at := gopher.CreatedAt.Add(time.Minute)
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	// GIVEN the last revision before the moment asked for deleted the gopher
+	sqlMock.ExpectQuery(
+		"SELECT gophers_revisions.gopher_id, gophers_revisions.revision, gophers_revisions.name, gophers_revisions.image, gophers_revisions.age, gophers_revisions.created_at, gophers_revisions.deleted, gophers_revisions.tenant_id FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ? AND created_at <= ? ORDER BY revision DESC LIMIT 1").
+		WithArgs(tenant.Default, gopher.ID, at).
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"gopher_id", "revision", "name", "image", "age", "created_at", "deleted", "tenant_id"}).
+			AddRow(gopher.ID, 2, "", "", 0, *gopher.CreatedAt, true, tenant.Default),
+		)
+
+	// WHEN the gopher is fetched as of that moment
+	repo := NewRepository("gophers", db).(gopherapi.RevisionRepository)
+	_, err = repo.FetchGopherAsOf(context.Background(), gopher.ID, at)
+
+	// THEN it is not found
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_GopherRepository_UpdateGopher_RevisionTaken(t *testing.T) {
+	gopher := buildGopher()
+
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	// GIVEN a concurrent writer takes the revision number of the first attempt
+	for _, revisionErr := range []error{errors.New("Error 1062 (23000): Duplicate entry 'default-123ABC-2' for key 'PRIMARY'"), nil} {
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectExec(
+			"UPDATE gophers SET name = ?, image = ?, age = ?, updated_at = ? WHERE tenant_id = ? AND id = ?").
+			WithArgs(gopher.Name, gopher.Image, gopher.Age, gopher.UpdatedAt, tenant.Default, gopher.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		if revisionErr != nil {
+			sqlMock.ExpectExec(
+				"INSERT INTO gophers_revisions (gopher_id, revision, name, image, age, created_at, deleted, tenant_id) SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?, ?, ? FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ?").
+				WillReturnError(revisionErr)
+			sqlMock.ExpectRollback()
+			continue
+		}
+		expectRevision(sqlMock, gopherapi.Revision{Gopher: gopher})
+		sqlMock.ExpectCommit()
+	}
+
+	// WHEN the gopher is updated
+	repo := NewRepository("gophers", db)
+	err = repo.UpdateGopher(context.Background(), gopher.ID, gopher)
+
+	// THEN the update is run again to number its revision after the other one
+	assert.NoError(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func expectRevision(sqlMock sqlmock.Sqlmock, revision gopherapi.Revision) {
+	g := revision.Gopher
+	sqlMock.ExpectExec(
+		"INSERT INTO gophers_revisions (gopher_id, revision, name, image, age, created_at, deleted, tenant_id) SELECT ?, COALESCE(MAX(revision), 0) + 1, ?, ?, ?, ?, ?, ? FROM gophers_revisions WHERE tenant_id = ? AND gopher_id = ?").
+		WithArgs(g.ID, g.Name, g.Image, g.Age, sqlmock.AnyArg(), revision.Deleted, tenant.Default, tenant.Default, g.ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
 func buildGopher() gopherapi.Gopher {
 	now := time.Now()
 	return gopherapi.Gopher{
@@ -336,3 +641,18 @@ func buildGopher() gopherapi.Gopher {
 		UpdatedAt: &now,
 	}
 }
+
+func Test_GopherRepository_HealthCheck(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		assert.NoError(t, err)
+	}
+
+	sqlMock.ExpectPing().WillReturnError(errors.New("database down"))
+
+	repo := NewRepository("gophers", db).(health.Checker)
+	err = repo.HealthCheck(context.Background())
+
+	assert.Error(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
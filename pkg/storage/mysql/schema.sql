@@ -0,0 +1,28 @@
+-- Tables of the mysql repository, created with the default name given to it by the server, gophers
+
+-- the gophers of every tenant, their IDs are only unique within their tenant
+CREATE TABLE IF NOT EXISTS gophers (
+    tenant_id  VARCHAR(64)  NOT NULL,
+    id         VARCHAR(255) NOT NULL,
+    name       VARCHAR(255) NOT NULL DEFAULT '',
+    image      VARCHAR(255) NOT NULL DEFAULT '',
+    age        INT          NOT NULL DEFAULT 0,
+    created_at DATETIME(6)  NULL,
+    updated_at DATETIME(6)  NULL,
+    PRIMARY KEY (tenant_id, id)
+);
+
+-- a snapshot of the gophers every time they are created, updated or deleted, the primary key keeps two
+-- concurrent writes of a gopher from taking the same revision number, the repository retries
+-- the one rejected
+CREATE TABLE IF NOT EXISTS gophers_revisions (
+    tenant_id  VARCHAR(64)  NOT NULL,
+    gopher_id  VARCHAR(255) NOT NULL,
+    revision   INT          NOT NULL,
+    name       VARCHAR(255) NOT NULL DEFAULT '',
+    image      VARCHAR(255) NOT NULL DEFAULT '',
+    age        INT          NOT NULL DEFAULT 0,
+    created_at DATETIME(6)  NOT NULL,
+    deleted    BOOLEAN      NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (tenant_id, gopher_id, revision)
+);
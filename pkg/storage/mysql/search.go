@@ -0,0 +1,89 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	gopherapi "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/searching"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
+	"github.com/huandu/go-sqlbuilder"
+)
+
+// maxSearchCandidates is the number of rows ranked by a search
+const maxSearchCandidates = 1000
+
+type searchIndex struct {
+	table string
+	db    *sql.DB
+}
+
+// NewSearchIndex instances a MySQL implementation of the searching.Index, looking up the gophers
+// table with LIKE. The default utf8mb4 collation compares ignoring the case and the accents
+func NewSearchIndex(table string, db *sql.DB) searching.Index {
+	return searchIndex{table: table, db: db}
+}
+
+// Index satisfies the searching.Index interface, the table is kept by the repository
+func (i searchIndex) Index(context.Context, gopherapi.Gopher) {}
+
+// Remove satisfies the searching.Index interface, the table is kept by the repository
+func (i searchIndex) Remove(context.Context, string) {}
+
+// Search satisfies the searching.Index interface, reading the gophers containing any trigram of the
+// terms of the query, so the ones with typos are found too, to rank them
+func (i searchIndex) Search(ctx context.Context, query string, limit int) ([]searching.Result, error) {
+	sqlGopherStruct := sqlbuilder.NewStruct(new(sqlGopher))
+
+	selectBuilder := sqlGopherStruct.SelectFrom(i.table)
+	var likes, starts []string
+	for _, term := range searching.Terms(query) {
+		// the terms are made of letters and digits only, so they have no wildcards to escape
+		for _, trigram := range searching.Trigrams(term) {
+			likes = append(likes, selectBuilder.Like("name", "%"+trigram+"%"))
+		}
+		// the gophers with words starting with the term are read first, so the candidates
+		// cut by the limit are the ones which would rank the worst
+		starts = append(starts, fmt.Sprintf("(%s OR %s)",
+			selectBuilder.Like("name", term+"%"), selectBuilder.Like("name", "% "+term+"%")))
+	}
+	statement, args := selectBuilder.Where(
+		selectBuilder.Equal("tenant_id", tenant.ID(ctx)),
+		selectBuilder.Or(likes...),
+	).OrderBy(strings.Join(starts, " + ")).Desc().Limit(maxSearchCandidates).Build()
+
+	tracer.TagStatement(ctx, statement)
+	rows, err := i.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = rows.Close() }()
+
+	var candidates []gopherapi.Gopher
+	for rows.Next() {
+		sqlGopher := sqlGopher{}
+
+		err := rows.Scan(sqlGopherStruct.Addr(&sqlGopher)...)
+		if err != nil {
+			return nil, err
+		}
+
+		candidates = append(candidates, gopherapi.Gopher{
+			ID:        sqlGopher.ID,
+			Name:      sqlGopher.Name,
+			Image:     sqlGopher.Image,
+			Age:       sqlGopher.Age,
+			CreatedAt: sqlGopher.CreatedAt,
+			UpdatedAt: sqlGopher.UpdatedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return searching.Rank(query, candidates, limit), nil
+}
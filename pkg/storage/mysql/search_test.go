@@ -0,0 +1,53 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+const searchQuery = "SELECT gophers.id, gophers.name, gophers.image, gophers.age, gophers.created_at, gophers.updated_at, gophers.tenant_id " +
+	"FROM gophers WHERE tenant_id = ? AND (name LIKE ? OR name LIKE ?) ORDER BY (name LIKE ? OR name LIKE ?) DESC LIMIT 1000"
+
+func Test_SearchIndex_Search_RepositoryError(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	sqlMock.ExpectQuery(searchQuery).
+		WithArgs(tenant.Default, "%jen%", "%eny%", "jeny%", "% jeny%").
+		WillReturnError(errors.New("something-failed"))
+
+	index := NewSearchIndex("gophers", db)
+	_, err = index.Search(context.Background(), "Jeny", 10)
+
+	assert.Error(t, err)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func Test_SearchIndex_Search_Success(t *testing.T) {
+	db, sqlMock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+
+	// the trigrams find the gophers not matching the query, which aren't ranked
+	sqlMock.ExpectQuery(searchQuery).
+		WithArgs(tenant.Default, "%jen%", "%eny%", "jeny%", "% jeny%").
+		WillReturnRows(sqlmock.NewRows(
+			[]string{"id", "name", "image", "age", "created_at", "updated_at", "tenant_id"}).
+			AddRow("01D3XZ3ZHCP3KG9VT4FGAD8KDR", "Jenny", "", 18, nil, nil, tenant.Default).
+			AddRow("01D3XZ7CN92AKS9HAPSZ4D5DP9", "Jennifer Kowalski", "", 35, nil, nil, tenant.Default).
+			AddRow("01D3XZ89NFJZ9QT2DHVD462AC2", "Rodeny", "", 21, nil, nil, tenant.Default),
+		)
+
+	index := NewSearchIndex("gophers", db)
+	results, err := index.Search(context.Background(), "Jeny", 10)
+
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Jenny", results[0].Gopher.Name)
+	assert.Equal(t, "<em>Jennifer</em> Kowalski", results[1].Highlights["name"])
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"github.com/alicebob/miniredis/v2"
 	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func Test_GopherRepository_Example(t *testing.T) {
@@ -41,3 +43,67 @@ func Test_GopherRepository_Example(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []gopher.Gopher{gopherA, gopherB}, results)
 }
+
+func Test_GopherRepository_TenantIsolation(t *testing.T) {
+	// GIVEN a miniredis instance and a Redis implementation of result.Repository
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	repo := NewRepository(NewConn(s.Addr()))
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	// WHEN each tenant creates a gopher with the same ID
+	gopherA, gopherB := buildGopher("123ABC"), buildGopher("123ABC")
+	gopherB.Name = "Globex gopher"
+
+	assert.NoError(t, repo.CreateGopher(acme, &gopherA))
+	assert.NoError(t, repo.CreateGopher(globex, &gopherB))
+
+	// THEN each tenant only sees its own gopher
+	result, err := repo.FetchGopherByID(globex, "123ABC")
+	assert.NoError(t, err)
+	assert.Equal(t, gopherB, *result)
+
+	results, err := repo.FetchGophers(acme)
+	assert.NoError(t, err)
+	assert.Equal(t, []gopher.Gopher{gopherA}, results)
+
+	// AND the default tenant sees none of them
+	results, err = repo.FetchGophers(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func Test_GopherRepository_UpdateKeepsCreatedAt(t *testing.T) {
+	// GIVEN a miniredis instance and a gopher created some time ago
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	repo := NewRepository(NewConn(s.Addr()))
+	createdAt := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	g := buildGopher("123ABC")
+	g.CreatedAt = &createdAt
+	assert.NoError(t, repo.CreateGopher(context.Background(), &g))
+
+	// WHEN it is updated without its creation time
+	updatedAt := time.Date(2020, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.UpdateGopher(context.Background(), g.ID, gopher.Gopher{ID: g.ID, Name: "Jenny", Age: 18, UpdatedAt: &updatedAt}))
+
+	// THEN it keeps the moment it was created at
+	result, err := repo.FetchGopherByID(context.Background(), g.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jenny", result.Name)
+	assert.Equal(t, createdAt, *result.CreatedAt)
+	assert.Equal(t, updatedAt, *result.UpdatedAt)
+
+	// AND the missing gophers are not created
+	err = repo.UpdateGopher(context.Background(), "ABC123", gopher.Gopher{ID: "ABC123"})
+	assert.ErrorIs(t, err, gopher.ErrNotFound)
+}
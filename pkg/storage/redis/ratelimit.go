@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+	"github.com/gomodule/redigo/redis"
+)
+
+// takeScript refills and takes a token from the bucket atomically, so every instance
+// sharing the redis sees the same bucket, the time is given by the caller in ms
+var takeScript = redis.NewScript(1, `
+local rate = tonumber(ARGV[1]) / 1000
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate))
+return {allowed, tostring(tokens)}
+`)
+
+// rateLimitPrefix prefixes the keys of the buckets, the braces can't be part of a tenant
+// so they never clash with the keys of the gophers, prefixed by their tenant
+const rateLimitPrefix = "{rl}:"
+
+type rateLimitStore struct {
+	pool *redis.Pool
+}
+
+// NewRateLimitStore instances a Redis implementation of the ratelimit.Store,
+// suitable for deployments with several instances
+func NewRateLimitStore(pool *redis.Pool) ratelimit.Store {
+	return rateLimitStore{pool: pool}
+}
+
+// Take satisfies the ratelimit.Store interface
+func (s rateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	conn, err := s.pool.GetContext(ctx)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	values, err := redis.Values(takeScript.Do(conn, rateLimitPrefix+key, limit.Rate, limit.Burst, now))
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	var (
+		allowed int
+		tokens  string
+	)
+	if _, err := redis.Scan(values, &allowed, &tokens); err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	remaining, err := strconv.ParseFloat(tokens, 64)
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+	return ratelimit.NewResult(allowed == 1, remaining, limit), nil
+}
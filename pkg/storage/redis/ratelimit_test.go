@@ -0,0 +1,69 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/ratelimit"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RateLimitStore_Take(t *testing.T) {
+	// GIVEN a miniredis instance shared by two stores, as two instances of the api would do
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	storeA, storeB := NewRateLimitStore(NewConn(s.Addr())), NewRateLimitStore(NewConn(s.Addr()))
+	limit := ratelimit.Limit{Rate: 0.001, Burst: 2}
+
+	// WHEN the burst is consumed between both stores
+	result, err := storeA.Take(context.Background(), "client", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	result, err = storeB.Take(context.Background(), "client", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// THEN the next request is rejected by any of them
+	result, err = storeA.Take(context.Background(), "client", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.True(t, result.RetryAfter > 0)
+
+	// AND other clients are not affected
+	result, err = storeB.Take(context.Background(), "other", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func Test_RateLimitStore_KeysDontClashWithTenants(t *testing.T) {
+	// GIVEN a gopher of the tenant "ratelimit" whose ID is the key of a bucket
+	s, err := miniredis.Run()
+	if err != nil {
+		panic(err)
+	}
+	defer s.Close()
+
+	pool := NewConn(s.Addr())
+	ctx := tenant.WithTenant(context.Background(), "ratelimit")
+	repo := NewRepository(pool)
+	assert.NoError(t, repo.CreateGopher(ctx, gopher.New("client", "Jenny", "", 18)))
+
+	// WHEN a token is taken from the bucket
+	_, err = NewRateLimitStore(pool).Take(context.Background(), "client", ratelimit.Limit{Rate: 0.001, Burst: 2})
+	assert.NoError(t, err)
+
+	// THEN the gopher is left untouched
+	g, err := repo.FetchGopherByID(ctx, "client")
+	assert.NoError(t, err)
+	assert.Equal(t, "Jenny", g.Name)
+}
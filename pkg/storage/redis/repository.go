@@ -4,15 +4,50 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+
 	gopherapi "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+	"github.com/friendsofgo/gopherapi/pkg/tracer"
 	"github.com/gomodule/redigo/redis"
 	_ "github.com/lib/pq"
 )
 
 const (
-	onlyIfExists = "XX"
+	onlyIfNotExists = "NX"
 )
 
+// updateScript replaces the gopher only if it exists, keeping the moment it was created at
+var updateScript = redis.NewScript(1, `
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return false
+end
+
+local gopher = cjson.decode(ARGV[1])
+gopher.created_at = cjson.decode(current).created_at
+return redis.call("SET", KEYS[1], cjson.encode(gopher))
+`)
+
+// redisGopher is the stored representation of a gopher, with its timestamps
+type redisGopher struct {
+	gopherapi.Gopher
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func newRedisGopher(g gopherapi.Gopher) redisGopher {
+	return redisGopher{Gopher: g, CreatedAt: g.CreatedAt, UpdatedAt: g.UpdatedAt}
+}
+
+func (g redisGopher) toGopher() gopherapi.Gopher {
+	gopher := g.Gopher
+	gopher.CreatedAt, gopher.UpdatedAt = g.CreatedAt, g.UpdatedAt
+	return gopher
+}
+
 type gopherRepository struct {
 	pool *redis.Pool
 }
@@ -26,7 +61,7 @@ func NewRepository(pool *redis.Pool) gopherapi.Repository {
 
 // CreateGopher satisfies the gopherapi.Repository interface
 func (r gopherRepository) CreateGopher(ctx context.Context, gopher *gopherapi.Gopher) error {
-	bytes, err := json.Marshal(gopher)
+	bytes, err := json.Marshal(newRedisGopher(*gopher))
 	if err != nil {
 		return err
 	}
@@ -36,8 +71,15 @@ func (r gopherRepository) CreateGopher(ctx context.Context, gopher *gopherapi.Go
 		return err
 	}
 
-	_, err = conn.Do("SET", gopher.ID, string(bytes))
-	return err
+	tracer.TagStatement(ctx, "SET "+key(ctx, gopher.ID)+" "+onlyIfNotExists)
+	result, err := conn.Do("SET", key(ctx, gopher.ID), string(bytes), onlyIfNotExists)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("%w: %s", gopherapi.ErrAlreadyExists, gopher.ID)
+	}
+	return nil
 }
 
 func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopherapi.Gopher, error) {
@@ -46,7 +88,8 @@ func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopherapi.Gopher,
 		return nil, err
 	}
 
-	keys, err := redis.Strings(conn.Do("KEYS", "*"))
+	tracer.TagStatement(ctx, "KEYS "+key(ctx, "*"))
+	keys, err := redis.Strings(conn.Do("KEYS", key(ctx, "*")))
 	if err != nil {
 		return nil, err
 	}
@@ -56,8 +99,8 @@ func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopherapi.Gopher,
 	}
 
 	args := make([]interface{}, 0, len(keys))
-	for _, key := range keys {
-		args = append(args, key)
+	for _, k := range keys {
+		args = append(args, k)
 	}
 
 	results, err := redis.Strings(conn.Do("MGET", args...))
@@ -67,14 +110,14 @@ func (r gopherRepository) FetchGophers(ctx context.Context) ([]gopherapi.Gopher,
 
 	gophers := make([]gopherapi.Gopher, 0, len(results))
 	for _, result := range results {
-		gopher := gopherapi.Gopher{}
+		gopher := redisGopher{}
 
 		err := json.Unmarshal([]byte(result), &gopher)
 		if err != nil {
 			return nil, err
 		}
 
-		gophers = append(gophers, gopher)
+		gophers = append(gophers, gopher.toGopher())
 	}
 	return gophers, nil
 }
@@ -85,12 +128,19 @@ func (r gopherRepository) DeleteGopher(ctx context.Context, ID string) error {
 		return err
 	}
 
-	_, err = conn.Do("DEL", ID)
-	return err
+	tracer.TagStatement(ctx, "DEL "+key(ctx, ID))
+	deleted, err := redis.Int(conn.Do("DEL", key(ctx, ID)))
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return gopherapi.ErrNotFound
+	}
+	return nil
 }
 
 func (r gopherRepository) UpdateGopher(ctx context.Context, ID string, gopher gopherapi.Gopher) error {
-	bytes, err := json.Marshal(gopher)
+	bytes, err := json.Marshal(newRedisGopher(gopher))
 	if err != nil {
 		return err
 	}
@@ -100,11 +150,15 @@ func (r gopherRepository) UpdateGopher(ctx context.Context, ID string, gopher go
 		return err
 	}
 
-	result, err := conn.Do("SET", ID, string(bytes), onlyIfExists)
+	tracer.TagStatement(ctx, "EVALSHA "+updateScript.Hash()+" 1 "+key(ctx, ID))
+	result, err := updateScript.Do(conn, key(ctx, ID), string(bytes))
+	if err != nil {
+		return err
+	}
 	if result == nil {
-		return errors.New("not found")
+		return gopherapi.ErrNotFound
 	}
-	return err
+	return nil
 }
 
 func (r gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*gopherapi.Gopher, error) {
@@ -113,17 +167,93 @@ func (r gopherRepository) FetchGopherByID(ctx context.Context, ID string) (*goph
 		return nil, err
 	}
 
-	result, err := redis.String(conn.Do("GET", ID))
+	tracer.TagStatement(ctx, "GET "+key(ctx, ID))
+	result, err := redis.String(conn.Do("GET", key(ctx, ID)))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, gopherapi.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if result == "" {
-		return nil, errors.New("not found")
+	gopher := redisGopher{}
+	if err := json.Unmarshal([]byte(result), &gopher); err != nil {
+		return nil, err
+	}
+
+	g := gopher.toGopher()
+	return &g, nil
+}
+
+// FetchGophersByIDs satisfies the gopherapi.BatchRepository interface, reading the gophers with one MGET
+func (r gopherRepository) FetchGophersByIDs(ctx context.Context, IDs []string) ([]gopherapi.Gopher, error) {
+	if len(IDs) == 0 {
+		return []gopherapi.Gopher{}, nil
 	}
 
-	gopher := &gopherapi.Gopher{}
-	err = json.Unmarshal([]byte(result), gopher)
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	keys := make([]string, 0, len(IDs))
+	args := make([]interface{}, 0, len(IDs))
+	for _, ID := range IDs {
+		keys = append(keys, key(ctx, ID))
+		args = append(args, key(ctx, ID))
+	}
+
+	tracer.TagStatement(ctx, "MGET "+strings.Join(keys, " "))
+	results, err := redis.Values(conn.Do("MGET", args...))
+	if err != nil {
+		return nil, err
+	}
+
+	gophers := make([]gopherapi.Gopher, 0, len(results))
+	for _, result := range results {
+		// the gophers which don't exist are nil
+		if result == nil {
+			continue
+		}
+		bytes, err := redis.Bytes(result, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		gopher := redisGopher{}
+		if err := json.Unmarshal(bytes, &gopher); err != nil {
+			return nil, err
+		}
+		gophers = append(gophers, gopher.toGopher())
+	}
+	return gophers, nil
+}
+
+// HealthCheck satisfies the health.Checker interface, the PING is bounded by the deadline
+// of the context, redigo v2 reads the replies with a timeout but can't be cancelled
+func (r gopherRepository) HealthCheck(ctx context.Context) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		_, err = conn.Do("PING")
+		return err
+	}
+	timeout := time.Until(deadline)
+	if timeout <= 0 {
+		return ctx.Err()
+	}
+	_, err = redis.DoWithTimeout(conn, timeout, "PING")
+	return err
+}
 
-	return gopher, err
+// key prefixes the given gopher ID with the tenant of the context,
+// so every command is scoped to the keys of a single tenant
+func key(ctx context.Context, ID string) string {
+	return fmt.Sprintf("%s:%s", tenant.ID(ctx), ID)
 }
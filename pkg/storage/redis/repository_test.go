@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	gopherapi "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/health"
 	"github.com/gomodule/redigo/redis"
 	_ "github.com/lib/pq"
 	"github.com/rafaeljusto/redigomock"
@@ -17,7 +18,7 @@ func Test_GopherRepository_CreateGopher_RepositoryError(t *testing.T) {
 	gopher := buildGopher("123ABC")
 
 	conn := redigomock.NewConn()
-	conn.Command("SET", gopher.ID, gopherToJSONString(gopher)).ExpectError(errors.New("something failed"))
+	conn.Command("SET", "default:"+gopher.ID, gopherToJSONString(gopher), "NX").ExpectError(errors.New("something failed"))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.CreateGopher(context.Background(), &gopher)
@@ -30,7 +31,7 @@ func Test_GopherRepository_CreateGopher_Success(t *testing.T) {
 	gopher := buildGopher("123ABC")
 
 	conn := redigomock.NewConn()
-	conn.Command("SET", gopher.ID, gopherToJSONString(gopher)).Expect("OK")
+	conn.Command("SET", "default:"+gopher.ID, gopherToJSONString(gopher), "NX").Expect("OK")
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.CreateGopher(context.Background(), &gopher)
@@ -39,9 +40,22 @@ func Test_GopherRepository_CreateGopher_Success(t *testing.T) {
 	assert.NoError(t, conn.ExpectationsWereMet())
 }
 
+func Test_GopherRepository_CreateGopher_AlreadyExists(t *testing.T) {
+	gopher := buildGopher("123ABC")
+
+	conn := redigomock.NewConn()
+	conn.Command("SET", "default:"+gopher.ID, gopherToJSONString(gopher), "NX").Expect(nil)
+
+	repo := NewRepository(wrapRedisConn(conn))
+	err := repo.CreateGopher(context.Background(), &gopher)
+
+	assert.ErrorIs(t, err, gopherapi.ErrAlreadyExists)
+	assert.NoError(t, conn.ExpectationsWereMet())
+}
+
 func Test_GopherRepository_FetchGophers_RepositoryError(t *testing.T) {
 	conn := redigomock.NewConn()
-	conn.Command("KEYS", "*").ExpectError(errors.New("something failed"))
+	conn.Command("KEYS", "default:*").ExpectError(errors.New("something failed"))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	_, err := repo.FetchGophers(context.Background())
@@ -52,7 +66,7 @@ func Test_GopherRepository_FetchGophers_RepositoryError(t *testing.T) {
 
 func Test_GopherRepository_FetchGophers_NoRows(t *testing.T) {
 	conn := redigomock.NewConn()
-	conn.Command("KEYS", "*").Expect([]interface{}{})
+	conn.Command("KEYS", "default:*").Expect([]interface{}{})
 
 	repo := NewRepository(wrapRedisConn(conn))
 	gophers, err := repo.FetchGophers(context.Background())
@@ -64,8 +78,8 @@ func Test_GopherRepository_FetchGophers_NoRows(t *testing.T) {
 
 func Test_GopherRepository_FetchGophers_RowWithInvalidData(t *testing.T) {
 	conn := redigomock.NewConn()
-	conn.Command("KEYS", "*").Expect([]interface{}{"123", "456"})
-	conn.Command("MGET", "123", "456").Expect([]interface{}{"invalid-data"})
+	conn.Command("KEYS", "default:*").Expect([]interface{}{"default:123", "default:456"})
+	conn.Command("MGET", "default:123", "default:456").Expect([]interface{}{"invalid-data"})
 
 	repo := NewRepository(wrapRedisConn(conn))
 	_, err := repo.FetchGophers(context.Background())
@@ -79,8 +93,8 @@ func Test_GopherRepository_FetchGophers_Succeeded(t *testing.T) {
 	expectedGophers := []gopherapi.Gopher{gopherA, gopherB}
 
 	conn := redigomock.NewConn()
-	conn.Command("KEYS", "*").Expect([]interface{}{gopherA.ID, gopherB.ID})
-	conn.Command("MGET", gopherA.ID, gopherB.ID).Expect(
+	conn.Command("KEYS", "default:*").Expect([]interface{}{"default:" + gopherA.ID, "default:" + gopherB.ID})
+	conn.Command("MGET", "default:"+gopherA.ID, "default:"+gopherB.ID).Expect(
 		[]interface{}{gopherToJSONString(gopherA), gopherToJSONString(gopherB)},
 	)
 
@@ -96,7 +110,7 @@ func Test_GopherRepository_DeleteGopher_RepositoryError(t *testing.T) {
 	gopherID := "123ABC"
 
 	conn := redigomock.NewConn()
-	conn.Command("DEL", gopherID).ExpectError(errors.New("something failed"))
+	conn.Command("DEL", "default:"+gopherID).ExpectError(errors.New("something failed"))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.DeleteGopher(context.Background(), gopherID)
@@ -109,7 +123,7 @@ func Test_GopherRepository_DeleteGopher_Success(t *testing.T) {
 	gopherID := "123ABC"
 
 	conn := redigomock.NewConn()
-	conn.Command("DEL", gopherID).Expect(1)
+	conn.Command("DEL", "default:"+gopherID).Expect(int64(1))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.DeleteGopher(context.Background(), gopherID)
@@ -118,11 +132,24 @@ func Test_GopherRepository_DeleteGopher_Success(t *testing.T) {
 	assert.NoError(t, conn.ExpectationsWereMet())
 }
 
+func Test_GopherRepository_DeleteGopher_NotFound(t *testing.T) {
+	gopherID := "123ABC"
+
+	conn := redigomock.NewConn()
+	conn.Command("DEL", "default:"+gopherID).Expect(int64(0))
+
+	repo := NewRepository(wrapRedisConn(conn))
+	err := repo.DeleteGopher(context.Background(), gopherID)
+
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
+	assert.NoError(t, conn.ExpectationsWereMet())
+}
+
 func Test_GopherRepository_UpdateGopher_RepositoryError(t *testing.T) {
 	gopher := buildGopher("123ABC")
 
 	conn := redigomock.NewConn()
-	conn.Command("SET", gopher.ID, gopherToJSONString(gopher), "XX").ExpectError(errors.New("something failed"))
+	conn.Command("EVALSHA", updateScript.Hash(), 1, "default:"+gopher.ID, gopherToJSONString(gopher)).ExpectError(errors.New("something failed"))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.UpdateGopher(context.Background(), gopher.ID, gopher)
@@ -135,12 +162,12 @@ func Test_GopherRepository_UpdateGopher_NotFound(t *testing.T) {
 	gopher := buildGopher("123ABC")
 
 	conn := redigomock.NewConn()
-	conn.Command("SET", gopher.ID, gopherToJSONString(gopher), "XX").Expect(nil)
+	conn.Command("EVALSHA", updateScript.Hash(), 1, "default:"+gopher.ID, gopherToJSONString(gopher)).Expect(nil)
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.UpdateGopher(context.Background(), gopher.ID, gopher)
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
 	assert.NoError(t, conn.ExpectationsWereMet())
 }
 
@@ -148,7 +175,7 @@ func Test_GopherRepository_UpdateGopher_Success(t *testing.T) {
 	gopher := buildGopher("123ABC")
 
 	conn := redigomock.NewConn()
-	conn.Command("SET", gopher.ID, gopherToJSONString(gopher), "XX").Expect("OK")
+	conn.Command("EVALSHA", updateScript.Hash(), 1, "default:"+gopher.ID, gopherToJSONString(gopher)).Expect("OK")
 
 	repo := NewRepository(wrapRedisConn(conn))
 	err := repo.UpdateGopher(context.Background(), gopher.ID, gopher)
@@ -161,7 +188,7 @@ func Test_GopherRepository_FetchGopherByID_RepositoryError(t *testing.T) {
 	gopherID := "123ABC"
 
 	conn := redigomock.NewConn()
-	conn.Command("GET", gopherID).ExpectError(errors.New("something failed"))
+	conn.Command("GET", "default:"+gopherID).ExpectError(errors.New("something failed"))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	_, err := repo.FetchGopherByID(context.Background(), gopherID)
@@ -174,12 +201,12 @@ func Test_GopherRepository_FetchGopherByID_NoRows(t *testing.T) {
 	gopherID := "123ABC"
 
 	conn := redigomock.NewConn()
-	conn.Command("GET", gopherID).Expect(nil)
+	conn.Command("GET", "default:"+gopherID).Expect(nil)
 
 	repo := NewRepository(wrapRedisConn(conn))
 	_, err := repo.FetchGopherByID(context.Background(), gopherID)
 
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, gopherapi.ErrNotFound)
 	assert.NoError(t, conn.ExpectationsWereMet())
 }
 
@@ -187,7 +214,7 @@ func Test_GopherRepository_FetchGopherByID_RowWithInvalidData(t *testing.T) {
 	gopherID := "123ABC"
 
 	conn := redigomock.NewConn()
-	conn.Command("GET", gopherID).Expect("invalid-data")
+	conn.Command("GET", "default:"+gopherID).Expect("invalid-data")
 
 	repo := NewRepository(wrapRedisConn(conn))
 	_, err := repo.FetchGopherByID(context.Background(), gopherID)
@@ -201,7 +228,7 @@ func Test_GopherRepository_FetchGopherByID_Succeeded(t *testing.T) {
 	expectedGopher := buildGopher(gopherID)
 
 	conn := redigomock.NewConn()
-	conn.Command("GET", gopherID).Expect(gopherToJSONString(expectedGopher))
+	conn.Command("GET", "default:"+gopherID).Expect(gopherToJSONString(expectedGopher))
 
 	repo := NewRepository(wrapRedisConn(conn))
 	gopher, err := repo.FetchGopherByID(context.Background(), gopherID)
@@ -211,6 +238,38 @@ func Test_GopherRepository_FetchGopherByID_Succeeded(t *testing.T) {
 	assert.Equal(t, &expectedGopher, gopher)
 }
 
+func Test_GopherRepository_FetchGophersByIDs_Succeeded(t *testing.T) {
+	gopherA := buildGopher("123ABC")
+	gopherB := buildGopher("456DEF")
+
+	conn := redigomock.NewConn()
+	conn.Command("MGET", "default:"+gopherA.ID, "default:missing", "default:"+gopherB.ID).Expect(
+		[]interface{}{gopherToJSONString(gopherA), nil, gopherToJSONString(gopherB)},
+	)
+
+	repo := NewRepository(wrapRedisConn(conn)).(gopherapi.BatchRepository)
+	gophers, err := repo.FetchGophersByIDs(context.Background(), []string{gopherA.ID, "missing", gopherB.ID})
+
+	assert.NoError(t, err)
+	assert.NoError(t, conn.ExpectationsWereMet())
+	assert.Equal(t, []gopherapi.Gopher{gopherA, gopherB}, gophers)
+}
+
+func Test_GopherRepository_FetchGophersByIDs_ReleasesTheConnection(t *testing.T) {
+	// GIVEN a pool which keeps no idle connections
+	conn := redigomock.NewConn()
+	conn.Command("MGET", "default:123ABC").Expect([]interface{}{nil})
+	pool := wrapRedisConn(conn)
+	pool.MaxIdle = 0
+
+	// WHEN the gophers are fetched
+	_, err := NewRepository(pool).(gopherapi.BatchRepository).FetchGophersByIDs(context.Background(), []string{"123ABC"})
+
+	// THEN the connection taken is given back to the pool
+	assert.NoError(t, err)
+	assert.Zero(t, pool.ActiveCount())
+}
+
 func buildGopher(ID string) gopherapi.Gopher {
 	return gopherapi.Gopher{
 		ID:    ID,
@@ -232,3 +291,34 @@ func wrapRedisConn(conn redis.Conn) *redis.Pool {
 		Dial:        func() (redis.Conn, error) { return conn, nil },
 	}
 }
+
+func Test_GopherRepository_HealthCheck(t *testing.T) {
+	conn := redigomock.NewConn()
+	conn.Command("PING").Expect("PONG")
+
+	repo := NewRepository(wrapRedisConn(conn)).(health.Checker)
+	err := repo.HealthCheck(context.Background())
+
+	assert.NoError(t, err)
+	assert.NoError(t, conn.ExpectationsWereMet())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, repo.HealthCheck(ctx))
+}
+
+func Test_GopherRepository_HealthCheck_Deadline(t *testing.T) {
+	// GIVEN a health check whose deadline has passed
+	conn := redigomock.NewConn()
+	ping := conn.Command("PING").Expect("PONG")
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	// WHEN the repository is checked
+	repo := NewRepository(wrapRedisConn(conn)).(health.Checker)
+	err := repo.HealthCheck(ctx)
+
+	// THEN it fails without waiting for redis
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, conn.Stats(ping))
+}
@@ -0,0 +1,80 @@
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Default is the tenant of the requests that don't belong to any tenant
+const Default = "default"
+
+var (
+	contextKeyTenant = contextKey("tenant")
+
+	validID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+)
+
+type contextKey string
+
+func (c contextKey) String() string {
+	return "tenant" + string(c)
+}
+
+// WithTenant returns a copy of ctx scoped to the given tenant
+func WithTenant(ctx context.Context, ID string) context.Context {
+	return context.WithValue(ctx, contextKeyTenant, ID)
+}
+
+// FromContext gets the tenant from context
+func FromContext(ctx context.Context) (string, bool) {
+	ID, ok := ctx.Value(contextKeyTenant).(string)
+	return ID, ok
+}
+
+// ID gets the tenant from context, or the default tenant when there is none,
+// this is the tenant the repositories must scope every query to
+func ID(ctx context.Context) string {
+	if ID, ok := FromContext(ctx); ok && ID != "" {
+		return ID
+	}
+	return Default
+}
+
+// Valid reports whether the given tenant identifier is well formed
+func Valid(ID string) bool {
+	return validID.MatchString(ID)
+}
+
+// Resolver finds the tenant of a request from a header or a subdomain
+type Resolver struct {
+	// Header holding the tenant identifier, e.g. X-Tenant-ID
+	Header string
+	// Domain is the base domain, when given the first label of the subdomains is the tenant
+	Domain string
+}
+
+// Resolve returns the tenant of the request, if any
+func (r Resolver) Resolve(req *http.Request) (string, bool) {
+	if r.Header != "" {
+		if ID := req.Header.Get(r.Header); ID != "" {
+			return ID, true
+		}
+	}
+
+	if r.Domain != "" {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		suffix := "." + strings.TrimPrefix(r.Domain, ".")
+		if strings.HasSuffix(host, suffix) {
+			labels := strings.Split(strings.TrimSuffix(host, suffix), ".")
+			return labels[len(labels)-1], true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,186 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/friendsofgo/gopherapi"
+
+type openTelemetryTracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewOpenTelemetryTracer creates a tracer exporting with OTLP/HTTP to the collector of the given URL
+// and propagating the W3C traceparent header, the returned closer flushes the spans pending to be
+// exported and must be closed on shutdown
+func NewOpenTelemetryTracer(serviceName, collectorURL string, opts ...Option) (Tracer, io.Closer, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	exporterOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(fmt.Sprintf("%s/v1/traces", collectorURL)),
+	}
+	if o.batching.Timeout > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithTimeout(o.batching.Timeout))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var batcherOpts []sdktrace.BatchSpanProcessorOption
+	if o.batching.Size > 0 {
+		batcherOpts = append(batcherOpts, sdktrace.WithMaxExportBatchSize(o.batching.Size))
+	}
+	if o.batching.Interval > 0 {
+		batcherOpts = append(batcherOpts, sdktrace.WithBatchTimeout(o.batching.Interval))
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, batcherOpts...),
+		sdktrace.WithSampler(sdktrace.ParentBased(newRouteSampler(o.sampling))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+
+	t := &openTelemetryTracer{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+	return t, providerCloser{provider}, nil
+}
+
+// StartSpanFromContext starts an OpenTelemetry span child of the one in the context, if any
+func (t *openTelemetryTracer) StartSpanFromContext(ctx context.Context, name string) (Span, context.Context) {
+	ctx, sp := t.tracer.Start(ctx, name)
+	span := openTelemetrySpan{sp}
+	return span, withSpan(ctx, span)
+}
+
+// Middleware traces the HTTP requests continuing the traceparent header of the callers
+func (t *openTelemetryTracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := t.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, sp := t.tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", r.Method),
+				attribute.String("http.path", r.URL.Path),
+			),
+		)
+		defer sp.End()
+
+		rec := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(withSpan(ctx, openTelemetrySpan{sp})))
+
+		sp.SetAttributes(attribute.Int("http.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			sp.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+type openTelemetrySpan struct {
+	span trace.Span
+}
+
+func (s openTelemetrySpan) SetName(name string)   { s.span.SetName(name) }
+func (s openTelemetrySpan) Tag(key, value string) { s.span.SetAttributes(attribute.String(key, value)) }
+func (s openTelemetrySpan) Annotate(t time.Time, value string) {
+	s.span.AddEvent(value, trace.WithTimestamp(t))
+}
+func (s openTelemetrySpan) SetError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+func (s openTelemetrySpan) Finish()         { s.span.End() }
+func (s openTelemetrySpan) TraceID() string { return s.span.SpanContext().TraceID().String() }
+func (s openTelemetrySpan) SpanID() string  { return s.span.SpanContext().SpanID().String() }
+
+// routeSampler samples the traces started by the server by their ID, with the rate of the
+// route of the request when it is overridden
+type routeSampler struct {
+	prefixes []string
+	routes   map[string]sdktrace.Sampler
+	fallback sdktrace.Sampler
+}
+
+func newRouteSampler(sampling Sampling) sdktrace.Sampler {
+	routes := make(map[string]sdktrace.Sampler, len(sampling.Routes))
+	for prefix, rate := range sampling.Routes {
+		routes[prefix] = sdktrace.TraceIDRatioBased(rate)
+	}
+	return routeSampler{
+		prefixes: sampling.routePrefixes(),
+		routes:   routes,
+		fallback: sdktrace.TraceIDRatioBased(sampling.Rate),
+	}
+}
+
+// ShouldSample implements sdktrace.Sampler
+func (s routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, attr := range p.Attributes {
+		if attr.Key != "http.path" {
+			continue
+		}
+		if prefix, ok := matchRoute(s.prefixes, attr.Value.AsString()); ok {
+			return s.routes[prefix].ShouldSample(p)
+		}
+	}
+	return s.fallback.ShouldSample(p)
+}
+
+// Description implements sdktrace.Sampler
+func (s routeSampler) Description() string {
+	return "RouteSampler"
+}
+
+// providerCloser shuts down the tracer provider exporting the pending spans
+type providerCloser struct {
+	provider *sdktrace.TracerProvider
+}
+
+// Close implements io.Closer
+func (c providerCloser) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return c.provider.Shutdown(ctx)
+}
+
+// statusWriter keeps the status of the response to tag it on the span
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher so streamed responses keep working
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController reaches the connection
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
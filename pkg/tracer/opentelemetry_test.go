@@ -0,0 +1,102 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is a stand-in of an OpenTelemetry collector receiving OTLP/HTTP protobuf requests
+type collector struct {
+	mtx   sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	var req collectortrace.ExportTraceServiceRequest
+	if r.URL.Path != "/v1/traces" || proto.Unmarshal(body, &req) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func Test_OpenTelemetryTracer_ExportsPropagatedTraces(t *testing.T) {
+	c := &collector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	trc, closer, err := NewOpenTelemetryTracer("gopherapi", srv.URL)
+	require.NoError(t, err)
+
+	// GIVEN a request of a caller propagating its trace with the W3C traceparent header
+	var traceID, spanID string
+	handler := trc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, ctx := trc.StartSpanFromContext(r.Context(), "FetchGopherByID")
+		traceID, spanID = SpanFromContext(r.Context()).TraceID(), SpanFromContext(ctx).SpanID()
+		span.Finish()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/gophers", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// WHEN the tracer is closed the pending spans are exported to the collector
+	require.NoError(t, closer.Close())
+
+	// THEN the trace of the caller is continued
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	require.Len(t, c.spans, 2)
+	for _, span := range c.spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(span.TraceId))
+	}
+	assert.Contains(t, []string{hex.EncodeToString(c.spans[0].SpanId), hex.EncodeToString(c.spans[1].SpanId)}, spanID)
+}
+
+func Test_RouteSampler(t *testing.T) {
+	sampler := newRouteSampler(Sampling{
+		Rate:   1,
+		Routes: map[string]float64{"/healthz": 0, "/gophers/search": 1},
+	})
+	traceID := trace.TraceID{0x01}
+
+	testData := []struct {
+		name     string
+		attrs    []attribute.KeyValue
+		decision sdktrace.SamplingDecision
+	}{
+		{name: "route not sampled", attrs: []attribute.KeyValue{attribute.String("http.path", "/healthz/ready")}, decision: sdktrace.Drop},
+		{name: "route sampled", attrs: []attribute.KeyValue{attribute.String("http.path", "/gophers/search")}, decision: sdktrace.RecordAndSample},
+		{name: "route with the rate of the tracer", attrs: []attribute.KeyValue{attribute.String("http.path", "/gophers")}, decision: sdktrace.RecordAndSample},
+		{name: "span without route", decision: sdktrace.RecordAndSample},
+	}
+
+	for _, tt := range testData {
+		t.Run(tt.name, func(t *testing.T) {
+			res := sampler.ShouldSample(sdktrace.SamplingParameters{TraceID: traceID, Name: "GET", Attributes: tt.attrs})
+			assert.Equal(t, tt.decision, res.Decision)
+		})
+	}
+}
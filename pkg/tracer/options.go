@@ -0,0 +1,109 @@
+package tracer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Declare the sampling strategies
+const (
+	// SamplingCounting samples exactly the given rate of the traces started by this server
+	SamplingCounting = "counting"
+	// SamplingBoundary samples the traces by their ID, so every service using the same
+	// rate takes the same decision for a trace
+	SamplingBoundary = "boundary"
+)
+
+// Sampling decides which traces are reported
+type Sampling struct {
+	// Strategy is SamplingCounting or SamplingBoundary, the OpenTelemetry
+	// tracer always samples by trace ID
+	Strategy string
+	// Rate is the ratio of traces sampled, from 0 to 1
+	Rate float64
+	// Routes overrides the rate of the requests whose path starts with the given prefixes
+	Routes map[string]float64
+	// OnError reports the traces with errors even when they were not sampled, only
+	// supported by the zipkin tracer
+	OnError bool
+}
+
+// Batching configures how the spans are sent to the collector, zero values keep the defaults of each reporter
+type Batching struct {
+	// Size is the maximum number of spans sent together
+	Size int
+	// Interval is the maximum time a span waits to be sent
+	Interval time.Duration
+	// Timeout is the maximum time to send a batch
+	Timeout time.Duration
+}
+
+type options struct {
+	sampling Sampling
+	batching Batching
+}
+
+// Option configures a tracer
+type Option func(*options)
+
+// WithSampling reports only the traces chosen by the given sampling, by default all of them are
+func WithSampling(sampling Sampling) Option {
+	return func(o *options) {
+		o.sampling = sampling
+	}
+}
+
+// WithBatching sends the spans to the collector with the given batching
+func WithBatching(batching Batching) Option {
+	return func(o *options) {
+		o.batching = batching
+	}
+}
+
+func newOptions(opts []Option) (options, error) {
+	o := options{sampling: Sampling{Strategy: SamplingCounting, Rate: 1}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o, o.sampling.validate()
+}
+
+func (s Sampling) validate() error {
+	switch s.Strategy {
+	case "", SamplingCounting, SamplingBoundary:
+	default:
+		return fmt.Errorf("unknown sampling strategy %q", s.Strategy)
+	}
+
+	if s.Rate < 0 || s.Rate > 1 {
+		return fmt.Errorf("sampling rate %v out of range [0, 1]", s.Rate)
+	}
+	for prefix, rate := range s.Routes {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("sampling rate %v of %s out of range [0, 1]", rate, prefix)
+		}
+	}
+	return nil
+}
+
+// routePrefixes returns the prefixes of the routes overriding the rate, longest first
+func (s Sampling) routePrefixes() []string {
+	prefixes := make([]string, 0, len(s.Routes))
+	for prefix := range s.Routes {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	return prefixes
+}
+
+// matchRoute returns the longest of the prefixes matching the path
+func matchRoute(prefixes []string, path string) (string, bool) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
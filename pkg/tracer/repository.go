@@ -0,0 +1,108 @@
+package tracer
+
+import (
+	"context"
+	"time"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+type repository struct {
+	next    gopher.Repository
+	backend string
+	tracer  Tracer
+}
+
+// NewRepository wraps a gopher repository creating a span for each operation, tagged with
+// the given backend name, the repositories can tag their statements with TagStatement
+func NewRepository(next gopher.Repository, backend string, tracer Tracer) gopher.Repository {
+	return &repository{next: next, backend: backend, tracer: tracer}
+}
+
+// CreateGopher saves a given gopher
+func (r *repository) CreateGopher(ctx context.Context, g *gopher.Gopher) (err error) {
+	span, ctx := r.start(ctx, "CreateGopher", g.ID)
+	defer finish(span, &err)
+	return r.next.CreateGopher(ctx, g)
+}
+
+// FetchGophers return all gophers saved in storage
+func (r *repository) FetchGophers(ctx context.Context) (gophers []gopher.Gopher, err error) {
+	span, ctx := r.start(ctx, "FetchGophers", "")
+	defer finish(span, &err)
+	return r.next.FetchGophers(ctx)
+}
+
+// DeleteGopher remove gopher with given ID
+func (r *repository) DeleteGopher(ctx context.Context, ID string) (err error) {
+	span, ctx := r.start(ctx, "DeleteGopher", ID)
+	defer finish(span, &err)
+	return r.next.DeleteGopher(ctx, ID)
+}
+
+// UpdateGopher modify gopher with given ID and given new data
+func (r *repository) UpdateGopher(ctx context.Context, ID string, g gopher.Gopher) (err error) {
+	span, ctx := r.start(ctx, "UpdateGopher", ID)
+	defer finish(span, &err)
+	return r.next.UpdateGopher(ctx, ID, g)
+}
+
+// FetchGopherByID returns the gopher with given ID
+func (r *repository) FetchGopherByID(ctx context.Context, ID string) (g *gopher.Gopher, err error) {
+	span, ctx := r.start(ctx, "FetchGopherByID", ID)
+	defer finish(span, &err)
+	return r.next.FetchGopherByID(ctx, ID)
+}
+
+// FetchGopherRevisions returns all revisions of the gopher with given ID when the wrapped repository keeps them
+func (r *repository) FetchGopherRevisions(ctx context.Context, ID string) (revisions []gopher.Revision, err error) {
+	next, ok := r.next.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+	span, ctx := r.start(ctx, "FetchGopherRevisions", ID)
+	defer finish(span, &err)
+	return next.FetchGopherRevisions(ctx, ID)
+}
+
+// FetchGopherAsOf returns the gopher with given ID as it was at the given moment when the wrapped repository keeps revisions
+func (r *repository) FetchGopherAsOf(ctx context.Context, ID string, at time.Time) (g *gopher.Gopher, err error) {
+	next, ok := r.next.(gopher.RevisionRepository)
+	if !ok {
+		return nil, gopher.ErrRevisionsNotSupported
+	}
+	span, ctx := r.start(ctx, "FetchGopherAsOf", ID)
+	defer finish(span, &err)
+	return next.FetchGopherAsOf(ctx, ID, at)
+}
+
+// StreamGophers calls fn with each gopher saved in storage, streamed when the wrapped repository is able to
+func (r *repository) StreamGophers(ctx context.Context, fn func(gopher.Gopher) error) (err error) {
+	span, ctx := r.start(ctx, "StreamGophers", "")
+	defer finish(span, &err)
+	return gopher.StreamGophers(ctx, r.next, fn)
+}
+
+// FetchGophersByIDs returns the gophers with the given IDs, looked up together when the wrapped repository is able to
+func (r *repository) FetchGophersByIDs(ctx context.Context, IDs []string) (gophers []gopher.Gopher, err error) {
+	span, ctx := r.start(ctx, "FetchGophersByIDs", "")
+	defer finish(span, &err)
+	return gopher.FetchGophersByIDs(ctx, r.next, IDs)
+}
+
+func (r *repository) start(ctx context.Context, operation, ID string) (Span, context.Context) {
+	span, ctx := r.tracer.StartSpanFromContext(ctx, "repository."+operation)
+	span.Tag("db.system", r.backend)
+	if ID != "" {
+		span.Tag("gopher.id", ID)
+	}
+	return span, ctx
+}
+
+// finish marks the span as failed when the operation returned an error and ends it
+func finish(span Span, err *error) {
+	if *err != nil {
+		span.SetError(*err)
+	}
+	span.Finish()
+}
@@ -0,0 +1,87 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+)
+
+// recordingTracer keeps the finished spans to assert on them
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) StartSpanFromContext(ctx context.Context, name string) (Span, context.Context) {
+	span := &recordedSpan{name: name, tags: map[string]string{}}
+	t.spans = append(t.spans, span)
+	return span, withSpan(ctx, span)
+}
+
+func (t *recordingTracer) Middleware(next http.Handler) http.Handler {
+	return next
+}
+
+type recordedSpan struct {
+	name     string
+	tags     map[string]string
+	err      error
+	finished bool
+}
+
+func (s *recordedSpan) SetName(name string)        { s.name = name }
+func (s *recordedSpan) Tag(key, value string)      { s.tags[key] = value }
+func (s *recordedSpan) Annotate(time.Time, string) {}
+func (s *recordedSpan) SetError(err error)         { s.err = err }
+func (s *recordedSpan) Finish()                    { s.finished = true }
+func (s *recordedSpan) TraceID() string            { return "" }
+func (s *recordedSpan) SpanID() string             { return "" }
+
+type statementRepository struct {
+	gopher.Repository
+	err error
+}
+
+func (r statementRepository) FetchGopherByID(ctx context.Context, ID string) (*gopher.Gopher, error) {
+	TagStatement(ctx, "SELECT * FROM gophers WHERE id = ?")
+	return nil, r.err
+}
+
+func Test_Repository_TracesOperations(t *testing.T) {
+	trc := &recordingTracer{}
+	repo := NewRepository(statementRepository{err: errors.New("connection refused")}, "mysql", trc)
+
+	// WHEN the wrapped repository fails
+	_, err := repo.FetchGopherByID(context.Background(), "123ABC")
+	assert.Error(t, err)
+
+	// THEN its span is tagged with the backend, the gopher, the statement and the error
+	require.Len(t, trc.spans, 1)
+	span := trc.spans[0]
+	assert.Equal(t, "repository.FetchGopherByID", span.name)
+	assert.Equal(t, map[string]string{
+		"db.system":    "mysql",
+		"gopher.id":    "123ABC",
+		"db.statement": "SELECT * FROM gophers WHERE id = ?",
+	}, span.tags)
+	assert.EqualError(t, span.err, "connection refused")
+	assert.True(t, span.finished)
+}
+
+func Test_Repository_ForwardsOptionalInterfaces(t *testing.T) {
+	trc := &recordingTracer{}
+	repo := NewRepository(statementRepository{}, "redis", trc)
+
+	revisions, ok := repo.(gopher.RevisionRepository)
+	require.True(t, ok)
+
+	_, err := revisions.FetchGopherRevisions(context.Background(), "123ABC")
+	assert.Equal(t, gopher.ErrRevisionsNotSupported, err)
+	assert.Empty(t, trc.spans)
+}
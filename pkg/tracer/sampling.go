@@ -0,0 +1,158 @@
+package tracer
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter"
+)
+
+const (
+	// maxPendingTraces bounds the traces kept in memory waiting for their local root span
+	maxPendingTraces = 10000
+	// pendingTraceTTL bounds the time a trace waits for its local root span, the ones whose
+	// root span is never reported, e.g. lost to a panic, are flushed with the spans they have
+	pendingTraceTTL = time.Minute
+)
+
+// zipkinSampler returns the sampler of the traces started by the server
+func (s Sampling) zipkinSampler() (zipkin.Sampler, error) {
+	if s.Strategy == SamplingBoundary {
+		return zipkin.NewBoundarySampler(s.Rate, 0)
+	}
+	return zipkin.NewCountingSampler(s.Rate)
+}
+
+// zipkinRouteSamplers returns a counting sampler for each route overriding the rate,
+// the routes are sampled before the trace exists so its ID can't be used
+func (s Sampling) zipkinRouteSamplers() (map[string]zipkin.Sampler, error) {
+	samplers := make(map[string]zipkin.Sampler, len(s.Routes))
+	for prefix, rate := range s.Routes {
+		sampler, err := zipkin.NewCountingSampler(rate)
+		if err != nil {
+			return nil, err
+		}
+		samplers[prefix] = sampler
+	}
+	return samplers, nil
+}
+
+type pendingTrace struct {
+	traceID model.TraceID
+	started time.Time
+	sampled bool
+	failed  bool
+	spans   []model.SpanModel
+}
+
+// errorSamplingReporter records every trace and reports the ones sampled or with errors once
+// their local root span finishes, the spans of the rest are dropped
+type errorSamplingReporter struct {
+	next    reporter.Reporter
+	sampler zipkin.Sampler
+	now     func() time.Time
+
+	mtx    sync.Mutex
+	traces map[model.TraceID]*list.Element
+	// order keeps the pending traces oldest first, to evict the expired ones
+	order *list.List
+}
+
+func newErrorSamplingReporter(next reporter.Reporter, sampler zipkin.Sampler) *errorSamplingReporter {
+	return &errorSamplingReporter{
+		next:    next,
+		sampler: sampler,
+		now:     time.Now,
+		traces:  make(map[model.TraceID]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// decide keeps the sampling decision taken for the trace
+func (r *errorSamplingReporter) decide(traceID model.TraceID, sampled bool) {
+	r.mtx.Lock()
+	r.trace(traceID).sampled = sampled
+	evicted := r.evict()
+	r.mtx.Unlock()
+
+	r.flush(evicted...)
+}
+
+// Send implements reporter.Reporter
+func (r *errorSamplingReporter) Send(span model.SpanModel) {
+	r.mtx.Lock()
+	t := r.trace(span.TraceID)
+	t.spans = append(t.spans, span)
+	if _, ok := span.Tags[string(zipkin.TagError)]; ok {
+		t.failed = true
+	}
+
+	var finished []*pendingTrace
+	if span.ParentID == nil || span.Kind == model.Server {
+		r.order.Remove(r.traces[span.TraceID])
+		delete(r.traces, span.TraceID)
+		finished = append(finished, t)
+	}
+	finished = append(finished, r.evict()...)
+	r.mtx.Unlock()
+
+	r.flush(finished...)
+}
+
+// Close reports the pending traces and closes the wrapped reporter
+func (r *errorSamplingReporter) Close() error {
+	r.mtx.Lock()
+	pending := make([]*pendingTrace, 0, r.order.Len())
+	for e := r.order.Front(); e != nil; e = e.Next() {
+		pending = append(pending, e.Value.(*pendingTrace))
+	}
+	r.traces = make(map[model.TraceID]*list.Element)
+	r.order.Init()
+	r.mtx.Unlock()
+
+	r.flush(pending...)
+	return r.next.Close()
+}
+
+// trace returns the pending trace of the given ID, starting it when there's none
+func (r *errorSamplingReporter) trace(traceID model.TraceID) *pendingTrace {
+	if e, ok := r.traces[traceID]; ok {
+		return e.Value.(*pendingTrace)
+	}
+
+	t := &pendingTrace{traceID: traceID, started: r.now(), sampled: r.sampler(traceID.Low)}
+	r.traces[traceID] = r.order.PushBack(t)
+	return t
+}
+
+// evict removes the pending traces expired, and the oldest ones when too many are pending,
+// returning them to be flushed once the lock is released
+func (r *errorSamplingReporter) evict() []*pendingTrace {
+	var evicted []*pendingTrace
+	expired := r.now().Add(-pendingTraceTTL)
+	for e := r.order.Front(); e != nil; e = r.order.Front() {
+		t := e.Value.(*pendingTrace)
+		if r.order.Len() <= maxPendingTraces && t.started.After(expired) {
+			break
+		}
+		r.order.Remove(e)
+		delete(r.traces, t.traceID)
+		evicted = append(evicted, t)
+	}
+	return evicted
+}
+
+// flush reports the spans of the given traces sampled or with errors
+func (r *errorSamplingReporter) flush(traces ...*pendingTrace) {
+	for _, t := range traces {
+		if !t.sampled && !t.failed {
+			continue
+		}
+		for _, span := range t.spans {
+			r.next.Send(span)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package tracer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/reporter"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordTo(rec *recorder.ReporterRecorder) ZipkinReporter {
+	return func(Batching) (reporter.Reporter, error) {
+		return rec, nil
+	}
+}
+
+func serve(t *testing.T, trc Tracer, path string, status int) {
+	handler := trc.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span, _ := trc.StartSpanFromContext(r.Context(), "FetchGophers")
+		span.Finish()
+		w.WriteHeader(status)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+}
+
+func Test_ZipkinTracer_SamplesRoutes(t *testing.T) {
+	rec := recorder.NewReporter()
+	trc, _, err := NewZipkinTracer("gopherapi", recordTo(rec), WithSampling(Sampling{
+		Rate:   1,
+		Routes: map[string]float64{"/healthz": 0},
+	}))
+	require.NoError(t, err)
+
+	// WHEN the health checks are not sampled
+	serve(t, trc, "/healthz", http.StatusOK)
+	assert.Empty(t, rec.Flush())
+
+	// THEN the rest of the routes keep the rate of the tracer
+	serve(t, trc, "/gophers", http.StatusOK)
+	assert.Len(t, rec.Flush(), 2)
+}
+
+func Test_ZipkinTracer_SamplesErrors(t *testing.T) {
+	rec := recorder.NewReporter()
+	trc, closer, err := NewZipkinTracer("gopherapi", recordTo(rec), WithSampling(Sampling{
+		Rate:    0,
+		OnError: true,
+	}))
+	require.NoError(t, err)
+
+	// WHEN no trace is sampled the successful requests are not reported
+	serve(t, trc, "/gophers", http.StatusOK)
+	assert.Empty(t, rec.Flush())
+
+	// THEN the traces of the failed requests are reported with all their spans
+	serve(t, trc, "/gophers", http.StatusInternalServerError)
+	spans := rec.Flush()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	assert.Contains(t, spans[1].Tags, "error")
+
+	assert.NoError(t, closer.Close())
+}
+
+func Test_NewZipkinTracer_InvalidSampling(t *testing.T) {
+	_, _, err := NewZipkinTracer("gopherapi", recordTo(recorder.NewReporter()), WithSampling(Sampling{Rate: 2}))
+	assert.Error(t, err)
+
+	_, _, err = NewZipkinTracer("gopherapi", recordTo(recorder.NewReporter()), WithSampling(Sampling{Strategy: "random", Rate: 1}))
+	assert.Error(t, err)
+}
+
+func Test_ErrorSamplingReporter_FlushesExpiredTraces(t *testing.T) {
+	rec := recorder.NewReporter()
+	r := newErrorSamplingReporter(rec, zipkin.NeverSample)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	// GIVEN a failed trace whose local root span is never reported
+	parentID := model.ID(1)
+	failed := model.SpanModel{
+		SpanContext: model.SpanContext{TraceID: model.TraceID{Low: 1}, ID: 2, ParentID: &parentID},
+		Tags:        map[string]string{string(zipkin.TagError): "boom"},
+	}
+	r.Send(failed)
+	assert.Empty(t, rec.Flush())
+
+	// WHEN another trace is recorded once it has expired
+	now = now.Add(pendingTraceTTL)
+	r.Send(model.SpanModel{SpanContext: model.SpanContext{TraceID: model.TraceID{Low: 2}, ID: 3, ParentID: &parentID}})
+
+	// THEN the spans of the expired trace are reported and the rest keep waiting
+	spans := rec.Flush()
+	require.Len(t, spans, 1)
+	assert.Equal(t, failed.TraceID, spans[0].TraceID)
+	assert.Equal(t, 1, r.order.Len())
+}
@@ -1,41 +1,80 @@
 package tracer
 
 import (
-	"fmt"
-
-	"github.com/openzipkin/zipkin-go"
-	"github.com/openzipkin/zipkin-go/model"
-	"github.com/openzipkin/zipkin-go/reporter/http"
+	"context"
+	"net/http"
+	"time"
 )
 
-// NewTracer creates a new tracer with the necessary dependencies
-func NewTracer(serviceName, reporterURL string) (*zipkin.Tracer, error) {
+// Tracer creates the spans of the traces, it is implemented by each exporter (zipkin, OpenTelemetry)
+type Tracer interface {
+	// StartSpanFromContext starts a span child of the one in the context, if any
+	StartSpanFromContext(ctx context.Context, name string) (Span, context.Context)
+	// Middleware traces the HTTP requests, continuing the traces propagated by the callers
+	Middleware(next http.Handler) http.Handler
+}
 
-	reporter := http.NewReporter(fmt.Sprintf("%s/api/v2/spans", reporterURL))
+// Span is a traced operation
+type Span interface {
+	// SetName renames the span, e.g. once the route of the request is known
+	SetName(name string)
+	// Tag adds a key value pair to the span
+	Tag(key, value string)
+	// Annotate records an event happened at the given moment
+	Annotate(t time.Time, value string)
+	// SetError marks the span as failed with the given error
+	SetError(err error)
+	// Finish ends the span
+	Finish()
+	// TraceID returns the hex identifier of the trace
+	TraceID() string
+	// SpanID returns the hex identifier of the span
+	SpanID() string
+}
 
-	endpoint := &model.Endpoint{
-		ServiceName: serviceName,
-	}
+type contextKey struct{}
 
-	// sampler indicate the range of how many traces are going to be sampled
-	sampler, err := zipkin.NewCountingSampler(1)
-	if err != nil {
-		return nil, err
-	}
+// withSpan stores the span in the context, so it can be retrieved with SpanFromContext
+func withSpan(ctx context.Context, span Span) context.Context {
+	return context.WithValue(ctx, contextKey{}, span)
+}
+
+// SpanFromContext returns the current span, or nil when the context is not traced
+func SpanFromContext(ctx context.Context) Span {
+	span, _ := ctx.Value(contextKey{}).(Span)
+	return span
+}
 
-	t, err := zipkin.NewTracer(
-		reporter,
-		zipkin.WithSampler(sampler),
-		zipkin.WithLocalEndpoint(endpoint),
-	)
-	if err != nil {
-		return nil, err
+// TagStatement tags the statement sent to the database on the current span, if any
+func TagStatement(ctx context.Context, statement string) {
+	if span := SpanFromContext(ctx); span != nil {
+		span.Tag("db.statement", statement)
 	}
-	return t, nil
 }
 
+type noopTracer struct{}
+
 // NewNoopTracer creates a new no operational tracer with the necessary dependencies
-func NewNoopTracer() *zipkin.Tracer {
-	noopTracer, _ := zipkin.NewTracer(nil)
-	return noopTracer
+func NewNoopTracer() Tracer {
+	return noopTracer{}
+}
+
+// StartSpanFromContext returns a span which records nothing
+func (noopTracer) StartSpanFromContext(ctx context.Context, _ string) (Span, context.Context) {
+	return noopSpan{}, ctx
 }
+
+// Middleware returns the handler as is
+func (noopTracer) Middleware(next http.Handler) http.Handler {
+	return next
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetName(string)             {}
+func (noopSpan) Tag(string, string)         {}
+func (noopSpan) Annotate(time.Time, string) {}
+func (noopSpan) SetError(error)             {}
+func (noopSpan) Finish()                    {}
+func (noopSpan) TraceID() string            { return "" }
+func (noopSpan) SpanID() string             { return "" }
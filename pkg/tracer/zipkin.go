@@ -0,0 +1,213 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/openzipkin/zipkin-go"
+	zipkinhttp "github.com/openzipkin/zipkin-go/middleware/http"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/openzipkin/zipkin-go/reporter"
+	reporterhttp "github.com/openzipkin/zipkin-go/reporter/http"
+	reporterkafka "github.com/openzipkin/zipkin-go/reporter/kafka"
+	reporterlog "github.com/openzipkin/zipkin-go/reporter/log"
+)
+
+// ZipkinReporter creates the reporter sending the spans to zipkin with the given batching
+type ZipkinReporter func(batching Batching) (reporter.Reporter, error)
+
+// ZipkinHTTP reports the spans to the zipkin server of the given URL
+func ZipkinHTTP(reporterURL string) ZipkinReporter {
+	return func(batching Batching) (reporter.Reporter, error) {
+		var opts []reporterhttp.ReporterOption
+		if batching.Size > 0 {
+			opts = append(opts, reporterhttp.BatchSize(batching.Size))
+		}
+		if batching.Interval > 0 {
+			opts = append(opts, reporterhttp.BatchInterval(batching.Interval))
+		}
+		if batching.Timeout > 0 {
+			opts = append(opts, reporterhttp.Timeout(batching.Timeout))
+		}
+		return reporterhttp.NewReporter(fmt.Sprintf("%s/api/v2/spans", reporterURL), opts...), nil
+	}
+}
+
+// ZipkinLog writes the spans as JSON to the given writer, e.g. the standard output
+func ZipkinLog(w io.Writer) ZipkinReporter {
+	return func(Batching) (reporter.Reporter, error) {
+		return reporterlog.NewReporter(log.New(w, "", 0)), nil
+	}
+}
+
+// ZipkinKafka publishes the spans to the given topic of the Kafka cluster of the given brokers,
+// where the zipkin collector consumes them
+func ZipkinKafka(brokers []string, topic string) ZipkinReporter {
+	return func(batching Batching) (reporter.Reporter, error) {
+		config := sarama.NewConfig()
+		config.Producer.Flush.Messages = batching.Size
+		config.Producer.Flush.Frequency = batching.Interval
+		if batching.Timeout > 0 {
+			config.Producer.Timeout = batching.Timeout
+		}
+
+		producer, err := sarama.NewAsyncProducer(brokers, config)
+		if err != nil {
+			return nil, err
+		}
+		return reporterkafka.NewReporter(brokers, reporterkafka.Producer(producer), reporterkafka.Topic(topic))
+	}
+}
+
+type zipkinTracer struct {
+	tracer     *zipkin.Tracer
+	middleware func(http.Handler) http.Handler
+
+	sampler  zipkin.Sampler
+	prefixes []string
+	routes   map[string]zipkin.Sampler
+	errors   *errorSamplingReporter
+}
+
+// NewZipkinTracer creates a tracer sending the spans to zipkin with the given reporter, the returned
+// closer flushes the spans pending to be reported and must be closed on shutdown
+func NewZipkinTracer(serviceName string, newReporter ZipkinReporter, opts ...Option) (Tracer, io.Closer, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rep, err := newReporter(o.batching)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	endpoint := &model.Endpoint{
+		ServiceName: serviceName,
+	}
+
+	// sampler indicate the range of how many traces are going to be sampled
+	sampler, err := o.sampling.zipkinSampler()
+	if err != nil {
+		_ = rep.Close()
+		return nil, nil, err
+	}
+	routes, err := o.sampling.zipkinRouteSamplers()
+	if err != nil {
+		_ = rep.Close()
+		return nil, nil, err
+	}
+
+	t := &zipkinTracer{
+		sampler:  sampler,
+		prefixes: o.sampling.routePrefixes(),
+		routes:   routes,
+	}
+
+	// to report the traces with errors all of them are recorded, the
+	// sampling decision is taken by the reporter once they finish
+	if o.sampling.OnError {
+		t.errors = newErrorSamplingReporter(rep, sampler)
+		rep = t.errors
+		sampler = zipkin.AlwaysSample
+	}
+
+	t.tracer, err = zipkin.NewTracer(
+		rep,
+		zipkin.WithSampler(sampler),
+		zipkin.WithLocalEndpoint(endpoint),
+	)
+	if err != nil {
+		_ = rep.Close()
+		return nil, nil, err
+	}
+	t.middleware = zipkinhttp.NewServerMiddleware(t.tracer, zipkinhttp.RequestSampler(t.sampleRequest))
+	return t, rep, nil
+}
+
+// StartSpanFromContext starts a zipkin span child of the one in the context, if any
+func (t *zipkinTracer) StartSpanFromContext(ctx context.Context, name string) (Span, context.Context) {
+	sp, ctx := t.tracer.StartSpanFromContext(ctx, name)
+	span := zipkinSpan{sp}
+	return span, withSpan(ctx, span)
+}
+
+// Middleware traces the HTTP requests continuing the B3 headers of the callers
+func (t *zipkinTracer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn := w
+		t.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if sp := zipkin.SpanFromContext(r.Context()); sp != nil {
+				if t.errors != nil {
+					t.errors.decide(sp.Context().TraceID, t.sampled(r, sp.Context().TraceID))
+				}
+				r = r.WithContext(withSpan(r.Context(), zipkinSpan{sp}))
+			}
+			next.ServeHTTP(&zipkinWriter{ResponseWriter: w, conn: conn}, r)
+		})).ServeHTTP(w, r)
+	})
+}
+
+// zipkinWriter is the writer of the zipkin middleware, which keeps the status and size of
+// the response but can't be unwrapped, unwrapping to the writer it wraps instead
+type zipkinWriter struct {
+	http.ResponseWriter
+	conn http.ResponseWriter
+}
+
+// Flush implements http.Flusher so streamed responses keep working
+func (w *zipkinWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the writer wrapped by the zipkin middleware, so http.ResponseController
+// reaches the connection
+func (w *zipkinWriter) Unwrap() http.ResponseWriter {
+	return w.conn
+}
+
+// sampleRequest overrides the sampling decision of the requests to the routes with their own rate
+func (t *zipkinTracer) sampleRequest(r *http.Request) *bool {
+	if t.errors != nil {
+		sampled := true
+		return &sampled
+	}
+
+	if prefix, ok := matchRoute(t.prefixes, r.URL.Path); ok {
+		sampled := t.routes[prefix](0)
+		return &sampled
+	}
+	return nil
+}
+
+// sampled returns the decision for a request recorded to report its errors, taken from
+// the rate of its route, the caller, or the sampler of the tracer in that order
+func (t *zipkinTracer) sampled(r *http.Request, traceID model.TraceID) bool {
+	if prefix, ok := matchRoute(t.prefixes, r.URL.Path); ok {
+		return t.routes[prefix](traceID.Low)
+	}
+	if sc, err := b3.ExtractHTTP(r)(); err == nil && sc != nil && sc.Sampled != nil {
+		return *sc.Sampled
+	}
+	return t.sampler(traceID.Low)
+}
+
+type zipkinSpan struct {
+	span zipkin.Span
+}
+
+func (s zipkinSpan) SetName(name string)                { s.span.SetName(name) }
+func (s zipkinSpan) Tag(key, value string)              { s.span.Tag(key, value) }
+func (s zipkinSpan) Annotate(t time.Time, value string) { s.span.Annotate(t, value) }
+func (s zipkinSpan) SetError(err error)                 { zipkin.TagError.Set(s.span, err.Error()) }
+func (s zipkinSpan) Finish()                            { s.span.Finish() }
+func (s zipkinSpan) TraceID() string                    { return s.span.Context().TraceID.String() }
+func (s zipkinSpan) SpanID() string                     { return s.span.Context().ID.String() }
@@ -0,0 +1,42 @@
+package tracer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ZipkinTracer_ReportsToKafka(t *testing.T) {
+	// GIVEN a Kafka-compatible broker standing in for the cluster
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("zipkin", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	trc, closer, err := NewZipkinTracer("gopherapi", ZipkinKafka([]string{broker.Addr()}, "zipkin"), WithBatching(Batching{
+		Size:     1,
+		Interval: 10 * time.Millisecond,
+	}))
+	require.NoError(t, err)
+
+	// WHEN a request is traced
+	serve(t, trc, "/gophers", http.StatusOK)
+	require.NoError(t, closer.Close())
+
+	// THEN its spans are produced to the zipkin topic
+	var produced int
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produced++
+		}
+	}
+	assert.NotZero(t, produced)
+}
@@ -0,0 +1,29 @@
+package watching
+
+import (
+	"context"
+
+	"github.com/friendsofgo/gopherapi/pkg/auth"
+)
+
+type authorizingService struct {
+	next   Service
+	policy *auth.Policy
+}
+
+// NewAuthorizingService wraps a watching service so only the callers allowed by the policy can use it
+func NewAuthorizingService(next Service, policy *auth.Policy) Service {
+	return &authorizingService{next, policy}
+}
+
+// WatchGophers returns the events of the gophers if the caller has read permission
+func (s *authorizingService) WatchGophers(ctx context.Context) (<-chan Event, <-chan error) {
+	if err := s.policy.Authorize(ctx, auth.PermissionRead); err != nil {
+		events, errs := make(chan Event), make(chan error, 1)
+		errs <- err
+		close(errs)
+		close(events)
+		return events, errs
+	}
+	return s.next.WatchGophers(ctx)
+}
@@ -0,0 +1,114 @@
+package watching
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+// ErrWatcherTooSlow is returned to the watchers which didn't receive the events as fast as they were published
+var ErrWatcherTooSlow = errors.New("watcher fell behind the gopher events")
+
+// subscriptionBuffer is the number of events a watcher can fall behind before being dropped
+const subscriptionBuffer = 64
+
+// EventType is the kind of change of a gopher
+type EventType string
+
+// Declare the types of the gopher events
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a change of a gopher, only its ID is known for the deletions
+type Event struct {
+	Type   EventType
+	Gopher gopher.Gopher
+	At     time.Time
+}
+
+// Service provides watching operations.
+type Service interface {
+	// WatchGophers returns the events of the gophers of the caller's tenant until ctx is done,
+	// once the events are closed the reason the watch ended can be read from the errors
+	WatchGophers(ctx context.Context) (<-chan Event, <-chan error)
+}
+
+// Broker delivers the events published by the repositories to the watchers of the same tenant,
+// it lives in memory so the watchers only see the changes made through the same instance
+type Broker struct {
+	mtx         sync.Mutex
+	subscribers map[string]map[*subscription]struct{}
+}
+
+type subscription struct {
+	events chan Event
+	errs   chan error
+}
+
+// NewBroker creates a broker without watchers
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[string]map[*subscription]struct{})}
+}
+
+// NewService creates a watching service receiving the events of the given broker
+func NewService(broker *Broker) Service {
+	return broker
+}
+
+// Publish delivers the event to the watchers of the tenant of ctx, the watchers whose buffer
+// is full are dropped so a slow one can't block the writes
+func (b *Broker) Publish(ctx context.Context, event Event) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	for s := range b.subscribers[tenant.ID(ctx)] {
+		select {
+		case s.events <- event:
+		default:
+			b.unsubscribe(tenant.ID(ctx), s, ErrWatcherTooSlow)
+		}
+	}
+}
+
+// WatchGophers returns the events of the gophers of the caller's tenant until ctx is done
+func (b *Broker) WatchGophers(ctx context.Context) (<-chan Event, <-chan error) {
+	tenantID := tenant.ID(ctx)
+	s := &subscription{events: make(chan Event, subscriptionBuffer), errs: make(chan error, 1)}
+
+	b.mtx.Lock()
+	if b.subscribers[tenantID] == nil {
+		b.subscribers[tenantID] = make(map[*subscription]struct{})
+	}
+	b.subscribers[tenantID][s] = struct{}{}
+	b.mtx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+		b.unsubscribe(tenantID, s, ctx.Err())
+	}()
+
+	return s.events, s.errs
+}
+
+// unsubscribe closes the subscription with the given reason, it must be called with the lock held
+func (b *Broker) unsubscribe(tenantID string, s *subscription, reason error) {
+	if _, ok := b.subscribers[tenantID][s]; !ok {
+		return
+	}
+	delete(b.subscribers[tenantID], s)
+	if len(b.subscribers[tenantID]) == 0 {
+		delete(b.subscribers, tenantID)
+	}
+	s.errs <- reason
+	close(s.errs)
+	close(s.events)
+}
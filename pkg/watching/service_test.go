@@ -0,0 +1,61 @@
+package watching
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gopher "github.com/friendsofgo/gopherapi/pkg"
+	"github.com/friendsofgo/gopherapi/pkg/storage/inmem"
+	"github.com/friendsofgo/gopherapi/pkg/tenant"
+)
+
+func Test_Broker_DeliversEventsOfTheTenant(t *testing.T) {
+	broker := NewBroker()
+	repo := NewRepository(inmem.NewRepository(nil), broker)
+
+	ctx, cancel := context.WithCancel(tenant.WithTenant(context.Background(), "acme"))
+	events, errs := NewService(broker).WatchGophers(ctx)
+
+	// WHEN gophers are changed in both tenants
+	require.NoError(t, repo.CreateGopher(tenant.WithTenant(context.Background(), "other"), gopher.New("01D3XZ3ZHCP3KG9VT4FGAD8KDR", "Jenny", "", 18)))
+	require.NoError(t, repo.CreateGopher(ctx, gopher.New("01D3XZ7CN92AKS9HAPSZ4D5DP9", "Billy", "", 35)))
+	require.NoError(t, repo.UpdateGopher(ctx, "01D3XZ7CN92AKS9HAPSZ4D5DP9", *gopher.New("", "Billy", "", 36)))
+	require.NoError(t, repo.DeleteGopher(ctx, "01D3XZ7CN92AKS9HAPSZ4D5DP9"))
+	cancel()
+
+	// THEN the watcher only receives the changes of its tenant
+	var got []Event
+	for event := range events {
+		got = append(got, event)
+	}
+	require.Len(t, got, 3)
+	assert.Equal(t, EventCreated, got[0].Type)
+	assert.Equal(t, EventUpdated, got[1].Type)
+	assert.Equal(t, 36, got[1].Gopher.Age)
+	assert.Equal(t, "01D3XZ7CN92AKS9HAPSZ4D5DP9", got[1].Gopher.ID)
+	assert.Equal(t, EventDeleted, got[2].Type)
+	assert.ErrorIs(t, <-errs, context.Canceled)
+}
+
+func Test_Broker_DropsSlowWatchers(t *testing.T) {
+	broker := NewBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, errs := broker.WatchGophers(ctx)
+
+	// WHEN the watcher doesn't read the events
+	for i := 0; i <= subscriptionBuffer; i++ {
+		broker.Publish(context.Background(), Event{Type: EventCreated})
+	}
+
+	// THEN it gets the buffered events before being told it fell behind
+	n := 0
+	for range events {
+		n++
+	}
+	assert.Equal(t, subscriptionBuffer, n)
+	assert.ErrorIs(t, <-errs, ErrWatcherTooSlow)
+}